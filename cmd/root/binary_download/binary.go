@@ -19,6 +19,7 @@ type Handler struct {
 	Version      string
 	Token        string
 	Provider     string
+	APIURL       string
 	OutFile      string
 	Platform     string
 }
@@ -30,7 +31,8 @@ func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
 
 	cmd.Args = cobra.ExactArgs(0)
 
-	cmd.PersistentFlags().StringVar(&me.Provider, "provider", "github", "Provider to install from")
+	cmd.PersistentFlags().StringVar(&me.Provider, "provider", "github", "Provider to install from, one of [github, gitea]")
+	cmd.PersistentFlags().StringVar(&me.APIURL, "api-url", "", "API root of the provider, required for gitea, e.g. https://codeberg.org/api/v1")
 	cmd.PersistentFlags().StringVar(&me.Repository, "repository", "", "Repository to install from")
 	cmd.PersistentFlags().StringVar(&me.Organization, "organization", "", "Organization to install from")
 	cmd.PersistentFlags().StringVar(&me.Version, "version", "latest", "Version to install")
@@ -48,6 +50,10 @@ func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []strin
 		return errors.Errorf("Repository and organization must be specified")
 	}
 
+	if me.Provider == "gitea" && me.APIURL == "" {
+		return errors.Errorf("api-url must be specified for gitea")
+	}
+
 	return nil
 
 }
@@ -56,21 +62,20 @@ func (me *Handler) Run(ctx context.Context) error {
 	var fle afero.File
 	var err error
 
+	var plat *buildrc.Platform
+	if me.Platform == "runtime.GOOS/runtime.GOARCH" {
+		plat = buildrc.GetGoPlatform(ctx)
+	} else {
+
+		plat, err = buildrc.NewPlatformFromFullString(me.Platform)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch me.Provider {
 	case "github":
 		{
-
-			var plat *buildrc.Platform
-			if me.Platform == "runtime.GOOS/runtime.GOARCH" {
-				plat = buildrc.GetGoPlatform(ctx)
-			} else {
-
-				plat, err = buildrc.NewPlatformFromFullString(me.Platform)
-				if err != nil {
-					return err
-				}
-			}
-
 			fle, err = install.DownloadGithubReleaseWithOptions(ctx, afero.NewOsFs(), &install.DownloadGithubReleaseOptions{
 				Org:      me.Organization,
 				Name:     me.Repository,
@@ -82,6 +87,20 @@ func (me *Handler) Run(ctx context.Context) error {
 				return err
 			}
 		}
+	case "gitea":
+		{
+			fle, err = install.DownloadGiteaReleaseWithOptions(ctx, afero.NewOsFs(), &install.DownloadGiteaReleaseOptions{
+				BaseURL:  me.APIURL,
+				Org:      me.Organization,
+				Name:     me.Repository,
+				Version:  me.Version,
+				Token:    me.Token,
+				Platform: plat,
+			})
+			if err != nil {
+				return err
+			}
+		}
 	default:
 		{
 			return errors.Errorf("Unknown provider: %s", me.Provider)
@@ -0,0 +1,93 @@
+package changelog
+
+import (
+	"context"
+	"os"
+
+	"github.com/go-faster/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/changelog"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/snake"
+)
+
+var _ snake.Snakeable = (*Handler)(nil)
+
+type Handler struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Version    string `json:"version"`
+	Format     string `json:"format"`
+	GroupBy    string `json:"group-by"`
+	MainBranch string `json:"main-branch"`
+	Prepend    string `json:"prepend"`
+}
+
+func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "generate release notes from the commits between two refs",
+	}
+
+	cmd.Args = cobra.ExactArgs(0)
+
+	cmd.Flags().StringVarP(&me.From, "from", "", "", "The tag to start after (default the latest semver tag before --to)")
+	cmd.Flags().StringVarP(&me.To, "to", "", "HEAD", "The ref to end at")
+	cmd.Flags().StringVarP(&me.Version, "version", "", "", "The version to title the notes with (default the semver tag of --to, or Unreleased)")
+	cmd.Flags().StringVarP(&me.Format, "format", "f", string(changelog.FormatMarkdown), "The output format, one of [markdown, json, keep-a-changelog]")
+	cmd.Flags().StringVarP(&me.GroupBy, "group-by", "g", string(changelog.GroupByType), "How to group entries, one of [type, pull-request]")
+	cmd.Flags().StringVarP(&me.MainBranch, "main-branch", "", "", "The branch pull requests are merged into, overrides the main-branch in .buildrc (default main)")
+	cmd.Flags().StringVarP(&me.Prepend, "prepend", "", "", "Prepend the keep-a-changelog section to this file, e.g. CHANGELOG.md, instead of printing it")
+
+	return cmd
+}
+
+func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []string) error {
+
+	if me.Prepend != "" && me.Format != string(changelog.FormatKeepAChangelog) {
+		return errors.Errorf("--prepend requires --format=%s", changelog.FormatKeepAChangelog)
+	}
+
+	return nil
+}
+
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, prp git.PullRequestProvider, fls afero.Fs) error {
+
+	brc, err := buildrc.LoadBuildrc(ctx, gitp)
+	if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+		return err
+	}
+
+	if me.MainBranch != "" {
+		brc.MainBranchRaw = me.MainBranch
+	}
+
+	cl, err := changelog.Generate(ctx, gitp, prp, &changelog.Options{
+		From:       me.From,
+		To:         me.To,
+		GroupBy:    changelog.GroupBy(me.GroupBy),
+		Version:    me.Version,
+		MainBranch: brc.MainBranch(),
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := cl.Render(changelog.Format(me.Format))
+	if err != nil {
+		return err
+	}
+
+	if me.Prepend == "" {
+		cmd.Print(out)
+		return nil
+	}
+
+	existing, err := afero.ReadFile(fls, me.Prepend)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return afero.WriteFile(fls, me.Prepend, []byte(changelog.Prepend(string(existing), out)), 0644)
+}
@@ -0,0 +1,48 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	cienv "github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/snake"
+)
+
+var _ snake.Snakeable = (*Handler)(nil)
+
+type Handler struct {
+}
+
+func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "print the detected ci environment",
+	}
+
+	cmd.Args = cobra.ExactArgs(0)
+
+	return cmd
+}
+
+func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []string) error {
+
+	return nil
+
+}
+
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, det cienv.Detector) error {
+
+	env, err := det.Detect(ctx)
+	if err != nil {
+		return err
+	}
+
+	byt, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("%s\n", string(byt))
+
+	return nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/go-faster/errors"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
 	"github.com/walteh/buildrc/pkg/git"
 	"github.com/walteh/snake"
 )
@@ -14,7 +17,9 @@ import (
 var _ snake.Snakeable = (*Handler)(nil)
 
 type Handler struct {
-	FilesDir string `json:"files-dir"`
+	FilesDir        string `json:"files-dir"`
+	Remote          string `json:"remote"`
+	GoPseudoVersion bool   `json:"go-pseudo-version"`
 }
 
 func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
@@ -25,6 +30,8 @@ func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
 	cmd.Args = cobra.ExactArgs(0)
 
 	cmd.Flags().StringVarP(&me.FilesDir, "files-dir", "", "", "The directory to write the files to")
+	cmd.Flags().BoolVarP(&me.GoPseudoVersion, "go-pseudo-version", "", false, "Use the version the go command resolves HEAD of the module in go.mod to, a pseudo-version if HEAD is not tagged")
+	cmd.Flags().StringVarP(&me.Remote, "remote", "", "", "The git remote to read the repository from, overrides the remote in .buildrc (default origin)")
 
 	return cmd
 }
@@ -35,9 +42,23 @@ func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []strin
 
 }
 
-func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, fls afero.Fs) error {
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, fls afero.Fs, det ci.Detector) error {
+
+	brc, err := buildrc.LoadBuildrc(ctx, gitp)
+	if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+		return err
+	}
+
+	if me.Remote != "" {
+		brc.RemoteRaw = me.Remote
+	}
+
+	env, err := det.Detect(ctx)
+	if err != nil {
+		return err
+	}
 
-	revision, err := buildrc.GetBuildrcJSON(ctx, gitp, nil)
+	revision, err := buildrc.GetBuildrcJSON(ctx, gitp, brc, &buildrc.GetVersionOpts{Auto: true, PatchIndicator: "patch", CI: env, GoPseudoVersion: me.GoPseudoVersion})
 	if err != nil {
 		return err
 	}
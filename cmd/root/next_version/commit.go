@@ -2,9 +2,14 @@ package next_version
 
 import (
 	"context"
+	"encoding/json"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
 
 	"github.com/spf13/cobra"
 	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
 	"github.com/walteh/buildrc/pkg/git"
 	"github.com/walteh/snake"
 )
@@ -19,8 +24,19 @@ const (
 	CommitTypeRelease CommitType = "release"
 )
 
+const (
+	StrategyDefault = "default"
+	StrategyPRAware = "pr-aware"
+)
+
+const (
+	ExplainText = "text"
+	ExplainJSON = "json"
+)
+
 type Handler struct {
 	Type                  CommitType `json:"type"`
+	BumpStrategy          string     `json:"bump-strategy"`
 	PatchIndicator        string     `json:"patch-indicator"`
 	PRNumber              uint64     `json:"pr-number"`
 	CommitMessageOverride string     `json:"commit-message-override"`
@@ -28,6 +44,13 @@ type Handler struct {
 	Patch                 bool       `json:"patch"`
 	Auto                  bool       `json:"auto"`
 	NoV                   bool       `json:"no-v"`
+	Strategy              string     `json:"strategy"`
+	MainBranch            string     `json:"main-branch"`
+	Channel               string     `json:"channel"`
+	GoPseudoVersion       bool       `json:"go-pseudo-version"`
+	Format                string     `json:"format"`
+	Explain               bool       `json:"explain"`
+	ExplainFormat         string     `json:"explain-format"`
 }
 
 func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
@@ -37,6 +60,7 @@ func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
 
 	cmd.Args = cobra.ExactArgs(0)
 
+	cmd.Flags().StringVarP(&me.BumpStrategy, "bump-strategy", "b", string(buildrc.BumpStrategyPatchIndicator), "How to pick the bump for a release: 'patch-indicator' or 'conventional'")
 	cmd.Flags().StringVarP(&me.PatchIndicator, "patch-indicator", "i", "patch", "The ref to calculate the patch from")
 	cmd.Flags().StringVarP((*string)(&me.Type), "type", "t", "local", "The type of commit to calculate")
 	cmd.Flags().Uint64VarP(&me.PRNumber, "pr-number", "n", 0, "The pr number to set")
@@ -45,28 +69,80 @@ func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
 
 	cmd.Flags().BoolVarP(&me.Patch, "patch", "p", false, "shortcut for --patch-indicator=x --commit-message-override=x")
 
-	cmd.Flags().BoolVarP(&me.Auto, "auto", "a", false, "shortcut for if CI != 'true' then local else if '--pr-number' > 0 then pr")
+	cmd.Flags().BoolVarP(&me.Auto, "auto", "a", false, "detect the type: pr if the ci environment or refs name a pull request, local if the tree is dirty, else release")
 
 	cmd.Flags().BoolVarP(&me.NoV, "no-v", "", false, "do not prefix with 'v'")
 
+	cmd.Flags().StringVarP(&me.Strategy, "strategy", "s", StrategyDefault, "How to calculate the version: 'default' or 'pr-aware', which compares HEAD against the main branch and its pull requests")
+	cmd.Flags().StringVarP(&me.MainBranch, "main-branch", "", "", "The branch releases are cut from, overrides the main-branch in .buildrc (default main)")
+	cmd.Flags().BoolVarP(&me.GoPseudoVersion, "go-pseudo-version", "", false, "print the version the go command resolves HEAD of the module in go.mod to, a pseudo-version if HEAD is not tagged")
+	cmd.Flags().StringVarP(&me.Channel, "channel", "", "", "The prerelease channel to cut, like beta or rc, overrides the channel in .buildrc")
+	cmd.Flags().BoolVarP(&me.Explain, "explain", "", false, "print how the version was calculated instead of the version")
+	cmd.Flags().StringVarP(&me.ExplainFormat, "explain-format", "", ExplainText, "How to print the explanation: 'text' or 'json'")
+	cmd.Flags().StringVarP(&me.Format, "format", "", string(buildrc.FormatSemver), "How to render the version: 'semver', 'docker', 'pep440', 'debian', 'rpm', 'npm', 'maven' or 'go-module'")
+
 	return cmd
 }
 
 func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []string) error {
 
-	return nil
+	if me.Channel != "" {
+		if err := buildrc.ValidateChannel(me.Channel); err != nil {
+			return err
+		}
+	}
+
+	if err := buildrc.ValidateBumpStrategy(buildrc.BumpStrategy(me.BumpStrategy)); err != nil {
+		return err
+	}
+
+	switch me.Strategy {
+	case StrategyDefault, StrategyPRAware:
+	default:
+		return errors.Errorf("unknown strategy '%s', must be one of [%s, %s]", me.Strategy, StrategyDefault, StrategyPRAware)
+	}
+
+	if _, err := buildrc.ParseFormat(me.Format); err != nil {
+		return err
+	}
+
+	switch me.ExplainFormat {
+	case ExplainText, ExplainJSON:
+	default:
+		return errors.Errorf("unknown explain format '%s', must be one of [%s, %s]", me.ExplainFormat, ExplainText, ExplainJSON)
+	}
+
+	if me.GoPseudoVersion && me.Strategy == StrategyPRAware {
+		return errors.Errorf("--go-pseudo-version can not be used with the %s strategy", StrategyPRAware)
+	}
 
+	return nil
 }
 
-func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider) error {
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, det ci.Detector, prp git.PullRequestProvider) error {
 
 	brc, err := buildrc.LoadBuildrc(ctx, gitp)
+	if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+		return err
+	}
+
+	if me.MainBranch != "" {
+		brc.MainBranchRaw = me.MainBranch
+	}
+
+	env, err := det.Detect(ctx)
 	if err != nil {
 		return err
 	}
 
-	vers, err := buildrc.GetVersion(ctx, gitp, brc, &buildrc.GetVersionOpts{
+	var exp *buildrc.Explanation
+	if me.Explain {
+		exp = &buildrc.Explanation{}
+	}
+
+	opts := &buildrc.GetVersionOpts{
 		Type:                  buildrc.CommitType(me.Type),
+		BumpStrategy:          buildrc.BumpStrategy(me.BumpStrategy),
 		PatchIndicator:        me.PatchIndicator,
 		PRNumber:              me.PRNumber,
 		CommitMessageOverride: me.CommitMessageOverride,
@@ -74,14 +150,61 @@ func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProv
 		Patch:                 me.Patch,
 		Auto:                  me.Auto,
 		ExcludeV:              me.NoV,
-	})
-
+		Channel:               me.Channel,
+		GoPseudoVersion:       me.GoPseudoVersion,
+		CI:                    env,
+		Explain:               exp,
+		PRAware:               me.Strategy == StrategyPRAware,
+		PullRequests:          prp,
+	}
+	vers, err := buildrc.GetVersion(ctx, gitp, brc, opts)
 	if err != nil {
 		return err
 	}
 
-	cmd.Printf("%s\n", vers)
+	if opts.PRAware {
+		// stderr keeps stdout to the version, so scripts capturing it are not broken
+		cmd.PrintErrf("%s strategy: %s\n", StrategyPRAware, opts.PRAwareStrategy)
+	}
+
+	return me.print(cmd, vers, exp)
 
-	return nil
+}
+
+// print writes the version rendered in the requested format, semver keeps the v prefix unless --no-v is set.
+// With --explain the explanation of the version is written instead.
+func (me *Handler) print(cmd *cobra.Command, vers string, exp *buildrc.Explanation) error {
+
+	format := buildrc.Format(me.Format)
+
+	if format != buildrc.FormatSemver {
+		parsed, err := semver.NewVersion(vers)
+		if err != nil {
+			return err
+		}
 
+		vers, err = buildrc.Render(parsed, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !me.Explain {
+		cmd.Printf("%s\n", vers)
+		return nil
+	}
+
+	exp.Format, exp.Rendering = format, vers
+
+	switch me.ExplainFormat {
+	case ExplainJSON:
+		byt, err := json.MarshalIndent(exp, "", "\t")
+		if err != nil {
+			return err
+		}
+		cmd.Printf("%s\n", string(byt))
+		return nil
+	default:
+		return exp.WriteText(cmd.OutOrStdout())
+	}
 }
@@ -0,0 +1,287 @@
+package root
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	cienv "github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/forge"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/snake"
+)
+
+const (
+	ProviderAuto   = "auto"
+	ProviderCI     = "ci"
+	ProviderGitHub = string(forge.GitHub)
+	ProviderGitLab = string(forge.GitLab)
+	ProviderGitea  = string(forge.Gitea)
+)
+
+// newForge returns the api client for the provider of the named remote. In auto mode the provider is picked
+// from the host of the remote, and nil is returned if the remote is missing or not hosted on a known forge.
+func newForge(ctx context.Context, gpv git.GitProvider, det cienv.Detector, name string, provider string) (forge.Forge, error) {
+	switch provider {
+	case ProviderCI:
+		return nil, nil
+	case ProviderAuto, ProviderGitHub, ProviderGitLab, ProviderGitea:
+	default:
+		return nil, errors.Errorf("unknown provider '%s', must be one of [%s, %s, %s, %s, %s]", provider, ProviderAuto, ProviderCI, ProviderGitHub, ProviderGitLab, ProviderGitea)
+	}
+
+	raw, err := gpv.GetRemoteURL(ctx, name)
+	if err != nil {
+		if provider == ProviderAuto {
+			zerolog.Ctx(ctx).Debug().Err(err).Msg("no remote to pick a provider from")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	remote, err := git.ParseRemoteURL(raw)
+	if err != nil {
+		if provider == ProviderAuto {
+			zerolog.Ctx(ctx).Debug().Err(err).Msg("no remote to pick a provider from")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	fname := forge.Name(provider)
+	if provider == ProviderAuto {
+		fname = ""
+	}
+
+	opts, ok := forge.Detect(remote, fname, os.Getenv)
+	if !ok {
+		return nil, nil
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("host", remote.Host).Msgf("using %s provider", opts.Name)
+
+	return opts.NewClient(ctx, gpv, det)
+}
+
+// noForge stands in for the release and metadata providers when there is no forge, so commands
+// that need one fail with git.ErrNoForge instead of a missing binding.
+type noForge struct {
+	reason string
+}
+
+var _ git.ReleaseProvider = (*noForge)(nil)
+var _ git.RemoteRepositoryMetadataProvider = (*noForge)(nil)
+
+func (me *noForge) err() error {
+	return errors.Wrap(git.ErrNoForge, me.reason)
+}
+
+func (me *noForge) UploadReleaseArtifact(context.Context, string, string, afero.File) error {
+	return me.err()
+}
+
+func (me *noForge) DownloadReleaseArtifact(context.Context, string, string, afero.Fs) (afero.File, error) {
+	return nil, me.err()
+}
+
+func (me *noForge) DeleteReleaseArtifact(context.Context, string, string) error {
+	return me.err()
+}
+
+func (me *noForge) HasReleaseArtifact(context.Context, string, string) (bool, error) {
+	return false, me.err()
+}
+
+func (me *noForge) GetReleaseByTag(context.Context, string) (*git.Release, error) {
+	return nil, me.err()
+}
+
+func (me *noForge) GetReleaseByID(context.Context, string) (*git.Release, error) {
+	return nil, me.err()
+}
+
+func (me *noForge) TagRelease(context.Context, git.GitProvider, *semver.Version) (*git.Release, error) {
+	return nil, me.err()
+}
+
+func (me *noForge) ListRecentReleases(context.Context, int) ([]*git.Release, error) {
+	return nil, me.err()
+}
+
+func (me *noForge) TakeReleaseOutOfDraft(context.Context, string) error {
+	return me.err()
+}
+
+func (me *noForge) GetRemoteRepositoryMetadata(context.Context) (*git.RemoteRepositoryMetadata, error) {
+	return nil, me.err()
+}
+
+// providers is the forge, or the fallbacks when there is none, for each remote provider binding.
+type providers struct {
+	prs  git.PullRequestProvider
+	rels git.ReleaseProvider
+	meta git.RemoteRepositoryMetadataProvider
+}
+
+// resolveProviders picks the remote providers. Pull requests fall back to the ci environment
+// when there is no forge, and the release and metadata providers report git.ErrNoForge.
+func resolveProviders(ctx context.Context, gpv git.GitProvider, det cienv.Detector, provider string) (*providers, error) {
+	// the forge is picked from the remote the repository is published to
+	brc, err := buildrc.LoadBuildrc(ctx, gpv)
+	if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+		return nil, err
+	}
+
+	fg, err := newForge(ctx, gpv, det, brc.Remote(), provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if fg == nil {
+		nf := &noForge{reason: "the remote is not hosted on a known forge, pick one with --provider"}
+		if provider == ProviderCI {
+			nf.reason = "the ci provider only reads pull requests from the ci environment"
+		}
+		return &providers{prs: cienv.NewPullRequestProvider(det), rels: nf, meta: nf}, nil
+	}
+
+	return &providers{prs: fg, rels: fg, meta: fg}, nil
+}
+
+// lazyProviders resolves the remote providers on first use, so a broken .buildrc or --provider
+// only fails the commands that talk to a forge.
+type lazyProviders struct {
+	once    sync.Once
+	resolve func() (*providers, error)
+	res     *providers
+	err     error
+}
+
+var _ git.PullRequestProvider = (*lazyProviders)(nil)
+var _ git.ReleaseProvider = (*lazyProviders)(nil)
+var _ git.RemoteRepositoryMetadataProvider = (*lazyProviders)(nil)
+
+func (me *lazyProviders) load() (*providers, error) {
+	me.once.Do(func() {
+		me.res, me.err = me.resolve()
+	})
+	return me.res, me.err
+}
+
+func (me *lazyProviders) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.prs.ListRecentPullRequests(ctx, head)
+}
+
+func (me *lazyProviders) ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*git.PullRequest, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.prs.ListPullRequestsUpdatedSince(ctx, base, since)
+}
+
+func (me *lazyProviders) UploadReleaseArtifact(ctx context.Context, id string, name string, file afero.File) error {
+	p, err := me.load()
+	if err != nil {
+		return err
+	}
+	return p.rels.UploadReleaseArtifact(ctx, id, name, file)
+}
+
+func (me *lazyProviders) DownloadReleaseArtifact(ctx context.Context, id string, name string, filesystem afero.Fs) (afero.File, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.rels.DownloadReleaseArtifact(ctx, id, name, filesystem)
+}
+
+func (me *lazyProviders) DeleteReleaseArtifact(ctx context.Context, id string, name string) error {
+	p, err := me.load()
+	if err != nil {
+		return err
+	}
+	return p.rels.DeleteReleaseArtifact(ctx, id, name)
+}
+
+func (me *lazyProviders) HasReleaseArtifact(ctx context.Context, id string, name string) (bool, error) {
+	p, err := me.load()
+	if err != nil {
+		return false, err
+	}
+	return p.rels.HasReleaseArtifact(ctx, id, name)
+}
+
+func (me *lazyProviders) GetReleaseByTag(ctx context.Context, tag string) (*git.Release, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.rels.GetReleaseByTag(ctx, tag)
+}
+
+func (me *lazyProviders) GetReleaseByID(ctx context.Context, id string) (*git.Release, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.rels.GetReleaseByID(ctx, id)
+}
+
+func (me *lazyProviders) TagRelease(ctx context.Context, prov git.GitProvider, vers *semver.Version) (*git.Release, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.rels.TagRelease(ctx, prov, vers)
+}
+
+func (me *lazyProviders) ListRecentReleases(ctx context.Context, limit int) ([]*git.Release, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.rels.ListRecentReleases(ctx, limit)
+}
+
+func (me *lazyProviders) TakeReleaseOutOfDraft(ctx context.Context, id string) error {
+	p, err := me.load()
+	if err != nil {
+		return err
+	}
+	return p.rels.TakeReleaseOutOfDraft(ctx, id)
+}
+
+func (me *lazyProviders) GetRemoteRepositoryMetadata(ctx context.Context) (*git.RemoteRepositoryMetadata, error) {
+	p, err := me.load()
+	if err != nil {
+		return nil, err
+	}
+	return p.meta.GetRemoteRepositoryMetadata(ctx)
+}
+
+// bindProviders binds the remote providers. They are resolved when a command first uses one,
+// commands that never talk to a forge do not load .buildrc or check --provider for it.
+func bindProviders(ctx context.Context, gpv git.GitProvider, det cienv.Detector, provider string) context.Context {
+	lp := &lazyProviders{
+		resolve: func() (*providers, error) {
+			return resolveProviders(ctx, gpv, det, provider)
+		},
+	}
+
+	ctx = snake.Bind(ctx, (*git.PullRequestProvider)(nil), lp)
+	ctx = snake.Bind(ctx, (*git.ReleaseProvider)(nil), lp)
+	ctx = snake.Bind(ctx, (*git.RemoteRepositoryMetadataProvider)(nil), lp)
+
+	return ctx
+}
@@ -0,0 +1,111 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/release"
+	"github.com/walteh/snake"
+)
+
+var _ snake.Snakeable = (*Handler)(nil)
+
+type Handler struct {
+	Artifacts  []string      `json:"artifacts"`
+	Version    string        `json:"version"`
+	Retries    int           `json:"retries"`
+	RetryDelay time.Duration `json:"retry-delay"`
+	Channel    string        `json:"channel"`
+}
+
+func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "publish a release of HEAD with its artifacts",
+	}
+
+	cmd.Args = cobra.ExactArgs(0)
+
+	cmd.Flags().StringSliceVarP(&me.Artifacts, "artifacts", "a", []string{}, "Globs of the files to release, relative to the working directory, e.g. 'dist/**'. Each is archived and checksummed")
+	cmd.Flags().StringVarP(&me.Version, "version", "", "", "The version to release, calculated like the full command when empty, which refuses pull request and local versions")
+	cmd.Flags().IntVarP(&me.Retries, "retries", "", release.DefaultRetries, "How many times to try each upload")
+	cmd.Flags().DurationVarP(&me.RetryDelay, "retry-delay", "", release.DefaultRetryDelay, "How long to wait between upload attempts")
+	cmd.Flags().StringVarP(&me.Channel, "channel", "", "", "The prerelease channel to cut, like beta or rc, overrides the channel in .buildrc")
+
+	return cmd
+}
+
+func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []string) error {
+
+	if len(me.Artifacts) == 0 {
+		return errors.Errorf("at least one artifact glob must be specified")
+	}
+
+	if me.Version != "" {
+		if _, err := semver.NewVersion(me.Version); err != nil {
+			return errors.Wrapf(err, "invalid version '%s'", me.Version)
+		}
+	}
+
+	if me.Channel != "" {
+		if err := buildrc.ValidateChannel(me.Channel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, fls afero.Fs, det ci.Detector, relp git.ReleaseProvider) error {
+
+	raw := me.Version
+
+	if raw == "" {
+		brc, err := buildrc.LoadBuildrc(ctx, gitp)
+		if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+			return err
+		}
+
+		env, err := det.Detect(ctx)
+		if err != nil {
+			return err
+		}
+
+		raw, err = buildrc.GetReleaseVersion(ctx, gitp, brc, env, me.Channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	vers, err := semver.NewVersion(raw)
+	if err != nil {
+		return errors.Wrapf(err, "invalid version '%s'", raw)
+	}
+
+	res, err := release.Publish(ctx, fls, gitp, relp, &release.Options{
+		Version:    vers,
+		Artifacts:  me.Artifacts,
+		Retries:    me.Retries,
+		RetryDelay: me.RetryDelay,
+	})
+	if err != nil {
+		return err
+	}
+
+	byt, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("%s\n", byt)
+
+	return nil
+}
@@ -9,11 +9,16 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/walteh/buildrc/cmd/root/binary_download"
 	"github.com/walteh/buildrc/cmd/root/binary_install"
+	"github.com/walteh/buildrc/cmd/root/changelog"
+	"github.com/walteh/buildrc/cmd/root/ci"
 	"github.com/walteh/buildrc/cmd/root/diff"
 
 	"github.com/walteh/buildrc/cmd/root/full"
 	"github.com/walteh/buildrc/cmd/root/next_version"
+	"github.com/walteh/buildrc/cmd/root/release"
 	"github.com/walteh/buildrc/cmd/root/revision"
+	"github.com/walteh/buildrc/cmd/root/tag"
+	cienv "github.com/walteh/buildrc/pkg/ci"
 	"github.com/walteh/buildrc/pkg/git"
 
 	myversion "github.com/walteh/buildrc/version"
@@ -26,6 +31,9 @@ type Root struct {
 	Version bool
 	File    string
 	GitDir  string
+
+	GitBackend string
+	Provider   string
 }
 
 var _ snake.Snakeable = (*Root)(nil)
@@ -40,6 +48,8 @@ func (me *Root) BuildCommand(ctx context.Context) *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(&me.Debug, "debug", "d", false, "Print debug output")
 	cmd.PersistentFlags().BoolVarP(&me.Version, "version", "v", false, "Print version and exit")
 	cmd.PersistentFlags().StringVar(&me.GitDir, "git-dir", ".", "The git directory to use")
+	cmd.PersistentFlags().StringVar(&me.GitBackend, "git-backend", git.GitBackendGoGit, "The git backend to use, one of [go-git, exec]")
+	cmd.PersistentFlags().StringVar(&me.Provider, "provider", ProviderAuto, "The forge to query for pull requests and releases, one of [auto, ci, github, gitlab, gitea]. auto picks one from the remote host")
 
 	snake.MustNewCommand(ctx, cmd, "next-version", &next_version.Handler{})
 	snake.MustNewCommand(ctx, cmd, "revision", &revision.Handler{})
@@ -47,8 +57,14 @@ func (me *Root) BuildCommand(ctx context.Context) *cobra.Command {
 	snake.MustNewCommand(ctx, cmd, "binary-install", &binary_install.Handler{})
 	snake.MustNewCommand(ctx, cmd, "diff", &diff.Handler{})
 	snake.MustNewCommand(ctx, cmd, "binary-download", &binary_download.Handler{})
+	snake.MustNewCommand(ctx, cmd, "ci", &ci.Handler{})
+	snake.MustNewCommand(ctx, cmd, "release", &release.Handler{})
+	snake.MustNewCommand(ctx, cmd, "changelog", &changelog.Handler{})
+	snake.MustNewCommand(ctx, cmd, "tag", &tag.Handler{})
 
-	cmd.SetOutput(os.Stdout)
+	// results go to stdout for scripts to capture, notes about how they were reached to stderr
+	cmd.SetOut(os.Stdout)
+	cmd.SetErr(os.Stderr)
 
 	cmd.SilenceUsage = true
 
@@ -78,13 +94,18 @@ func (me *Root) ParseArguments(ctx context.Context, cmd *cobra.Command, _ []stri
 
 	root := afero.NewOsFs()
 
-	gpv, err := git.NewGitGoGitProvider(afero.NewOsFs(), me.GitDir)
+	gpv, err := git.NewGitProvider(afero.NewOsFs(), me.GitDir, me.GitBackend)
 	if err != nil {
 		return err
 	}
 
 	ctx = snake.Bind(ctx, (*git.GitProvider)(nil), gpv)
 	ctx = snake.Bind(ctx, (*afero.Fs)(nil), root)
+	det := cienv.NewEnvDetector(os.Getenv)
+
+	ctx = snake.Bind(ctx, (*cienv.Detector)(nil), det)
+
+	ctx = bindProviders(ctx, gpv, det, me.Provider)
 
 	cmd.SetContext(ctx)
 
@@ -0,0 +1,168 @@
+package tag
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/forge"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/snake"
+)
+
+// SigningKeyPassphraseEnv is the environment variable holding the passphrase of an encrypted signing key.
+const SigningKeyPassphraseEnv = "BUILDRC_SIGNING_KEY_PASSPHRASE"
+
+var _ snake.Snakeable = (*Handler)(nil)
+
+type Handler struct {
+	Version     string `json:"version"`
+	Message     string `json:"message"`
+	SignKey     string `json:"sign-key"`
+	TaggerName  string `json:"tagger-name"`
+	TaggerEmail string `json:"tagger-email"`
+	Push        bool   `json:"push"`
+	Remote      string `json:"remote"`
+	Channel     string `json:"channel"`
+}
+
+func (me *Handler) BuildCommand(_ context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "create and push an annotated tag of the version of HEAD",
+	}
+
+	cmd.Args = cobra.ExactArgs(0)
+
+	cmd.Flags().StringVarP(&me.Version, "version", "", "", "The version to tag, calculated like the full command when empty, which refuses pull request and local versions")
+	cmd.Flags().StringVarP(&me.Message, "message", "m", "", "The tag message (default a summary of the commits since the previous version)")
+	cmd.Flags().StringVarP(&me.SignKey, "sign-key", "", "", "Path to an armored gpg or an openssh private key to sign the tag with, its passphrase is read from "+SigningKeyPassphraseEnv)
+	cmd.Flags().StringVarP(&me.TaggerName, "tagger-name", "", "", "The tagger name (default user.name from the git config, or the committer of HEAD)")
+	cmd.Flags().StringVarP(&me.TaggerEmail, "tagger-email", "", "", "The tagger email (default user.email from the git config, or the committer of HEAD)")
+	cmd.Flags().BoolVarP(&me.Push, "push", "", true, "Push the tag to the remote")
+	cmd.Flags().StringVarP(&me.Remote, "remote", "", "", "The remote to push the tag to, overrides the remote in .buildrc (default origin)")
+	cmd.Flags().StringVarP(&me.Channel, "channel", "", "", "The prerelease channel to cut, like beta or rc, overrides the channel in .buildrc")
+
+	return cmd
+}
+
+func (me *Handler) ParseArguments(_ context.Context, _ *cobra.Command, _ []string) error {
+
+	if me.Version != "" {
+		if _, err := semver.NewVersion(me.Version); err != nil {
+			return errors.Wrapf(err, "invalid version '%s'", me.Version)
+		}
+	}
+
+	if me.Channel != "" {
+		if err := buildrc.ValidateChannel(me.Channel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (me *Handler) Run(ctx context.Context, cmd *cobra.Command, gitp git.GitProvider, fls afero.Fs, det ci.Detector) error {
+
+	brc, err := buildrc.LoadBuildrc(ctx, gitp)
+	if err != nil && !errors.Is(err, buildrc.ErrBuildrcNotFound) {
+		return err
+	}
+
+	raw := me.Version
+
+	if raw == "" {
+		env, err := det.Detect(ctx)
+		if err != nil {
+			return err
+		}
+
+		raw, err = buildrc.GetReleaseVersion(ctx, gitp, brc, env, me.Channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	vers, err := semver.NewVersion(raw)
+	if err != nil {
+		return errors.Wrapf(err, "invalid version '%s'", raw)
+	}
+
+	opts := &git.CreateTagOptions{
+		Message:     me.Message,
+		TaggerName:  me.TaggerName,
+		TaggerEmail: me.TaggerEmail,
+	}
+
+	if me.SignKey != "" {
+		key, err := afero.ReadFile(fls, me.SignKey)
+		if err != nil {
+			return errors.Wrapf(err, "reading signing key")
+		}
+
+		opts.Signer, err = git.NewTagSigner(key, []byte(os.Getenv(SigningKeyPassphraseEnv)))
+		if err != nil {
+			return err
+		}
+	}
+
+	var push *git.PushOptions
+
+	if me.Push {
+		// the credentials are looked up for the remote pushed to, so the name is resolved once for both
+		remote := me.Remote
+		if remote == "" {
+			remote = brc.Remote()
+		}
+		if remote == "" {
+			remote = git.DefaultRemoteName
+		}
+		push = &git.PushOptions{Remote: remote}
+		push.Username, push.Password = credentials(ctx, gitp, remote)
+	}
+
+	tag, err := git.TagVersion(ctx, gitp, vers, opts, push)
+	if err != nil {
+		return err
+	}
+
+	byt, err := json.Marshal(tag)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("%s\n", byt)
+
+	return nil
+}
+
+// credentials returns the http credentials for the remote from the token of the forge hosting it,
+// or empty strings if there is none.
+func credentials(ctx context.Context, gitp git.GitProvider, name string) (string, string) {
+	raw, err := gitp.GetRemoteURL(ctx, name)
+	if err != nil {
+		return "", ""
+	}
+
+	remote, err := git.ParseRemoteURL(raw)
+	if err != nil {
+		return "", ""
+	}
+
+	if opts, ok := forge.Detect(remote, "", os.Getenv); ok {
+		if username, password := opts.Credentials(); password != "" {
+			return username, password
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("host", remote.Host).Msg("no forge token for the remote, pushing without credentials")
+
+	return "", ""
+}
@@ -27,6 +27,63 @@ func (_m *MockGitProvider_git) EXPECT() *MockGitProvider_git_Expecter {
 	return &MockGitProvider_git_Expecter{mock: &_m.Mock}
 }
 
+// CreateTag provides a mock function with given fields: ctx, name, ref, opts
+func (_m *MockGitProvider_git) CreateTag(ctx context.Context, name string, ref string, opts *git.CreateTagOptions) (*git.Tag, error) {
+	ret := _m.Called(ctx, name, ref, opts)
+
+	var r0 *git.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *git.CreateTagOptions) (*git.Tag, error)); ok {
+		return rf(ctx, name, ref, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *git.CreateTagOptions) *git.Tag); ok {
+		r0 = rf(ctx, name, ref, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*git.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *git.CreateTagOptions) error); ok {
+		r1 = rf(ctx, name, ref, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_CreateTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTag'
+type MockGitProvider_git_CreateTag_Call struct {
+	*mock.Call
+}
+
+// CreateTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - ref string
+//   - opts *git.CreateTagOptions
+func (_e *MockGitProvider_git_Expecter) CreateTag(ctx interface{}, name interface{}, ref interface{}, opts interface{}) *MockGitProvider_git_CreateTag_Call {
+	return &MockGitProvider_git_CreateTag_Call{Call: _e.mock.On("CreateTag", ctx, name, ref, opts)}
+}
+
+func (_c *MockGitProvider_git_CreateTag_Call) Run(run func(ctx context.Context, name string, ref string, opts *git.CreateTagOptions)) *MockGitProvider_git_CreateTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(*git.CreateTagOptions))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_CreateTag_Call) Return(_a0 *git.Tag, _a1 error) *MockGitProvider_git_CreateTag_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_CreateTag_Call) RunAndReturn(run func(context.Context, string, string, *git.CreateTagOptions) (*git.Tag, error)) *MockGitProvider_git_CreateTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Dirty provides a mock function with given fields: ctx
 func (_m *MockGitProvider_git) Dirty(ctx context.Context) bool {
 	ret := _m.Called(ctx)
@@ -112,6 +169,61 @@ func (_c *MockGitProvider_git_Fs_Call) RunAndReturn(run func() afero.Fs) *MockGi
 	return _c
 }
 
+// GetCommitFromRef provides a mock function with given fields: ctx, ref
+func (_m *MockGitProvider_git) GetCommitFromRef(ctx context.Context, ref string) (*git.Commit, error) {
+	ret := _m.Called(ctx, ref)
+
+	var r0 *git.Commit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*git.Commit, error)); ok {
+		return rf(ctx, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *git.Commit); ok {
+		r0 = rf(ctx, ref)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*git.Commit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_GetCommitFromRef_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCommitFromRef'
+type MockGitProvider_git_GetCommitFromRef_Call struct {
+	*mock.Call
+}
+
+// GetCommitFromRef is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ref string
+func (_e *MockGitProvider_git_Expecter) GetCommitFromRef(ctx interface{}, ref interface{}) *MockGitProvider_git_GetCommitFromRef_Call {
+	return &MockGitProvider_git_GetCommitFromRef_Call{Call: _e.mock.On("GetCommitFromRef", ctx, ref)}
+}
+
+func (_c *MockGitProvider_git_GetCommitFromRef_Call) Run(run func(ctx context.Context, ref string)) *MockGitProvider_git_GetCommitFromRef_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_GetCommitFromRef_Call) Return(_a0 *git.Commit, _a1 error) *MockGitProvider_git_GetCommitFromRef_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_GetCommitFromRef_Call) RunAndReturn(run func(context.Context, string) (*git.Commit, error)) *MockGitProvider_git_GetCommitFromRef_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetContentHashFromRef provides a mock function with given fields: ctx, ref
 func (_m *MockGitProvider_git) GetContentHashFromRef(ctx context.Context, ref string) (string, error) {
 	ret := _m.Called(ctx, ref)
@@ -486,23 +598,23 @@ func (_c *MockGitProvider_git_GetLocalRepositoryMetadata_Call) RunAndReturn(run
 	return _c
 }
 
-// GetRemoteURL provides a mock function with given fields: ctx
-func (_m *MockGitProvider_git) GetRemoteURL(ctx context.Context) (string, error) {
-	ret := _m.Called(ctx)
+// GetRemoteURL provides a mock function with given fields: ctx, remote
+func (_m *MockGitProvider_git) GetRemoteURL(ctx context.Context, remote string) (string, error) {
+	ret := _m.Called(ctx, remote)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (string, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, remote)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, remote)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, remote)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -517,13 +629,14 @@ type MockGitProvider_git_GetRemoteURL_Call struct {
 
 // GetRemoteURL is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockGitProvider_git_Expecter) GetRemoteURL(ctx interface{}) *MockGitProvider_git_GetRemoteURL_Call {
-	return &MockGitProvider_git_GetRemoteURL_Call{Call: _e.mock.On("GetRemoteURL", ctx)}
+//   - remote string
+func (_e *MockGitProvider_git_Expecter) GetRemoteURL(ctx interface{}, remote interface{}) *MockGitProvider_git_GetRemoteURL_Call {
+	return &MockGitProvider_git_GetRemoteURL_Call{Call: _e.mock.On("GetRemoteURL", ctx, remote)}
 }
 
-func (_c *MockGitProvider_git_GetRemoteURL_Call) Run(run func(ctx context.Context)) *MockGitProvider_git_GetRemoteURL_Call {
+func (_c *MockGitProvider_git_GetRemoteURL_Call) Run(run func(ctx context.Context, remote string)) *MockGitProvider_git_GetRemoteURL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -533,7 +646,271 @@ func (_c *MockGitProvider_git_GetRemoteURL_Call) Return(_a0 string, _a1 error) *
 	return _c
 }
 
-func (_c *MockGitProvider_git_GetRemoteURL_Call) RunAndReturn(run func(context.Context) (string, error)) *MockGitProvider_git_GetRemoteURL_Call {
+func (_c *MockGitProvider_git_GetRemoteURL_Call) RunAndReturn(run func(context.Context, string) (string, error)) *MockGitProvider_git_GetRemoteURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListChangedFilesBetweenRefs provides a mock function with given fields: ctx, from, to
+func (_m *MockGitProvider_git) ListChangedFilesBetweenRefs(ctx context.Context, from string, to string) ([]string, error) {
+	ret := _m.Called(ctx, from, to)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]string, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []string); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_ListChangedFilesBetweenRefs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListChangedFilesBetweenRefs'
+type MockGitProvider_git_ListChangedFilesBetweenRefs_Call struct {
+	*mock.Call
+}
+
+// ListChangedFilesBetweenRefs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *MockGitProvider_git_Expecter) ListChangedFilesBetweenRefs(ctx interface{}, from interface{}, to interface{}) *MockGitProvider_git_ListChangedFilesBetweenRefs_Call {
+	return &MockGitProvider_git_ListChangedFilesBetweenRefs_Call{Call: _e.mock.On("ListChangedFilesBetweenRefs", ctx, from, to)}
+}
+
+func (_c *MockGitProvider_git_ListChangedFilesBetweenRefs_Call) Run(run func(ctx context.Context, from string, to string)) *MockGitProvider_git_ListChangedFilesBetweenRefs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListChangedFilesBetweenRefs_Call) Return(_a0 []string, _a1 error) *MockGitProvider_git_ListChangedFilesBetweenRefs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListChangedFilesBetweenRefs_Call) RunAndReturn(run func(context.Context, string, string) ([]string, error)) *MockGitProvider_git_ListChangedFilesBetweenRefs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListCommitsBetweenRefs provides a mock function with given fields: ctx, from, to
+func (_m *MockGitProvider_git) ListCommitsBetweenRefs(ctx context.Context, from string, to string) ([]*git.Commit, error) {
+	ret := _m.Called(ctx, from, to)
+
+	var r0 []*git.Commit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]*git.Commit, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*git.Commit); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*git.Commit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_ListCommitsBetweenRefs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListCommitsBetweenRefs'
+type MockGitProvider_git_ListCommitsBetweenRefs_Call struct {
+	*mock.Call
+}
+
+// ListCommitsBetweenRefs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from string
+//   - to string
+func (_e *MockGitProvider_git_Expecter) ListCommitsBetweenRefs(ctx interface{}, from interface{}, to interface{}) *MockGitProvider_git_ListCommitsBetweenRefs_Call {
+	return &MockGitProvider_git_ListCommitsBetweenRefs_Call{Call: _e.mock.On("ListCommitsBetweenRefs", ctx, from, to)}
+}
+
+func (_c *MockGitProvider_git_ListCommitsBetweenRefs_Call) Run(run func(ctx context.Context, from string, to string)) *MockGitProvider_git_ListCommitsBetweenRefs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListCommitsBetweenRefs_Call) Return(_a0 []*git.Commit, _a1 error) *MockGitProvider_git_ListCommitsBetweenRefs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListCommitsBetweenRefs_Call) RunAndReturn(run func(context.Context, string, string) ([]*git.Commit, error)) *MockGitProvider_git_ListCommitsBetweenRefs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDirtyFiles provides a mock function with given fields: ctx
+func (_m *MockGitProvider_git) ListDirtyFiles(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_ListDirtyFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDirtyFiles'
+type MockGitProvider_git_ListDirtyFiles_Call struct {
+	*mock.Call
+}
+
+// ListDirtyFiles is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockGitProvider_git_Expecter) ListDirtyFiles(ctx interface{}) *MockGitProvider_git_ListDirtyFiles_Call {
+	return &MockGitProvider_git_ListDirtyFiles_Call{Call: _e.mock.On("ListDirtyFiles", ctx)}
+}
+
+func (_c *MockGitProvider_git_ListDirtyFiles_Call) Run(run func(ctx context.Context)) *MockGitProvider_git_ListDirtyFiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListDirtyFiles_Call) Return(_a0 []string, _a1 error) *MockGitProvider_git_ListDirtyFiles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListDirtyFiles_Call) RunAndReturn(run func(context.Context) ([]string, error)) *MockGitProvider_git_ListDirtyFiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTags provides a mock function with given fields: ctx
+func (_m *MockGitProvider_git) ListTags(ctx context.Context) ([]*git.Tag, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*git.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*git.Tag, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*git.Tag); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*git.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGitProvider_git_ListTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTags'
+type MockGitProvider_git_ListTags_Call struct {
+	*mock.Call
+}
+
+// ListTags is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockGitProvider_git_Expecter) ListTags(ctx interface{}) *MockGitProvider_git_ListTags_Call {
+	return &MockGitProvider_git_ListTags_Call{Call: _e.mock.On("ListTags", ctx)}
+}
+
+func (_c *MockGitProvider_git_ListTags_Call) Run(run func(ctx context.Context)) *MockGitProvider_git_ListTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListTags_Call) Return(_a0 []*git.Tag, _a1 error) *MockGitProvider_git_ListTags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGitProvider_git_ListTags_Call) RunAndReturn(run func(context.Context) ([]*git.Tag, error)) *MockGitProvider_git_ListTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PushTag provides a mock function with given fields: ctx, name, opts
+func (_m *MockGitProvider_git) PushTag(ctx context.Context, name string, opts *git.PushOptions) error {
+	ret := _m.Called(ctx, name, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *git.PushOptions) error); ok {
+		r0 = rf(ctx, name, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockGitProvider_git_PushTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PushTag'
+type MockGitProvider_git_PushTag_Call struct {
+	*mock.Call
+}
+
+// PushTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - opts *git.PushOptions
+func (_e *MockGitProvider_git_Expecter) PushTag(ctx interface{}, name interface{}, opts interface{}) *MockGitProvider_git_PushTag_Call {
+	return &MockGitProvider_git_PushTag_Call{Call: _e.mock.On("PushTag", ctx, name, opts)}
+}
+
+func (_c *MockGitProvider_git_PushTag_Call) Run(run func(ctx context.Context, name string, opts *git.PushOptions)) *MockGitProvider_git_PushTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*git.PushOptions))
+	})
+	return _c
+}
+
+func (_c *MockGitProvider_git_PushTag_Call) Return(_a0 error) *MockGitProvider_git_PushTag_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockGitProvider_git_PushTag_Call) RunAndReturn(run func(context.Context, string, *git.PushOptions) error) *MockGitProvider_git_PushTag_Call {
 	_c.Call.Return(run)
 	return _c
 }
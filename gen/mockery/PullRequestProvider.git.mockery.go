@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	git "github.com/walteh/buildrc/pkg/git"
+
+	time "time"
 )
 
 // MockPullRequestProvider_git is an autogenerated mock type for the PullRequestProvider type
@@ -22,6 +24,62 @@ func (_m *MockPullRequestProvider_git) EXPECT() *MockPullRequestProvider_git_Exp
 	return &MockPullRequestProvider_git_Expecter{mock: &_m.Mock}
 }
 
+// ListPullRequestsUpdatedSince provides a mock function with given fields: ctx, base, since
+func (_m *MockPullRequestProvider_git) ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*git.PullRequest, error) {
+	ret := _m.Called(ctx, base, since)
+
+	var r0 []*git.PullRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) ([]*git.PullRequest, error)); ok {
+		return rf(ctx, base, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []*git.PullRequest); ok {
+		r0 = rf(ctx, base, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*git.PullRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, base, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListPullRequestsUpdatedSince'
+type MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call struct {
+	*mock.Call
+}
+
+// ListPullRequestsUpdatedSince is a helper method to define mock.On call
+//   - ctx context.Context
+//   - base string
+//   - since time.Time
+func (_e *MockPullRequestProvider_git_Expecter) ListPullRequestsUpdatedSince(ctx interface{}, base interface{}, since interface{}) *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call {
+	return &MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call{Call: _e.mock.On("ListPullRequestsUpdatedSince", ctx, base, since)}
+}
+
+func (_c *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call) Run(run func(ctx context.Context, base string, since time.Time)) *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call) Return(_a0 []*git.PullRequest, _a1 error) *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call) RunAndReturn(run func(context.Context, string, time.Time) ([]*git.PullRequest, error)) *MockPullRequestProvider_git_ListPullRequestsUpdatedSince_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListRecentPullRequests provides a mock function with given fields: ctx, head
 func (_m *MockPullRequestProvider_git) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
 	ret := _m.Called(ctx, head)
@@ -0,0 +1,164 @@
+package buildrc
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/git"
+	"gopkg.in/yaml.v3"
+)
+
+// BootstrapSource is where the version of a repository without tags comes from.
+type BootstrapSource string
+
+const (
+	BootstrapInitial     BootstrapSource = "initial"
+	BootstrapVersionFile BootstrapSource = "VERSION"
+	BootstrapPackageJSON BootstrapSource = "package.json"
+	BootstrapCargoToml   BootstrapSource = "Cargo.toml"
+	BootstrapChartYaml   BootstrapSource = "Chart.yaml"
+	BootstrapDefault     BootstrapSource = "default"
+)
+
+// DefaultBootstrapFiles are the files consulted, in order, when .buildrc does not list them.
+var DefaultBootstrapFiles = []BootstrapSource{BootstrapVersionFile, BootstrapPackageJSON, BootstrapCargoToml, BootstrapChartYaml}
+
+var ErrInvalidBootstrapVersion = errors.New("buildrc.ErrInvalidBootstrapVersion")
+
+// Bootstrap is the version that stands in for the latest tag when no tag is reachable from HEAD.
+// It is the version the first release gets, so it is not bumped.
+type Bootstrap struct {
+	Version *semver.Version
+	Source  BootstrapSource
+}
+
+var cargoVersionRegex = regexp.MustCompile(`^version\s*=\s*"([^"]*)"`)
+
+// GetBootstrapVersion returns the first of the initial version in .buildrc, the version in one of the
+// bootstrap files, or 0.0.0. A file that does not exist or has no version is skipped, a file with a
+// version that is not semver is an error.
+func GetBootstrapVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc) (*Bootstrap, error) {
+
+	if brc.InitialRaw != "" {
+		v, err := semver.NewVersion(brc.InitialRaw)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidBootstrapVersion, "initial version '%s' in %s: %v", brc.InitialRaw, BuildrcFileName, err)
+		}
+		return &Bootstrap{Version: v, Source: BootstrapInitial}, nil
+	}
+
+	for _, source := range brc.BootstrapFiles() {
+		raw, err := readBootstrapVersion(gitp.Fs(), source)
+		if err != nil {
+			return nil, err
+		}
+
+		if raw == "" {
+			zerolog.Ctx(ctx).Debug().Str("file", string(source)).Msg("no bootstrap version in file")
+			continue
+		}
+
+		v, err := semver.NewVersion(raw)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidBootstrapVersion, "version '%s' in %s: %v", raw, source, err)
+		}
+
+		return &Bootstrap{Version: v, Source: source}, nil
+	}
+
+	return &Bootstrap{Version: semver.New(0, 0, 0, "", ""), Source: BootstrapDefault}, nil
+}
+
+// readBootstrapVersion returns the version field of the file, or an empty string if the file
+// does not exist or has no version.
+func readBootstrapVersion(fs afero.Fs, source BootstrapSource) (string, error) {
+
+	byt, err := afero.ReadFile(fs, string(source))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	switch source {
+	case BootstrapVersionFile:
+		return strings.TrimSpace(firstLine(string(byt))), nil
+	case BootstrapPackageJSON:
+		var pkg struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(byt, &pkg); err != nil {
+			return "", errors.Wrapf(ErrInvalidBootstrapVersion, "%s: %v", source, err)
+		}
+		return pkg.Version, nil
+	case BootstrapChartYaml:
+		var chart struct {
+			Version string `yaml:"version"`
+		}
+		if err := yaml.Unmarshal(byt, &chart); err != nil {
+			return "", errors.Wrapf(ErrInvalidBootstrapVersion, "%s: %v", source, err)
+		}
+		return chart.Version, nil
+	case BootstrapCargoToml:
+		// only the version key of the package table is needed, so there is no need for a toml parser
+		table := ""
+		for _, line := range strings.Split(string(byt), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "[") {
+				table = strings.TrimSpace(strings.Trim(line, "[]"))
+				continue
+			}
+			if table != "package" && table != "workspace.package" {
+				continue
+			}
+			if m := cargoVersionRegex.FindStringSubmatch(line); m != nil {
+				return m[1], nil
+			}
+		}
+		return "", nil
+	default:
+		return "", errors.Wrapf(ErrInvalidBootstrapVersion, "unknown bootstrap file '%s'", source)
+	}
+}
+
+// latestTagOrBootstrap returns the latest tag reachable from HEAD. Without one it returns the bootstrap
+// version and true, raised to the major version of .buildrc.
+func latestTagOrBootstrap(ctx context.Context, gitp git.GitProvider, brc *Buildrc, exp *Explanation) (*semver.Version, bool, error) {
+
+	latest, err := gitp.GetLatestSemverTagFromRef(ctx, "HEAD")
+	if err == nil {
+		exp.base(latest, "latest tag reachable from HEAD")
+		return latest, false, nil
+	}
+
+	if !errors.Is(err, git.ErrNoSemverTags) {
+		return nil, false, err
+	}
+
+	boot, err := GetBootstrapVersion(ctx, gitp, brc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("source", string(boot.Source)).Str("version", boot.Version.String()).Msg("no tag reachable from HEAD, bootstrapping the version")
+
+	exp.base(boot.Version, "no tag reachable from HEAD, bootstrapped from %s", boot.Source)
+	if exp != nil {
+		exp.Bootstrap = boot.Source
+	}
+
+	if boot.Version.Major() < brc.Major() {
+		exp.floor(brc.Major(), true)
+		return semver.New(brc.Major(), 0, 0, "", ""), true, nil
+	}
+
+	return boot.Version, true, nil
+}
@@ -0,0 +1,226 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestGetBootstrapVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		brc            string
+		files          map[string]string
+		expected       string
+		expectedSource buildrc.BootstrapSource
+		err            error
+	}{
+		{
+			name:           "initial wins over files",
+			brc:            "initial: 1.0.0\n",
+			files:          map[string]string{"VERSION": "3.0.0\n"},
+			expected:       "1.0.0",
+			expectedSource: buildrc.BootstrapInitial,
+		},
+		{
+			name:           "version file",
+			files:          map[string]string{"VERSION": "v2.1.0\n", "package.json": `{"version": "9.9.9"}`},
+			expected:       "2.1.0",
+			expectedSource: buildrc.BootstrapVersionFile,
+		},
+		{
+			name:           "package.json",
+			files:          map[string]string{"package.json": `{"name": "thing", "version": "0.4.2"}`, "Chart.yaml": "version: 9.9.9\n"},
+			expected:       "0.4.2",
+			expectedSource: buildrc.BootstrapPackageJSON,
+		},
+		{
+			name: "Cargo.toml package table",
+			files: map[string]string{"Cargo.toml": `[dependencies]
+version = "0.0.1"
+
+[package]
+name = "thing"
+version = "1.7.0" # the crate version
+`},
+			expected:       "1.7.0",
+			expectedSource: buildrc.BootstrapCargoToml,
+		},
+		{
+			name:           "Cargo.toml workspace",
+			files:          map[string]string{"Cargo.toml": "[workspace.package]\nversion = \"0.3.0\"\n"},
+			expected:       "0.3.0",
+			expectedSource: buildrc.BootstrapCargoToml,
+		},
+		{
+			name:           "Chart.yaml",
+			files:          map[string]string{"Chart.yaml": "apiVersion: v2\nname: thing\nversion: 0.1.5\nappVersion: \"2.0\"\n"},
+			expected:       "0.1.5",
+			expectedSource: buildrc.BootstrapChartYaml,
+		},
+		{
+			name:           "files without a version are skipped",
+			files:          map[string]string{"VERSION": "\n", "package.json": `{"private": true}`, "Chart.yaml": "version: 0.2.0\n"},
+			expected:       "0.2.0",
+			expectedSource: buildrc.BootstrapChartYaml,
+		},
+		{
+			name:           "bootstrap files from .buildrc",
+			brc:            "bootstrap-files: [Chart.yaml]\n",
+			files:          map[string]string{"VERSION": "3.0.0", "Chart.yaml": "version: 0.2.0\n"},
+			expected:       "0.2.0",
+			expectedSource: buildrc.BootstrapChartYaml,
+		},
+		{
+			name:           "default",
+			expected:       "0.0.0",
+			expectedSource: buildrc.BootstrapDefault,
+		},
+		{
+			name:  "invalid version",
+			files: map[string]string{"VERSION": "latest"},
+			err:   buildrc.ErrInvalidBootstrapVersion,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range test.files {
+				require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+			}
+
+			brc, err := buildrc.ParseBuildrc([]byte(test.brc))
+			require.NoError(t, err)
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			mockGitProvider.EXPECT().Fs().Return(fs).Maybe()
+
+			boot, err := buildrc.GetBootstrapVersion(context.TODO(), mockGitProvider, brc)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, boot.Version.String())
+			assert.Equal(t, test.expectedSource, boot.Source)
+		})
+	}
+}
+
+func TestParseBuildrcBootstrapInvalid(t *testing.T) {
+	tests := []struct {
+		content       string
+		errorContains string
+	}{
+		{content: "initial: latest\n", errorContains: ".buildrc:1:10: initial must be a semver version"},
+		{content: "bootstrap-files: [VERSION, setup.py]\n", errorContains: ".buildrc:1:28: bootstrap file 'setup.py' must be one of"},
+		{content: "scheme: calver\ninitial: 1.0.0\n", errorContains: ".buildrc:2:10: initial only applies to the semver scheme"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			_, err := buildrc.ParseBuildrc([]byte(test.content))
+			assert.ErrorIs(t, err, buildrc.ErrInvalidBuildrc)
+			assert.ErrorContains(t, err, test.errorContains)
+		})
+	}
+}
+
+func TestGetVersionBootstrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		brc      *buildrc.Buildrc
+		typ      buildrc.CommitType
+		files    map[string]string
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+		source   buildrc.BootstrapSource
+	}{
+		{
+			name:     "first release is the bootstrap version",
+			brc:      &buildrc.Buildrc{},
+			typ:      buildrc.CommitTypeRelease,
+			files:    map[string]string{"package.json": `{"version": "1.4.0"}`},
+			expected: "v1.4.0",
+			source:   buildrc.BootstrapPackageJSON,
+		},
+		{
+			name:     "major floor still applies",
+			brc:      &buildrc.Buildrc{MajorRaw: 2, InitialRaw: "1.0.0"},
+			typ:      buildrc.CommitTypeRelease,
+			expected: "v2.0.0",
+			source:   buildrc.BootstrapInitial,
+		},
+		{
+			name:  "pull request counts every commit",
+			brc:   &buildrc.Buildrc{},
+			typ:   buildrc.CommitTypePR,
+			files: map[string]string{"VERSION": "0.3.0"},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "", "HEAD").Return([]*git.Commit{{}, {}, {}}, nil)
+			},
+			expected: "v0.3.0-pr.5.3+abcdef0",
+			source:   buildrc.BootstrapVersionFile,
+		},
+		{
+			name:  "shallow clone without tags",
+			brc:   &buildrc.Buildrc{},
+			typ:   buildrc.CommitTypeLocal,
+			files: map[string]string{},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "", "HEAD").Return([]*git.Commit{{}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+			},
+			expected: "v0.0.0-local.1+abcdef0",
+			source:   buildrc.BootstrapDefault,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range test.files {
+				require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+			}
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			mockGitProvider.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrNoSemverTags)
+			mockGitProvider.EXPECT().Fs().Return(fs).Maybe()
+			if test.setup != nil {
+				test.setup(mockGitProvider)
+			}
+
+			exp := &buildrc.Explanation{}
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, test.brc, &buildrc.GetVersionOpts{
+				Type:     test.typ,
+				PRNumber: 5,
+				CI:       &ci.Environment{},
+				Explain:  exp,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+			assert.Equal(t, test.source, exp.Bootstrap)
+			assert.Equal(t, test.brc.Major() > 0, exp.MajorFloorApplied)
+		})
+	}
+}
+
+func TestGetVersionReleaseKeepsTagErrors(t *testing.T) {
+	mockGitProvider := mockery.NewMockGitProvider_git(t)
+	mockGitProvider.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrRefNotFound)
+
+	_, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{}, &buildrc.GetVersionOpts{Type: buildrc.CommitTypeRelease})
+	assert.ErrorIs(t, err, git.ErrRefNotFound)
+}
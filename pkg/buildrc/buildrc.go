@@ -2,19 +2,323 @@ package buildrc
 
 import (
 	"context"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/walteh/buildrc/pkg/git"
+	"gopkg.in/yaml.v3"
+)
+
+const BuildrcFileName = ".buildrc"
+
+var (
+	ErrBuildrcNotFound = errors.New("buildrc.ErrBuildrcNotFound")
+	ErrInvalidBuildrc  = errors.New("buildrc.ErrInvalidBuildrc")
 )
 
 type Buildrc struct {
-	MajorRaw int `yaml:"major,flow" json:"major"`
+	MajorRaw      int               `yaml:"major,flow" json:"major"`
+	BumpRaw       map[string]string `yaml:"bump" json:"bump,omitempty"`
+	RemoteRaw     string            `yaml:"remote" json:"remote,omitempty"`
+	MainBranchRaw string            `yaml:"main-branch" json:"main-branch,omitempty"`
+	SchemeRaw     string            `yaml:"scheme" json:"scheme,omitempty"`
+	CalverRaw     string            `yaml:"calver-layout" json:"calver-layout,omitempty"`
+	ChannelRaw    string            `yaml:"channel" json:"channel,omitempty"`
+	// MaintenanceRaw are the branches older release lines are patched on
+	MaintenanceRaw []MaintenanceBranch `yaml:"maintenance-branches" json:"maintenance-branches,omitempty"`
+	// InitialRaw is the version of the first release when no tag is reachable from HEAD
+	InitialRaw string `yaml:"initial" json:"initial,omitempty"`
+	// BootstrapRaw are the files the version is read from when there is no tag and no initial version
+	BootstrapRaw []string `yaml:"bootstrap-files" json:"bootstrap-files,omitempty"`
 }
 
 func (me *Buildrc) Major() uint64 {
 	return uint64(me.MajorRaw)
 }
 
-func LoadBuildrc(_ context.Context, _ git.GitProvider) (*Buildrc, error) {
+// Remote returns the name of the git remote the repository is published to.
+// If empty, origin is used, or the first remote by name if there is no origin.
+func (me *Buildrc) Remote() string {
+	return me.RemoteRaw
+}
+
+// MainBranch returns the name of the branch releases are cut from, defaulting to main.
+func (me *Buildrc) MainBranch() string {
+	if me.MainBranchRaw == "" {
+		return git.DefaultMainBranch
+	}
+	return me.MainBranchRaw
+}
+
+// Scheme returns the versioning scheme, defaulting to semver.
+func (me *Buildrc) Scheme() Scheme {
+	if me.SchemeRaw == "" {
+		return SchemeSemver
+	}
+	return Scheme(me.SchemeRaw)
+}
+
+// CalverLayout returns the calendar versioning layout, defaulting to DefaultCalverLayout.
+// The major version and bump rules do not apply to calendar versions.
+func (me *Buildrc) CalverLayout() (*CalverLayout, error) {
+	if me.CalverRaw == "" {
+		return ParseCalverLayout(DefaultCalverLayout)
+	}
+	return ParseCalverLayout(me.CalverRaw)
+}
+
+// BootstrapFiles returns the files the version of a repository without tags is read from, in order,
+// defaulting to DefaultBootstrapFiles.
+func (me *Buildrc) BootstrapFiles() []BootstrapSource {
+	if me.BootstrapRaw == nil {
+		return DefaultBootstrapFiles
+	}
+	res := make([]BootstrapSource, 0, len(me.BootstrapRaw))
+	for _, f := range me.BootstrapRaw {
+		res = append(res, BootstrapSource(f))
+	}
+	return res
+}
+
+// Channel returns the prerelease channel releases are cut on, like beta or rc. It is empty for final releases.
+func (me *Buildrc) Channel() string {
+	return me.ChannelRaw
+}
+
+// MaintenanceLine returns the release line of the branch, or nil if it is not a maintenance branch.
+// The first matching entry of maintenance-branches wins.
+func (me *Buildrc) MaintenanceLine(branch string) (*MaintenanceLine, error) {
+	for i := range me.MaintenanceRaw {
+		line, err := me.MaintenanceRaw[i].match(branch)
+		if err != nil || line != nil {
+			return line, err
+		}
+	}
+	return nil, nil
+}
+
+// BumpRules returns the conventional commit type to bump mapping, with any
+// entries from the bump section of .buildrc taking precedence over the defaults.
+func (me *Buildrc) BumpRules() map[string]Bump {
+	rules := map[string]Bump{}
+	for k, v := range DefaultConventionalBumps {
+		rules[k] = v
+	}
+	for k, v := range me.BumpRaw {
+		b, err := ParseBump(v)
+		if err != nil {
+			continue
+		}
+		rules[strings.ToLower(k)] = b
+	}
+	return rules
+}
+
+// LoadBuildrc reads the .buildrc file at the root of the git provider's filesystem.
+// The file may be written as flow yaml ({ major: 3 }), block yaml or json.
+//
+// If no .buildrc file exists, a default *Buildrc is returned along with an error
+// that matches ErrBuildrcNotFound so callers can decide whether to tolerate it.
+func LoadBuildrc(ctx context.Context, gitp git.GitProvider) (*Buildrc, error) {
+
+	byt, err := afero.ReadFile(gitp.Fs(), BuildrcFileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			zerolog.Ctx(ctx).Debug().Msg("no .buildrc file found")
+			return &Buildrc{}, errors.Wrap(ErrBuildrcNotFound, BuildrcFileName)
+		}
+		return nil, err
+	}
+
+	return ParseBuildrc(byt)
+}
+
+// ParseBuildrc parses the raw contents of a .buildrc file, rejecting unknown keys.
+func ParseBuildrc(byt []byte) (*Buildrc, error) {
+
+	brc := &Buildrc{}
+
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(byt, &root); err != nil {
+		return nil, errors.Wrapf(ErrInvalidBuildrc, "%s: %v", BuildrcFileName, err)
+	}
+
+	// an empty file is a valid, default configuration
+	if len(root.Content) == 0 {
+		return brc, nil
+	}
+
+	if err := checkKnownFields(root.Content[0], reflect.TypeOf(brc).Elem()); err != nil {
+		return nil, err
+	}
+
+	if err := root.Content[0].Decode(brc); err != nil {
+		return nil, errors.Wrapf(ErrInvalidBuildrc, "%s: %v", BuildrcFileName, err)
+	}
+
+	if brc.MajorRaw < 0 {
+		key, _ := findKey(root.Content[0], "major")
+		return nil, errorAtNode(key, "major must not be negative")
+	}
+
+	if brc.Scheme() != SchemeSemver && brc.Scheme() != SchemeCalver {
+		_, value := findKey(root.Content[0], "scheme")
+		return nil, errorAtNode(value, "scheme must be one of %s or %s", SchemeSemver, SchemeCalver)
+	}
+
+	if _, err := brc.CalverLayout(); err != nil {
+		_, value := findKey(root.Content[0], "calver-layout")
+		return nil, errorAtNode(value, "%v", err)
+	}
+
+	if brc.ChannelRaw != "" {
+		if err := ValidateChannel(brc.ChannelRaw); err != nil {
+			_, value := findKey(root.Content[0], "channel")
+			return nil, errorAtNode(value, "%v", err)
+		}
+	}
+
+	if _, branches := findKey(root.Content[0], "maintenance-branches"); branches != nil {
+		if brc.Scheme() == SchemeCalver {
+			return nil, errorAtNode(branches, "maintenance-branches only apply to the %s scheme", SchemeSemver)
+		}
+		for i := range brc.MaintenanceRaw {
+			if err := brc.MaintenanceRaw[i].validate(); err != nil {
+				var node *yaml.Node
+				if i < len(branches.Content) {
+					node = branches.Content[i]
+				}
+				return nil, errorAtNode(node, "%v", err)
+			}
+		}
+	}
+
+	for _, key := range []string{"initial", "bootstrap-files"} {
+		if _, value := findKey(root.Content[0], key); value != nil && brc.Scheme() == SchemeCalver {
+			return nil, errorAtNode(value, "%s only applies to the %s scheme", key, SchemeSemver)
+		}
+	}
+
+	if brc.InitialRaw != "" {
+		if _, err := semver.NewVersion(brc.InitialRaw); err != nil {
+			_, value := findKey(root.Content[0], "initial")
+			return nil, errorAtNode(value, "initial must be a semver version: %v", err)
+		}
+	}
+
+	if _, files := findKey(root.Content[0], "bootstrap-files"); files != nil {
+		for i, f := range brc.BootstrapRaw {
+			if !slices.Contains(DefaultBootstrapFiles, BootstrapSource(f)) {
+				var node *yaml.Node
+				if i < len(files.Content) {
+					node = files.Content[i]
+				}
+				return nil, errorAtNode(node, "bootstrap file '%s' must be one of %s, %s, %s or %s", f, BootstrapVersionFile, BootstrapPackageJSON, BootstrapCargoToml, BootstrapChartYaml)
+			}
+		}
+	}
+
+	if _, bumps := findKey(root.Content[0], "bump"); bumps != nil && bumps.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(bumps.Content); i += 2 {
+			if _, err := ParseBump(bumps.Content[i+1].Value); err != nil {
+				return nil, errorAtNode(bumps.Content[i+1], "bump for %q must be one of major, minor or patch", bumps.Content[i].Value)
+			}
+		}
+	}
+
+	return brc, nil
+}
+
+func errorAtNode(node *yaml.Node, format string, args ...any) error {
+	if node == nil {
+		return errors.Wrapf(ErrInvalidBuildrc, "%s: "+format, append([]any{BuildrcFileName}, args...)...)
+	}
+	return errors.Wrapf(ErrInvalidBuildrc, "%s:%d:%d: "+format, append([]any{BuildrcFileName, node.Line, node.Column}, args...)...)
+}
+
+// findKey returns the key and value nodes for a key in a yaml mapping.
+func findKey(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// checkKnownFields walks the yaml tree alongside the go type it will be decoded into
+// and reports the position of the first key that does not map to a field.
+func checkKnownFields(node *yaml.Node, typ reflect.Type) error {
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		fields := map[string]reflect.Type{}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			fields[name] = f.Type
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			ftyp, ok := fields[key.Value]
+			if !ok {
+				return errorAtNode(key, "unknown key %q", key.Value)
+			}
+			if err := checkKnownFields(node.Content[i+1], ftyp); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkKnownFields(node.Content[i+1], typ.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, child := range node.Content {
+			if err := checkKnownFields(child, typ.Elem()); err != nil {
+				return err
+			}
+		}
+	}
 
-	return &Buildrc{0}, nil
+	return nil
 }
@@ -0,0 +1,134 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+)
+
+func TestLoadBuildrc(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       *string
+		expectedMajor uint64
+		expectedError error
+		errorContains string
+	}{
+		{
+			name:          "flow yaml",
+			content:       ptr("{ major: 3 }"),
+			expectedMajor: 3,
+		},
+		{
+			name:          "block yaml",
+			content:       ptr("major: 4\n"),
+			expectedMajor: 4,
+		},
+		{
+			name:          "json",
+			content:       ptr(`{"major": 5}`),
+			expectedMajor: 5,
+		},
+		{
+			name:          "empty file",
+			content:       ptr(""),
+			expectedMajor: 0,
+		},
+		{
+			name:          "missing file",
+			content:       nil,
+			expectedMajor: 0,
+			expectedError: buildrc.ErrBuildrcNotFound,
+		},
+		{
+			name:          "unknown key",
+			content:       ptr("major: 1\nminor: 2\n"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+			errorContains: `.buildrc:2:1: unknown key "minor"`,
+		},
+		{
+			name:          "unknown key in flow yaml",
+			content:       ptr("{ major: 1, mjr: 2 }"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+			errorContains: `.buildrc:1:13: unknown key "mjr"`,
+		},
+		{
+			name:          "wrong type",
+			content:       ptr("{ major: three }"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+			errorContains: "line 1",
+		},
+		{
+			name:          "negative major",
+			content:       ptr("major: -1"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+			errorContains: ".buildrc:1:1: major must not be negative",
+		},
+		{
+			name:          "bump rules",
+			content:       ptr("{ major: 2, bump: { perf: minor, docs: patch } }"),
+			expectedMajor: 2,
+		},
+		{
+			name:          "invalid bump rule",
+			content:       ptr("major: 1\nbump:\n  feat: huge\n"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+			errorContains: `.buildrc:3:9: bump for "feat" must be one of major, minor or patch`,
+		},
+		{
+			name:          "invalid syntax",
+			content:       ptr("{ major: 1"),
+			expectedError: buildrc.ErrInvalidBuildrc,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			fs := afero.NewMemMapFs()
+
+			if test.content != nil {
+				require.NoError(t, afero.WriteFile(fs, ".buildrc", []byte(*test.content), 0644))
+			}
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			mockGitProvider.EXPECT().Fs().Return(fs)
+
+			brc, err := buildrc.LoadBuildrc(context.TODO(), mockGitProvider)
+
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				if test.errorContains != "" {
+					assert.ErrorContains(t, err, test.errorContains)
+				}
+			} else {
+				require.NoError(t, err)
+			}
+
+			if brc != nil {
+				assert.Equal(t, test.expectedMajor, brc.Major())
+			}
+		})
+	}
+}
+
+func TestParseBuildrcRepositorySettings(t *testing.T) {
+	brc, err := buildrc.ParseBuildrc([]byte("major: 1\nremote: upstream\nmain-branch: trunk\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "upstream", brc.Remote())
+	assert.Equal(t, "trunk", brc.MainBranch())
+
+	brc, err = buildrc.ParseBuildrc([]byte("major: 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "", brc.Remote())
+	assert.Equal(t, "main", brc.MainBranch())
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,218 @@
+package buildrc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+)
+
+type Bump int
+
+const (
+	BumpPatch Bump = iota + 1
+	BumpMinor
+	BumpMajor
+)
+
+var (
+	ErrUnknownBump         = errors.New("buildrc.ErrUnknownBump")
+	ErrUnknownBumpStrategy = errors.New("buildrc.ErrUnknownBumpStrategy")
+)
+
+func ParseBump(str string) (Bump, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "patch":
+		return BumpPatch, nil
+	case "minor":
+		return BumpMinor, nil
+	case "major":
+		return BumpMajor, nil
+	default:
+		return 0, errors.Wrapf(ErrUnknownBump, "%q", str)
+	}
+}
+
+func (me Bump) String() string {
+	switch me {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "unknown"
+	}
+}
+
+// Apply increments the version by the bump level, dropping any prerelease or metadata. The core of a
+// prerelease has not been released yet, so it is reused when it already includes the bump, which
+// promotes 1.3.0-rc.2 to 1.3.0 for a minor or patch bump.
+func (me Bump) Apply(v *semver.Version) semver.Version {
+	work := *semver.New(v.Major(), v.Minor(), v.Patch(), "", "")
+
+	if v.Prerelease() != "" {
+		switch {
+		case me == BumpMajor && v.Minor() == 0 && v.Patch() == 0:
+			return work
+		case me == BumpMinor && v.Patch() == 0:
+			return work
+		case me <= BumpPatch:
+			return work
+		}
+	}
+
+	switch me {
+	case BumpMajor:
+		return work.IncMajor()
+	case BumpMinor:
+		return work.IncMinor()
+	default:
+		return work.IncPatch()
+	}
+}
+
+type BumpStrategy string
+
+const (
+	// BumpStrategyPatchIndicator bumps the patch version if the commit message contains
+	// the patch indicator, otherwise it bumps the minor version.
+	BumpStrategyPatchIndicator BumpStrategy = "patch-indicator"
+	// BumpStrategyConventional derives the bump from the conventional commit header and footers.
+	BumpStrategyConventional BumpStrategy = "conventional"
+)
+
+// ValidateBumpStrategy checks the strategy is one of the bump strategies.
+func ValidateBumpStrategy(strategy BumpStrategy) error {
+	switch strategy {
+	case BumpStrategyPatchIndicator, BumpStrategyConventional:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnknownBumpStrategy, "'%s' must be one of [%s, %s]", strategy, BumpStrategyPatchIndicator, BumpStrategyConventional)
+	}
+}
+
+// DefaultConventionalBumps is the type to bump mapping used when .buildrc does not override it.
+// Types that are not listed result in a patch bump, breaking changes always result in a major bump.
+var DefaultConventionalBumps = map[string]Bump{
+	"feat": BumpMinor,
+	"fix":  BumpPatch,
+}
+
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	Footers     map[string]string
+}
+
+var (
+	conventionalHeaderRegex = regexp.MustCompile(`^([a-zA-Z][\w-]*)(?:\(([^()]*)\))?(!)?: (.+)$`)
+	conventionalFooterRegex = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[\w-]+)(?:: | #)(.*)$`)
+)
+
+// ParseConventionalCommit parses a commit message following https://www.conventionalcommits.org.
+// It returns false if the message does not start with a conventional commit header.
+func ParseConventionalCommit(message string) (*ConventionalCommit, bool) {
+
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(message), "\r\n", "\n"), "\n")
+
+	match := conventionalHeaderRegex.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return nil, false
+	}
+
+	cc := &ConventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Breaking:    match[3] == "!",
+		Description: match[4],
+		Footers:     map[string]string{},
+	}
+
+	// footers live in the last paragraph of the body
+	start := len(lines)
+	for start > 1 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	if start <= 1 {
+		return cc, true
+	}
+
+	var last string
+	for _, line := range lines[start:] {
+		if m := conventionalFooterRegex.FindStringSubmatch(line); m != nil {
+			last = m[1]
+			cc.Footers[last] = m[2]
+			continue
+		}
+		// continuation of the previous footer value
+		if last != "" {
+			cc.Footers[last] += "\n" + line
+		}
+	}
+
+	for _, key := range []string{"BREAKING CHANGE", "BREAKING-CHANGE"} {
+		if _, ok := cc.Footers[key]; ok {
+			cc.Breaking = true
+		}
+	}
+
+	return cc, true
+}
+
+// Bump returns the bump level implied by the commit given a type to bump mapping.
+func (me *ConventionalCommit) Bump(rules map[string]Bump) Bump {
+	if me.Breaking {
+		return BumpMajor
+	}
+	if b, ok := rules[me.Type]; ok {
+		return b
+	}
+	return BumpPatch
+}
+
+// GetBumpFromMessage returns the bump level for a single commit message using the given strategy.
+func GetBumpFromMessage(ctx context.Context, brc *Buildrc, strategy BumpStrategy, patchIndicator string, message string) (Bump, error) {
+	bump, _, err := bumpFromMessage(ctx, brc, strategy, patchIndicator, message)
+	return bump, err
+}
+
+// bumpFromMessage is GetBumpFromMessage, also returning a description of the rule that matched.
+func bumpFromMessage(ctx context.Context, brc *Buildrc, strategy BumpStrategy, patchIndicator string, message string) (Bump, string, error) {
+
+	switch strategy {
+	case BumpStrategyConventional:
+		cc, ok := ParseConventionalCommit(message)
+		if !ok {
+			zerolog.Ctx(ctx).Debug().Str("message", message).Msg("not a conventional commit, defaulting to patch")
+			return BumpPatch, "not a conventional commit, defaults to patch", nil
+		}
+
+		rules := brc.BumpRules()
+		bump := cc.Bump(rules)
+
+		zerolog.Ctx(ctx).Debug().Str("type", cc.Type).Bool("breaking", cc.Breaking).Str("bump", bump.String()).Msg("parsed conventional commit")
+
+		if cc.Breaking {
+			return bump, "breaking change", nil
+		}
+		if _, ok := rules[cc.Type]; ok {
+			return bump, fmt.Sprintf("type %s is a %s bump", cc.Type, bump.String()), nil
+		}
+		return bump, fmt.Sprintf("type %s has no bump rule, defaults to patch", cc.Type), nil
+	case BumpStrategyPatchIndicator, "":
+		if strings.Contains(message, patchIndicator) {
+			return BumpPatch, fmt.Sprintf("contains the patch indicator %q", patchIndicator), nil
+		}
+		return BumpMinor, fmt.Sprintf("no patch indicator %q, defaults to minor", patchIndicator), nil
+	default:
+		return 0, "", errors.Errorf("unknown bump strategy %q", strategy)
+	}
+}
@@ -0,0 +1,196 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+)
+
+func TestValidateBumpStrategy(t *testing.T) {
+	for _, strategy := range []buildrc.BumpStrategy{buildrc.BumpStrategyPatchIndicator, buildrc.BumpStrategyConventional} {
+		assert.NoError(t, buildrc.ValidateBumpStrategy(strategy), strategy)
+	}
+
+	for _, strategy := range []buildrc.BumpStrategy{"", "semantic", "Conventional"} {
+		assert.ErrorIs(t, buildrc.ValidateBumpStrategy(strategy), buildrc.ErrUnknownBumpStrategy, strategy)
+	}
+}
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name             string
+		message          string
+		expectedOk       bool
+		expectedType     string
+		expectedScope    string
+		expectedBreaking bool
+	}{
+		{
+			name:         "feature",
+			message:      "feat: add a thing",
+			expectedOk:   true,
+			expectedType: "feat",
+		},
+		{
+			name:          "fix with scope",
+			message:       "fix(git): resolve annotated tags",
+			expectedOk:    true,
+			expectedType:  "fix",
+			expectedScope: "git",
+		},
+		{
+			name:             "breaking with bang",
+			message:          "feat(api)!: remove endpoint",
+			expectedOk:       true,
+			expectedType:     "feat",
+			expectedScope:    "api",
+			expectedBreaking: true,
+		},
+		{
+			name:             "breaking with footer",
+			message:          "refactor: drop flag\n\nlonger body\n\nBREAKING CHANGE: the flag is gone\nRefs: #123",
+			expectedOk:       true,
+			expectedType:     "refactor",
+			expectedBreaking: true,
+		},
+		{
+			name:             "breaking with dash footer",
+			message:          "fix: thing\n\nBREAKING-CHANGE: other thing",
+			expectedOk:       true,
+			expectedType:     "fix",
+			expectedBreaking: true,
+		},
+		{
+			name:         "breaking change in body is not a footer",
+			message:      "fix: thing\n\nBREAKING CHANGE: in the body\n\nRefs: #1",
+			expectedOk:   true,
+			expectedType: "fix",
+		},
+		{
+			name:         "upper case type",
+			message:      "FEAT: shout",
+			expectedOk:   true,
+			expectedType: "feat",
+		},
+		{
+			name:       "not conventional",
+			message:    "update the readme",
+			expectedOk: false,
+		},
+		{
+			name:       "missing space after colon",
+			message:    "feat:thing",
+			expectedOk: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc, ok := buildrc.ParseConventionalCommit(test.message)
+			require.Equal(t, test.expectedOk, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, test.expectedType, cc.Type)
+			assert.Equal(t, test.expectedScope, cc.Scope)
+			assert.Equal(t, test.expectedBreaking, cc.Breaking)
+		})
+	}
+}
+
+func TestGetVersionBumpStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy buildrc.BumpStrategy
+		brc      *buildrc.Buildrc
+		message  string
+		expected string
+	}{
+		{
+			name:     "legacy minor",
+			strategy: buildrc.BumpStrategyPatchIndicator,
+			message:  "feat: add a thing",
+			expected: "v1.3.0",
+		},
+		{
+			name:     "legacy patch",
+			strategy: buildrc.BumpStrategyPatchIndicator,
+			message:  "patch: fix a thing",
+			expected: "v1.2.4",
+		},
+		{
+			name:     "default strategy is legacy",
+			message:  "fix: a thing",
+			expected: "v1.3.0",
+		},
+		{
+			name:     "conventional feat",
+			strategy: buildrc.BumpStrategyConventional,
+			message:  "feat: add a thing",
+			expected: "v1.3.0",
+		},
+		{
+			name:     "conventional fix",
+			strategy: buildrc.BumpStrategyConventional,
+			message:  "fix: a thing",
+			expected: "v1.2.4",
+		},
+		{
+			name:     "conventional breaking",
+			strategy: buildrc.BumpStrategyConventional,
+			message:  "feat!: break a thing",
+			expected: "v2.0.0",
+		},
+		{
+			name:     "conventional unknown type",
+			strategy: buildrc.BumpStrategyConventional,
+			message:  "chore: tidy",
+			expected: "v1.2.4",
+		},
+		{
+			name:     "conventional not conventional",
+			strategy: buildrc.BumpStrategyConventional,
+			message:  "tidy",
+			expected: "v1.2.4",
+		},
+		{
+			name:     "conventional custom mapping",
+			strategy: buildrc.BumpStrategyConventional,
+			brc:      &buildrc.Buildrc{BumpRaw: map[string]string{"perf": "minor", "feat": "patch"}},
+			message:  "perf: faster",
+			expected: "v1.3.0",
+		},
+		{
+			name:     "conventional custom mapping overrides default",
+			strategy: buildrc.BumpStrategyConventional,
+			brc:      &buildrc.Buildrc{BumpRaw: map[string]string{"feat": "patch"}},
+			message:  "feat: add a thing",
+			expected: "v1.2.4",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			brc := test.brc
+			if brc == nil {
+				brc = &buildrc.Buildrc{}
+			}
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, brc, &buildrc.GetVersionOpts{
+				Type:                  buildrc.CommitTypeRelease,
+				BumpStrategy:          test.strategy,
+				PatchIndicator:        "patch",
+				CommitMessageOverride: test.message,
+				LatestTagOverride:     "v1.2.3",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
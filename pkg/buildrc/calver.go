@@ -0,0 +1,128 @@
+package buildrc
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+)
+
+type Scheme string
+
+const (
+	SchemeSemver Scheme = "semver"
+	SchemeCalver Scheme = "calver"
+)
+
+const DefaultCalverLayout = "YYYY.MM.MICRO"
+
+var ErrInvalidCalverLayout = errors.New("buildrc.ErrInvalidCalverLayout")
+
+var (
+	calverYearTokens   = []string{"YYYY", "YY", "0Y"}
+	calverPeriodTokens = []string{"MM", "0M", "WW", "0W"}
+)
+
+// CalverLayout is a calendar versioning layout, see https://calver.org. It is always a year, a month or
+// week and a MICRO counter, so calendar versions stay valid semver and are found by the semver tag lookups.
+type CalverLayout struct {
+	// Year is one of YYYY (2026), YY (26) or 0Y (06)
+	Year string
+	// Period is one of MM (1), 0M (01), WW (1) or 0W (01), weeks are ISO weeks
+	Period string
+}
+
+// ParseCalverLayout parses a layout like YYYY.0M.MICRO.
+func ParseCalverLayout(raw string) (*CalverLayout, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrapf(ErrInvalidCalverLayout, "'%s' must have three parts, <year>.<period>.MICRO", raw)
+	}
+
+	if !slices.Contains(calverYearTokens, parts[0]) {
+		return nil, errors.Wrapf(ErrInvalidCalverLayout, "'%s' must start with one of [%s]", raw, strings.Join(calverYearTokens, ", "))
+	}
+
+	if !slices.Contains(calverPeriodTokens, parts[1]) {
+		return nil, errors.Wrapf(ErrInvalidCalverLayout, "'%s' must have one of [%s] in the middle", raw, strings.Join(calverPeriodTokens, ", "))
+	}
+
+	if parts[2] != "MICRO" {
+		return nil, errors.Wrapf(ErrInvalidCalverLayout, "'%s' must end with MICRO", raw)
+	}
+
+	return &CalverLayout{Year: parts[0], Period: parts[1]}, nil
+}
+
+func (me *CalverLayout) String() string {
+	return me.Year + "." + me.Period + ".MICRO"
+}
+
+func (me *CalverLayout) weekly() bool {
+	return me.Period == "WW" || me.Period == "0W"
+}
+
+// period returns the year and period segments of the time.
+func (me *CalverLayout) period(t time.Time) (uint64, uint64) {
+	year, period := t.Year(), int(t.Month())
+	if me.weekly() {
+		year, period = t.ISOWeek()
+	}
+
+	if me.Year != "YYYY" {
+		year %= 100
+	}
+
+	return uint64(year), uint64(period)
+}
+
+// Next returns the version after latest for the period containing t. MICRO restarts at 0 when the
+// period changed, and a latest prerelease is released as is, like a semver patch bump does.
+// A latest version from a later period than t, as left by a clock that was ahead, is continued.
+func (me *CalverLayout) Next(latest *semver.Version, t time.Time) *semver.Version {
+	year, period := me.period(t)
+
+	if latest == nil {
+		return semver.New(year, period, 0, "", "")
+	}
+
+	current := semver.New(year, period, 0, "", "")
+	last := semver.New(latest.Major(), latest.Minor(), 0, "", "")
+
+	switch {
+	case last.LessThan(current):
+		return current
+	case latest.Prerelease() != "":
+		return semver.New(latest.Major(), latest.Minor(), latest.Patch(), "", "")
+	default:
+		return semver.New(latest.Major(), latest.Minor(), latest.Patch()+1, "", "")
+	}
+}
+
+// Format renders the version with the zero padding of the layout, which semver drops.
+func (me *CalverLayout) Format(v *semver.Version) string {
+	var b strings.Builder
+
+	b.WriteString(pad(me.Year, v.Major()) + "." + pad(me.Period, v.Minor()) + "." + strconv.FormatUint(v.Patch(), 10))
+
+	if v.Prerelease() != "" {
+		b.WriteString("-" + v.Prerelease())
+	}
+
+	if v.Metadata() != "" {
+		b.WriteString("+" + v.Metadata())
+	}
+
+	return b.String()
+}
+
+func pad(token string, n uint64) string {
+	if strings.HasPrefix(token, "0") {
+		return fmt.Sprintf("%02d", n)
+	}
+	return strconv.FormatUint(n, 10)
+}
@@ -0,0 +1,203 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestCalverLayoutNext(t *testing.T) {
+	// 2026-10-03 is in ISO week 40
+	now := time.Date(2026, 10, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		layout   string
+		latest   string
+		expected string
+	}{
+		{layout: "YYYY.MM.MICRO", latest: "", expected: "2026.10.0"},
+		{layout: "YYYY.MM.MICRO", latest: "2026.10.2", expected: "2026.10.3"},
+		{layout: "YYYY.MM.MICRO", latest: "v2026.9.7", expected: "2026.10.0"},
+		{layout: "YYYY.MM.MICRO", latest: "v1.4.0", expected: "2026.10.0"},
+		{layout: "YYYY.MM.MICRO", latest: "2026.10.3-pr.12+abcdef0", expected: "2026.10.3"},
+		{layout: "YYYY.MM.MICRO", latest: "2026.11.1", expected: "2026.11.2"},
+		{layout: "YY.0M.MICRO", latest: "26.09.4", expected: "26.10.0"},
+		{layout: "YY.0M.MICRO", latest: "26.10.0", expected: "26.10.1"},
+		{layout: "0Y.MM.MICRO", latest: "", expected: "26.10.0"},
+		{layout: "YYYY.WW.MICRO", latest: "2026.40.1", expected: "2026.40.2"},
+		{layout: "YYYY.0W.MICRO", latest: "2026.39.1", expected: "2026.40.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.layout+"/"+test.latest, func(t *testing.T) {
+			layout, err := buildrc.ParseCalverLayout(test.layout)
+			require.NoError(t, err)
+
+			var latest *semver.Version
+			if test.latest != "" {
+				latest = semver.MustParse(test.latest)
+			}
+
+			assert.Equal(t, test.expected, layout.Format(layout.Next(latest, now)))
+		})
+	}
+}
+
+func TestCalverLayoutFormatPadding(t *testing.T) {
+	layout, err := buildrc.ParseCalverLayout("0Y.0M.MICRO")
+	require.NoError(t, err)
+
+	v := layout.Next(nil, time.Date(2006, 1, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "06.01.0", layout.Format(v))
+
+	week, err := buildrc.ParseCalverLayout("YYYY.0W.MICRO")
+	require.NoError(t, err)
+
+	// 2027-01-01 belongs to the last ISO week of 2026
+	v = week.Next(nil, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2026.53.0", week.Format(v))
+}
+
+func TestParseCalverLayoutInvalid(t *testing.T) {
+	for _, raw := range []string{"", "YYYY.MM", "YYYY.MM.DD", "MM.YYYY.MICRO", "YYYY.DD.MICRO", "YYYY.MM.MICRO.MICRO"} {
+		t.Run(raw, func(t *testing.T) {
+			_, err := buildrc.ParseCalverLayout(raw)
+			assert.ErrorIs(t, err, buildrc.ErrInvalidCalverLayout)
+		})
+	}
+}
+
+func TestParseBuildrcScheme(t *testing.T) {
+	brc, err := buildrc.ParseBuildrc([]byte("scheme: calver\ncalver-layout: YY.0M.MICRO\n"))
+	require.NoError(t, err)
+	assert.Equal(t, buildrc.SchemeCalver, brc.Scheme())
+
+	layout, err := brc.CalverLayout()
+	require.NoError(t, err)
+	assert.Equal(t, "YY.0M.MICRO", layout.String())
+
+	brc, err = buildrc.ParseBuildrc([]byte("major: 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, buildrc.SchemeSemver, brc.Scheme())
+
+	_, err = buildrc.ParseBuildrc([]byte("scheme: romver\n"))
+	assert.ErrorIs(t, err, buildrc.ErrInvalidBuildrc)
+	assert.ErrorContains(t, err, ".buildrc:1:9: scheme must be one of semver or calver")
+
+	_, err = buildrc.ParseBuildrc([]byte("scheme: calver\ncalver-layout: YYYY.DD.MICRO\n"))
+	assert.ErrorIs(t, err, buildrc.ErrInvalidBuildrc)
+	assert.ErrorContains(t, err, ".buildrc:2:16:")
+}
+
+func TestGetVersionCalver(t *testing.T) {
+	now := time.Date(2026, 10, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		env      *ci.Environment
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+	}{
+		{
+			name: "release in the same month",
+			env:  &ci.Environment{CI: true},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v26.10.2"), nil)
+			},
+			expected: "v26.10.3",
+		},
+		{
+			name: "release in a new month",
+			env:  &ci.Environment{CI: true},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v26.09.7"), nil)
+			},
+			expected: "v26.10.0",
+		},
+		{
+			name: "first release",
+			env:  &ci.Environment{CI: true},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrNoSemverTags)
+			},
+			expected: "v26.10.0",
+		},
+		{
+			name: "tagged head keeps its padding",
+			env:  &ci.Environment{CI: true},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(semver.MustParse("v26.01.4"), nil)
+			},
+			expected: "v26.01.4",
+		},
+		{
+			name: "pull request",
+			env:  &ci.Environment{CI: true, PRNumber: 12},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v26.09.7"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v26.09.7", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}, {Hash: "1234567"}, {Hash: "89abcde"}}, nil)
+			},
+			expected: "v26.10.0-pr.12.3+abcdef0",
+		},
+		{
+			name: "local",
+			env:  &ci.Environment{},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(true)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v26.10.1"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v26.10.1", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListDirtyFiles(mock.Anything).Return([]string{}, nil)
+			},
+			// the digest of no tracked changes, the tree only has untracked files
+			expected: "v26.10.2-local.1+abcdef0.dirty.e3b0c44298fc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{SchemeRaw: "calver", CalverRaw: "YY.0M.MICRO"}, &buildrc.GetVersionOpts{
+				Auto:           true,
+				PatchIndicator: "patch",
+				CI:             test.env,
+				Now:            now,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+func TestGetVersionCalverKeepsTagErrors(t *testing.T) {
+	mockGitProvider := mockery.NewMockGitProvider_git(t)
+	mockGitProvider.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrRefNotFound)
+
+	_, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{SchemeRaw: "calver"}, &buildrc.GetVersionOpts{
+		Type: buildrc.CommitTypeRelease,
+		Now:  time.Date(2026, 10, 3, 12, 0, 0, 0, time.UTC),
+	})
+	assert.ErrorIs(t, err, git.ErrRefNotFound)
+}
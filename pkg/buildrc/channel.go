@@ -0,0 +1,86 @@
+package buildrc
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+var ErrInvalidChannel = errors.New("buildrc.ErrInvalidChannel")
+
+// channelRegex matches a prerelease identifier that is not numeric, so it sorts before its counter.
+var channelRegex = regexp.MustCompile(`^[a-zA-Z][0-9a-zA-Z-]*$`)
+
+// reservedChannels are the prerelease identifiers of pull request and local versions.
+var reservedChannels = []string{string(CommitTypePR), string(CommitTypeLocal)}
+
+// ValidateChannel checks the channel is usable as the first prerelease identifier, like alpha, beta or rc.
+func ValidateChannel(channel string) error {
+	if !channelRegex.MatchString(channel) {
+		return errors.Wrapf(ErrInvalidChannel, "'%s' must start with a letter and only contain letters, digits and hyphens", channel)
+	}
+
+	for _, r := range reservedChannels {
+		if strings.EqualFold(channel, r) {
+			return errors.Wrapf(ErrInvalidChannel, "'%s' is reserved for %s versions", channel, r)
+		}
+	}
+
+	return nil
+}
+
+// channelOf returns the channel of a prerelease like rc.3, or an empty string.
+func channelOf(v *semver.Version) string {
+	channel, counter, ok := strings.Cut(v.Prerelease(), ".")
+	if !ok {
+		return ""
+	}
+
+	if _, err := strconv.ParseUint(counter, 10, 64); err != nil {
+		return ""
+	}
+
+	return channel
+}
+
+// NextPrerelease returns the next prerelease of the core version on the channel, incrementing the
+// counter of the highest existing tag for it, so rc.2 follows rc.1. The first prerelease is rc.1.
+func NextPrerelease(ctx context.Context, gitp git.GitProvider, core *semver.Version, channel string) (*semver.Version, error) {
+
+	if err := ValidateChannel(channel); err != nil {
+		return nil, err
+	}
+
+	tags, err := gitp.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var highest uint64
+
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag.Name)
+		if err != nil {
+			continue
+		}
+
+		if v.Major() != core.Major() || v.Minor() != core.Minor() || v.Patch() != core.Patch() || channelOf(v) != channel {
+			continue
+		}
+
+		n, _ := strconv.ParseUint(strings.TrimPrefix(v.Prerelease(), channel+"."), 10, 64)
+		if n > highest {
+			highest = n
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("core", core.String()).Str("channel", channel).Uint64("highest", highest).Msg("found highest prerelease counter")
+
+	return semver.New(core.Major(), core.Minor(), core.Patch(), channel+"."+strconv.FormatUint(highest+1, 10), ""), nil
+}
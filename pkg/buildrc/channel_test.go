@@ -0,0 +1,172 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestValidateChannel(t *testing.T) {
+	for _, channel := range []string{"alpha", "beta", "rc", "nightly-2"} {
+		assert.NoError(t, buildrc.ValidateChannel(channel), channel)
+	}
+
+	for _, channel := range []string{"", "1rc", "rc.1", "rc_1", "pr", "local", "PR"} {
+		assert.ErrorIs(t, buildrc.ValidateChannel(channel), buildrc.ErrInvalidChannel, channel)
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tags := []*git.Tag{
+		{Name: "v1.2.0"},
+		{Name: "v1.3.0-rc.1"},
+		{Name: "v1.3.0-rc.2"},
+		{Name: "v1.3.0-rc.10"},
+		{Name: "v1.3.0-beta.4"},
+		{Name: "v1.3.1-rc.7"},
+		{Name: "v1.3.0-pr.12.3"},
+		{Name: "not-a-version"},
+	}
+
+	tests := []struct {
+		core     string
+		channel  string
+		expected string
+	}{
+		{core: "1.3.0", channel: "rc", expected: "1.3.0-rc.11"},
+		{core: "1.3.0", channel: "beta", expected: "1.3.0-beta.5"},
+		{core: "1.3.0", channel: "alpha", expected: "1.3.0-alpha.1"},
+		{core: "1.4.0", channel: "rc", expected: "1.4.0-rc.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.core+"-"+test.channel, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			mockGitProvider.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+
+			next, err := buildrc.NextPrerelease(context.TODO(), mockGitProvider, semver.MustParse(test.core), test.channel)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, next.String())
+		})
+	}
+}
+
+func TestBumpApplyPrerelease(t *testing.T) {
+	tests := []struct {
+		version  string
+		bump     buildrc.Bump
+		expected string
+	}{
+		{version: "1.3.0-rc.2", bump: buildrc.BumpPatch, expected: "1.3.0"},
+		{version: "1.3.0-rc.2", bump: buildrc.BumpMinor, expected: "1.3.0"},
+		{version: "1.3.0-rc.2", bump: buildrc.BumpMajor, expected: "2.0.0"},
+		{version: "2.0.0-rc.1", bump: buildrc.BumpMajor, expected: "2.0.0"},
+		{version: "1.3.1-rc.1", bump: buildrc.BumpMinor, expected: "1.4.0"},
+		{version: "1.3.0", bump: buildrc.BumpMinor, expected: "1.4.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version+"/"+test.bump.String(), func(t *testing.T) {
+			work := test.bump.Apply(semver.MustParse(test.version))
+			assert.Equal(t, test.expected, work.String())
+		})
+	}
+}
+
+func TestGetVersionChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		brc      *buildrc.Buildrc
+		channel  string
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+	}{
+		{
+			name:    "first release candidate",
+			brc:     &buildrc.Buildrc{},
+			channel: "rc",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.0"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.0", "HEAD").Return([]*git.Commit{{Message: "feat: thing"}}, nil)
+				m.EXPECT().ListTags(mock.Anything).Return([]*git.Tag{{Name: "v1.2.0"}}, nil)
+			},
+			expected: "v1.3.0-rc.1",
+		},
+		{
+			name:    "next release candidate keeps the core",
+			brc:     &buildrc.Buildrc{ChannelRaw: "rc"},
+			channel: "",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.3.0-rc.1"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.3.0-rc.1", "HEAD").Return([]*git.Commit{{Message: "fix: thing"}}, nil)
+				m.EXPECT().ListTags(mock.Anything).Return([]*git.Tag{{Name: "v1.2.0"}, {Name: "v1.3.0-rc.1"}}, nil)
+			},
+			expected: "v1.3.0-rc.2",
+		},
+		{
+			name:    "tagged release candidate is reused on its channel",
+			brc:     &buildrc.Buildrc{},
+			channel: "rc",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(semver.MustParse("v1.3.0-rc.2"), nil)
+			},
+			expected: "v1.3.0-rc.2",
+		},
+		{
+			name:    "tagged release candidate is promoted",
+			brc:     &buildrc.Buildrc{},
+			channel: "",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(semver.MustParse("v1.3.0-rc.2"), nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.3.0-rc.2"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.3.0-rc.2", "HEAD").Return([]*git.Commit{}, nil)
+				m.EXPECT().GetCurrentCommitMessageFromRef(mock.Anything, "HEAD").Return("feat: thing", nil)
+			},
+			expected: "v1.3.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, test.brc, &buildrc.GetVersionOpts{
+				Auto:           true,
+				BumpStrategy:   buildrc.BumpStrategyConventional,
+				PatchIndicator: "patch",
+				Channel:        test.channel,
+				CI:             &ci.Environment{CI: true},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+func TestParseBuildrcChannel(t *testing.T) {
+	brc, err := buildrc.ParseBuildrc([]byte("channel: beta\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "beta", brc.Channel())
+
+	_, err = buildrc.ParseBuildrc([]byte("major: 1\nchannel: pr\n"))
+	assert.ErrorIs(t, err, buildrc.ErrInvalidBuildrc)
+	assert.ErrorContains(t, err, ".buildrc:2:10:")
+}
@@ -0,0 +1,164 @@
+package buildrc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+// Explanation records how GetVersion arrived at a version. It is filled in when it is passed as
+// GetVersionOpts.Explain, every method is a no-op on a nil Explanation.
+type Explanation struct {
+	Type CommitType `json:"type"`
+	// Auto lists why auto mode picked the type, in the order it was decided, empty if the type was given
+	Auto   []string `json:"auto,omitempty"`
+	Scheme Scheme   `json:"scheme"`
+	// Channel is the prerelease channel cut for a release
+	Channel         string `json:"channel,omitempty"`
+	MaintenanceLine string `json:"maintenance-line,omitempty"`
+	// BaseTag is the version the next one is built on, BaseTagSource says where it came from
+	BaseTag       string `json:"base-tag,omitempty"`
+	BaseTagSource string `json:"base-tag-source"`
+	// Bootstrap is the fallback the base version was read from when no tag is reachable from HEAD
+	Bootstrap BootstrapSource      `json:"bootstrap,omitempty"`
+	Commits   []*ExplanationCommit `json:"commits"`
+	Bump      string               `json:"bump,omitempty"`
+	// MajorFloor is the major version of .buildrc, which raises any lower version to <major>.0.0
+	MajorFloor        uint64 `json:"major-floor"`
+	MajorFloorApplied bool   `json:"major-floor-applied"`
+	Version           string `json:"version"`
+	// Format and Rendering are the ecosystem the version is printed for and how it is printed
+	Format    Format `json:"format,omitempty"`
+	Rendering string `json:"rendering,omitempty"`
+}
+
+// ExplanationCommit is a commit considered for the version. Bump and Rule are only set for
+// commits that decide the bump of a release.
+type ExplanationCommit struct {
+	Hash    string `json:"hash,omitempty"`
+	Subject string `json:"subject"`
+	Bump    string `json:"bump,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+func (me *Explanation) auto(format string, args ...any) {
+	if me == nil {
+		return
+	}
+	me.Auto = append(me.Auto, fmt.Sprintf(format, args...))
+}
+
+func (me *Explanation) base(tag *semver.Version, format string, args ...any) {
+	if me == nil {
+		return
+	}
+	me.BaseTag = ""
+	if tag != nil {
+		me.BaseTag = tag.Original()
+	}
+	me.BaseTagSource = fmt.Sprintf(format, args...)
+}
+
+func (me *Explanation) commit(c *git.Commit, bump Bump, rule string) {
+	if me == nil {
+		return
+	}
+	ec := &ExplanationCommit{Hash: c.Hash, Subject: firstLine(c.Message), Rule: rule}
+	if bump > 0 {
+		ec.Bump = bump.String()
+	}
+	me.Commits = append(me.Commits, ec)
+}
+
+func (me *Explanation) commits(commits []*git.Commit) {
+	for _, c := range commits {
+		me.commit(c, 0, "")
+	}
+}
+
+func (me *Explanation) bump(b Bump) {
+	if me == nil {
+		return
+	}
+	me.Bump = b.String()
+}
+
+func (me *Explanation) floor(major uint64, applied bool) {
+	if me == nil {
+		return
+	}
+	me.MajorFloor = major
+	me.MajorFloorApplied = applied
+}
+
+func (me *Explanation) line(line *MaintenanceLine) {
+	if me == nil || line == nil {
+		return
+	}
+	me.MaintenanceLine = line.String() + " of branch " + line.Branch
+}
+
+// WriteText writes the explanation for people, one decision per line.
+func (me *Explanation) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	typ := "given"
+	if len(me.Auto) > 0 {
+		typ = "auto"
+	}
+	fmt.Fprintf(&b, "type:          %s (%s)\n", me.Type, typ)
+	for _, reason := range me.Auto {
+		fmt.Fprintf(&b, "  - %s\n", reason)
+	}
+
+	fmt.Fprintf(&b, "scheme:        %s\n", me.Scheme)
+	if me.Channel != "" {
+		fmt.Fprintf(&b, "channel:       %s\n", me.Channel)
+	}
+	if me.MaintenanceLine != "" {
+		fmt.Fprintf(&b, "line:          %s\n", me.MaintenanceLine)
+	}
+
+	base := me.BaseTag
+	if base == "" {
+		base = "none"
+	}
+	fmt.Fprintf(&b, "base tag:      %s (%s)\n", base, me.BaseTagSource)
+
+	fmt.Fprintf(&b, "commits:       %d\n", len(me.Commits))
+	for _, c := range me.Commits {
+		hash := c.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		if hash == "" {
+			hash = "-"
+		}
+		fmt.Fprintf(&b, "  - %-7s %s", hash, c.Subject)
+		if c.Bump != "" {
+			fmt.Fprintf(&b, " => %s (%s)", c.Bump, c.Rule)
+		}
+		b.WriteString("\n")
+	}
+
+	if me.Bump != "" {
+		fmt.Fprintf(&b, "bump:          %s\n", me.Bump)
+	}
+
+	applied := "not applied"
+	if me.MajorFloorApplied {
+		applied = "applied"
+	}
+	fmt.Fprintf(&b, "major floor:   %d (%s)\n", me.MajorFloor, applied)
+
+	fmt.Fprintf(&b, "version:       %s\n", me.Version)
+	if me.Rendering != "" {
+		fmt.Fprintf(&b, "rendering:     %s (%s)\n", me.Rendering, me.Format)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,175 @@
+package buildrc_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestGetVersionExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		brc      *buildrc.Buildrc
+		opts     *buildrc.GetVersionOpts
+		setup    func(m *mockery.MockGitProvider_git)
+		expected *buildrc.Explanation
+	}{
+		{
+			name: "auto release with conventional commits",
+			brc:  &buildrc.Buildrc{},
+			opts: &buildrc.GetVersionOpts{Auto: true, BumpStrategy: buildrc.BumpStrategyConventional, CI: &ci.Environment{CI: true}},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{
+					{Hash: "c3", Message: "fix: last\n\nbody"},
+					{Hash: "c2", Message: "feat: middle"},
+					{Hash: "c1", Message: "docs: first"},
+				}, nil)
+			},
+			expected: &buildrc.Explanation{
+				Type:          buildrc.CommitTypeRelease,
+				Auto:          []string{"HEAD is not tagged and no pull request names it, so it is a release"},
+				Scheme:        buildrc.SchemeSemver,
+				BaseTag:       "v1.2.3",
+				BaseTagSource: "latest tag reachable from HEAD",
+				Commits: []*buildrc.ExplanationCommit{
+					{Hash: "c3", Subject: "fix: last", Bump: "patch", Rule: "type fix is a patch bump"},
+					{Hash: "c2", Subject: "feat: middle", Bump: "minor", Rule: "type feat is a minor bump"},
+					{Hash: "c1", Subject: "docs: first", Bump: "patch", Rule: "type docs has no bump rule, defaults to patch"},
+				},
+				Bump:    "minor",
+				Version: "v1.3.0",
+			},
+		},
+		{
+			name:  "major floor of .buildrc",
+			brc:   &buildrc.Buildrc{MajorRaw: 3},
+			opts:  &buildrc.GetVersionOpts{Type: buildrc.CommitTypeRelease, PatchIndicator: "patch", LatestTagOverride: "v1.2.3", CommitMessageOverride: "patch it"},
+			setup: func(m *mockery.MockGitProvider_git) {},
+			expected: &buildrc.Explanation{
+				Type:          buildrc.CommitTypeRelease,
+				Scheme:        buildrc.SchemeSemver,
+				BaseTag:       "v1.2.3",
+				BaseTagSource: "--latest-tag-override",
+				Commits: []*buildrc.ExplanationCommit{
+					{Subject: "patch it", Bump: "patch", Rule: `contains the patch indicator "patch"`},
+				},
+				Bump:              "patch",
+				MajorFloor:        3,
+				MajorFloorApplied: true,
+				Version:           "v3.0.0",
+			},
+		},
+		{
+			name: "pull request from ci",
+			brc:  &buildrc.Buildrc{},
+			opts: &buildrc.GetVersionOpts{Auto: true, CI: &ci.Environment{CI: true, Provider: ci.ProviderGitHubActions, PRNumber: 42}},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Hash: "abcdef0", Message: "wip"}}, nil)
+			},
+			expected: &buildrc.Explanation{
+				Type:          buildrc.CommitTypePR,
+				Auto:          []string{"the github-actions ci environment reports pull request #42"},
+				Scheme:        buildrc.SchemeSemver,
+				BaseTag:       "v1.2.3",
+				BaseTagSource: "latest tag reachable from HEAD",
+				Commits:       []*buildrc.ExplanationCommit{{Hash: "abcdef0", Subject: "wip"}},
+				Version:       "v1.2.3-pr.42.1+abcdef0",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			exp := &buildrc.Explanation{}
+			test.opts.Explain = exp
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, test.brc, test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Version, vers)
+			assert.Equal(t, test.expected, exp)
+		})
+	}
+}
+
+func TestGetVersionExplainPRAware(t *testing.T) {
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+	gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "main").Return(semver.MustParse("v1.2.3"), nil)
+	gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("feature", nil)
+	gitp.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+
+	prp := mockery.NewMockPullRequestProvider_git(t)
+	prp.EXPECT().ListRecentPullRequests(mock.Anything, "HEAD").Return([]*git.PullRequest{{Number: 7, Open: true}}, nil)
+
+	exp := &buildrc.Explanation{}
+	opts := &buildrc.GetVersionOpts{
+		PRAware:      true,
+		PullRequests: prp,
+		CI:           &ci.Environment{CI: true, PRNumber: 7},
+		Explain:      exp,
+	}
+
+	vers, err := buildrc.GetVersion(context.TODO(), gitp, &buildrc.Buildrc{}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0-pr.7+abcdef0", vers)
+	assert.Equal(t, git.TagStrategyCommitToNewPR, opts.PRAwareStrategy)
+
+	assert.Equal(t, &buildrc.Explanation{
+		Type:          buildrc.CommitTypePR,
+		Auto:          []string{"the pr-aware strategy compared HEAD against main and its pull requests: commit-to-new-pr"},
+		Scheme:        buildrc.SchemeSemver,
+		BaseTagSource: "pr-aware strategy",
+		Commits:       []*buildrc.ExplanationCommit{},
+		Version:       "v1.3.0-pr.7+abcdef0",
+	}, exp)
+}
+
+func TestExplanationWriteText(t *testing.T) {
+	exp := &buildrc.Explanation{
+		Type:          buildrc.CommitTypeRelease,
+		Auto:          []string{"HEAD is not tagged and no pull request names it, so it is a release"},
+		Scheme:        buildrc.SchemeSemver,
+		BaseTag:       "v1.2.3",
+		BaseTagSource: "latest tag reachable from HEAD",
+		Commits: []*buildrc.ExplanationCommit{
+			{Hash: "0123456789abcdef", Subject: "feat: thing", Bump: "minor", Rule: "type feat is a minor bump"},
+		},
+		Bump:      "minor",
+		Version:   "v1.3.0",
+		Format:    buildrc.FormatPEP440,
+		Rendering: "1.3.0",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, exp.WriteText(&buf))
+
+	assert.Equal(t, `type:          release (auto)
+  - HEAD is not tagged and no pull request names it, so it is a release
+scheme:        semver
+base tag:      v1.2.3 (latest tag reachable from HEAD)
+commits:       1
+  - 0123456 feat: thing => minor (type feat is a minor bump)
+bump:          minor
+major floor:   0 (not applied)
+version:       v1.3.0
+rendering:     1.3.0 (pep440)
+`, buf.String())
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-faster/errors"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
@@ -16,19 +18,25 @@ var (
 	ErrCouldNotParseRemoteURL = errors.Errorf("could not parse remote url")
 )
 
+// BuildrcJSON is the output of full. Image is org/name, ImagePath keeps the whole namespace
+// of the repository, which is what registries of nested groups expect.
 type BuildrcJSON struct {
-	Version              string   `json:"version"`
-	Revision             string   `json:"revision"`
-	Executable           string   `json:"executable"`
-	Org                  string   `json:"org"`
-	Artifact             string   `json:"artifact"`
-	GoPkg                string   `json:"go-pkg"`
-	Name                 string   `json:"name"`
-	Image                string   `json:"image"`
-	TargetPlatform       string   `json:"target-platform"`
-	TargetPlatformOutDir string   `json:"target-platform-out-dir"`
-	BuildPlatform        string   `json:"build-platform"`
-	GoTestablePackages   []string `json:"go-testable-packages"`
+	Version              string            `json:"version"`
+	Revision             string            `json:"revision"`
+	Executable           string            `json:"executable"`
+	Org                  string            `json:"org"`
+	Host                 string            `json:"host"`
+	Namespace            string            `json:"namespace"`
+	Artifact             string            `json:"artifact"`
+	GoPkg                string            `json:"go-pkg"`
+	Name                 string            `json:"name"`
+	Image                string            `json:"image"`
+	ImagePath            string            `json:"image-path"`
+	TargetPlatform       string            `json:"target-platform"`
+	TargetPlatformOutDir string            `json:"target-platform-out-dir"`
+	BuildPlatform        string            `json:"build-platform"`
+	GoTestablePackages   []string          `json:"go-testable-packages"`
+	Renderings           map[string]string `json:"renderings"`
 }
 
 type BuildrcPackageName string
@@ -56,28 +64,21 @@ func GetExecutable(_ context.Context, name string) string {
 	return name
 }
 
-func GetRepo(ctx context.Context, gitp git.GitProvider) (string, string, error) {
+// GetRepo parses the url of the named remote, or of the default remote if remote is empty.
+func GetRepo(ctx context.Context, gitp git.GitProvider, remote string) (*git.RemoteURL, error) {
 
-	url, err := gitp.GetRemoteURL(ctx)
+	url, err := gitp.GetRemoteURL(ctx, remote)
 	if err != nil {
-		return "", "", err
-	}
-
-	parts := strings.Split(url, "/")
-
-	if len(parts) < 2 {
-		return "", "", ErrCouldNotParseRemoteURL
+		return nil, err
 	}
 
-	org := parts[len(parts)-2]
-
-	if strings.Contains(org, ":") && !strings.HasSuffix(org, ":") {
-		org = strings.Split(org, ":")[1]
+	parsed, err := git.ParseRemoteURL(url)
+	if err != nil {
+		zerolog.Ctx(ctx).Debug().Err(err).Str("url", url).Msg("could not parse remote url")
+		return nil, ErrCouldNotParseRemoteURL
 	}
 
-	trimmed := strings.TrimSuffix(parts[len(parts)-1], ".git")
-
-	return org, trimmed, nil
+	return parsed, nil
 }
 
 func GetGoPkg(_ context.Context, gitp git.GitProvider) (string, error) {
@@ -148,13 +149,7 @@ func GetTestableGoPackages(ctx context.Context, gitp git.GitProvider) ([]string,
 	return resp, nil
 }
 
-func GetBuildrcJSON(ctx context.Context, gitp git.GitProvider, opts *GetVersionOpts) (*BuildrcJSON, error) {
-
-	brc, err := LoadBuildrc(ctx, gitp)
-	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not load buildrc")
-		return nil, err
-	}
+func GetBuildrcJSON(ctx context.Context, gitp git.GitProvider, brc *Buildrc, opts *GetVersionOpts) (*BuildrcJSON, error) {
 
 	tplat, err := GetTargetPlatform(ctx)
 	if err != nil {
@@ -168,52 +163,96 @@ func GetBuildrcJSON(ctx context.Context, gitp git.GitProvider, opts *GetVersionO
 		return nil, err
 	}
 
-	version, err := GetVersion(ctx, gitp, brc, opts)
-	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not get version")
-		return nil, err
-	}
-
-	org, name, err := GetRepo(ctx, gitp)
+	var (
+		version            string
+		repo               *git.RemoteURL
+		revision           string
+		goPkg              string
+		goTestablePackages []string
+	)
+
+	// the git provider is safe for concurrent use, so the independent lookups run in parallel
+	err = runConcurrently(ctx, map[string]func() error{
+		"version": func() (err error) {
+			version, err = GetVersion(ctx, gitp, brc, opts)
+			return err
+		},
+		"repo": func() (err error) {
+			repo, err = GetRepo(ctx, gitp, brc.Remote())
+			return err
+		},
+		"revision": func() (err error) {
+			revision, err = GetRevision(ctx, gitp)
+			return err
+		},
+		"go pkg": func() (err error) {
+			goPkg, err = GetGoPkg(ctx, gitp)
+			return err
+		},
+		"go testable packages": func() (err error) {
+			goTestablePackages, err = GetTestableGoPackages(ctx, gitp)
+			return err
+		},
+	})
 	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not get repo")
 		return nil, err
 	}
 
-	revision, err := GetRevision(ctx, gitp)
-	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not get revision")
-		return nil, err
-	}
+	exec := GetExecutable(ctx, repo.Name)
 
-	goPkg, err := GetGoPkg(ctx, gitp)
-	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not get go pkg")
-		return nil, err
-	}
+	artif := GetArtifactName(ctx, repo.Name, version, tplat)
 
-	goTestablePackages, err := GetTestableGoPackages(ctx, gitp)
-	if err != nil {
-		zerolog.Ctx(ctx).Debug().Err(err).Msg("could not get go testable packages")
-		return nil, err
+	renderings := map[string]string{}
+	if parsed, err := semver.NewVersion(version); err != nil {
+		zerolog.Ctx(ctx).Debug().Err(err).Str("version", version).Msg("could not render version")
+	} else {
+		renderings = RenderAll(parsed)
 	}
 
-	exec := GetExecutable(ctx, name)
-
-	artif := GetArtifactName(ctx, name, version, tplat)
-
 	return &BuildrcJSON{
 		Version:              version,
 		Revision:             revision,
 		Executable:           exec,
-		Image:                org + "/" + name,
+		Image:                repo.Org() + "/" + repo.Name,
+		ImagePath:            repo.Namespace + "/" + repo.Name,
 		Artifact:             artif,
 		GoPkg:                goPkg,
-		Name:                 name,
-		Org:                  org,
+		Name:                 repo.Name,
+		Org:                  repo.Org(),
+		Host:                 repo.Host,
+		Namespace:            repo.Namespace,
 		TargetPlatform:       tplat.String(),
 		TargetPlatformOutDir: tplat.UnderscoreString(),
 		BuildPlatform:        bplat.String(),
 		GoTestablePackages:   goTestablePackages,
+		Renderings:           renderings,
 	}, nil
 }
+
+// runConcurrently runs every func in its own goroutine and returns the first error encountered.
+func runConcurrently(ctx context.Context, funcs map[string]func() error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for name, f := range funcs {
+		wg.Add(1)
+		go func(name string, f func() error) {
+			defer wg.Done()
+			if err := f(); err != nil {
+				zerolog.Ctx(ctx).Debug().Err(err).Msgf("could not get %s", name)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(name, f)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
@@ -9,30 +9,37 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/walteh/buildrc/gen/mockery"
 	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/git"
 )
 
 func TestGetRepo(t *testing.T) {
 	tests := []struct {
 		name          string
+		remote        string
 		remoteURL     string
 		mockError     error
+		expected      *git.RemoteURL
 		expectedOrg   string
-		expectedRepo  string
 		expectedError error
 	}{
 		{
-			name:          "valid https remote",
-			remoteURL:     "https://github.com/org/repo.git",
-			expectedOrg:   "org",
-			expectedRepo:  "repo",
-			expectedError: nil,
+			name:        "valid https remote",
+			remoteURL:   "https://github.com/org/repo.git",
+			expected:    &git.RemoteURL{Host: "github.com", Namespace: "org", Name: "repo"},
+			expectedOrg: "org",
 		},
 		{
-			name:          "valid ssh remote",
-			remoteURL:     "git@github.com:org/repo.git",
-			expectedOrg:   "org",
-			expectedRepo:  "repo",
-			expectedError: nil,
+			name:        "valid ssh remote",
+			remoteURL:   "git@github.com:org/repo.git",
+			expected:    &git.RemoteURL{Host: "github.com", Namespace: "org", Name: "repo"},
+			expectedOrg: "org",
+		},
+		{
+			name:        "named remote with subgroups",
+			remote:      "upstream",
+			remoteURL:   "https://gitlab.com/a/b/c/repo.git",
+			expected:    &git.RemoteURL{Host: "gitlab.com", Namespace: "a/b/c", Name: "repo"},
+			expectedOrg: "a",
 		},
 		{
 			name:          "invalid remote",
@@ -51,17 +58,17 @@ func TestGetRepo(t *testing.T) {
 
 			mockGitProvider := mockery.NewMockGitProvider_git(t)
 
-			mockGitProvider.EXPECT().GetRemoteURL(mock.Anything).Return(test.remoteURL, test.mockError)
+			mockGitProvider.EXPECT().GetRemoteURL(mock.Anything, test.remote).Return(test.remoteURL, test.mockError)
 
-			org, repo, err := buildrc.GetRepo(context.TODO(), mockGitProvider)
+			repo, err := buildrc.GetRepo(context.TODO(), mockGitProvider, test.remote)
 
-			assert.Equal(t, test.expectedOrg, org)
-			assert.Equal(t, test.expectedRepo, repo)
+			assert.Equal(t, test.expected, repo)
 
 			if test.expectedError != nil {
 				assert.EqualError(t, err, test.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, test.expectedOrg, repo.Org())
 			}
 
 			mockGitProvider.AssertExpectations(t)
@@ -0,0 +1,113 @@
+package buildrc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+// WorkingTreeDigest returns a short digest of the tracked changes in the working tree. It only depends on
+// the paths and contents of the changed files, so the same changes always give the same digest.
+func WorkingTreeDigest(ctx context.Context, gitp git.GitProvider) (string, error) {
+
+	paths, err := gitp.ListDirtyFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	for _, path := range paths {
+		byt, err := afero.ReadFile(gitp.Fs(), path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00deleted\x00", path)
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(byt))
+		h.Write(byt)
+	}
+
+	zerolog.Ctx(ctx).Debug().Strs("files", paths).Msg("hashed tracked working tree changes")
+
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// describeLocal turns the next version into a local version like git describe would, as
+// <next>-local.<commits since the tag>+<short hash>, with .dirty.<digest> appended for a dirty tree.
+// from is the ref of the tag, or empty to count every commit. The counted commits are added to exp.
+func describeLocal(ctx context.Context, gitp git.GitProvider, next semver.Version, from string, exp *Explanation) (semver.Version, error) {
+
+	commits, err := gitp.ListCommitsBetweenRefs(ctx, from, "HEAD")
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	exp.commits(commits)
+
+	metadata, err := gitp.GetCurrentShortHashFromRef(ctx, "HEAD")
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	if gitp.Dirty(ctx) {
+		digest, err := WorkingTreeDigest(ctx, gitp)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		metadata += ".dirty." + digest
+	}
+
+	work, err := next.SetPrerelease("local." + strconv.Itoa(len(commits)))
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	return work.SetMetadata(metadata)
+}
+
+// getLocalVersion returns the local version of HEAD, built on the release the latest tag would be bumped to.
+// Without a tag it is built on the bootstrap version.
+func getLocalVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts) (semver.Version, error) {
+
+	base, bootstrapped, err := latestTagOrBootstrap(ctx, gitp, brc, me.Explain)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	if bootstrapped {
+		zerolog.Ctx(ctx).Debug().Msg("no previous tag, counting every commit")
+		return describeLocal(ctx, gitp, *base, "", me.Explain)
+	}
+
+	line, err := CurrentMaintenanceLine(ctx, gitp, brc, me.CI, CommitTypeLocal)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	me.Explain.line(line)
+
+	var next semver.Version
+	if line != nil {
+		next, err = getMaintenanceReleaseVersion(ctx, gitp, brc, me, line)
+	} else {
+		next, err = getReleaseVersion(ctx, gitp, brc, me)
+	}
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	// the commits since the tag were already explained by the release bump
+	return describeLocal(ctx, gitp, next, "refs/tags/"+base.Original(), nil)
+}
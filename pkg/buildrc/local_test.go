@@ -0,0 +1,105 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestWorkingTreeDigest(t *testing.T) {
+	ctx := context.TODO()
+
+	fls := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fls, "a.txt", []byte("a"), 0600))
+
+	mockGitProvider := mockery.NewMockGitProvider_git(t)
+	mockGitProvider.EXPECT().Fs().Return(fls)
+	// deleted.txt is a tracked file removed from the working tree
+	mockGitProvider.EXPECT().ListDirtyFiles(mock.Anything).Return([]string{"a.txt", "deleted.txt"}, nil)
+
+	first, err := buildrc.WorkingTreeDigest(ctx, mockGitProvider)
+	require.NoError(t, err)
+	assert.Len(t, first, 12)
+
+	again, err := buildrc.WorkingTreeDigest(ctx, mockGitProvider)
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "the same changes give the same digest")
+
+	require.NoError(t, afero.WriteFile(fls, "a.txt", []byte("b"), 0600))
+
+	changed, err := buildrc.WorkingTreeDigest(ctx, mockGitProvider)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+}
+
+func TestGetVersionLocal(t *testing.T) {
+	tests := []struct {
+		name     string
+		brc      *buildrc.Buildrc
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+	}{
+		{
+			name: "clean tree",
+			brc:  &buildrc.Buildrc{},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Message: "feat: one"}, {Message: "two"}, {Message: "three"}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+			},
+			expected: "v1.3.0-local.3+abcdef0",
+		},
+		{
+			name: "no tags",
+			brc:  &buildrc.Buildrc{MajorRaw: 2},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrNoSemverTags)
+				m.EXPECT().Fs().Return(afero.NewMemMapFs())
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "", "HEAD").Return([]*git.Commit{{}, {}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+			},
+			expected: "v2.0.0-local.2+abcdef0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, test.brc, &buildrc.GetVersionOpts{
+				Type:           buildrc.CommitTypeLocal,
+				BumpStrategy:   buildrc.BumpStrategyConventional,
+				PatchIndicator: "patch",
+				CI:             &ci.Environment{},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+func TestGetVersionCalverSourceDateEpoch(t *testing.T) {
+	// 2026-03-01T00:00:00Z
+	t.Setenv(git.SourceDateEpochEnv, "1772323200")
+
+	mockGitProvider := mockery.NewMockGitProvider_git(t)
+	mockGitProvider.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v2026.2.4"), nil)
+
+	vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{SchemeRaw: "calver"}, &buildrc.GetVersionOpts{
+		Type: buildrc.CommitTypeRelease,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v2026.3.0", vers)
+}
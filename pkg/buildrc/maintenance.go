@@ -0,0 +1,308 @@
+package buildrc
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+var (
+	ErrInvalidMaintenanceBranch = errors.New("buildrc.ErrInvalidMaintenanceBranch")
+	ErrOutsideMaintenanceLine   = errors.New("buildrc.ErrOutsideMaintenanceLine")
+)
+
+// MaintenanceBranch configures the branches an older release line is maintained on.
+//
+// Branch is the branch name, where * matches anything and {major} and {minor} capture the line
+// from the name, as in release/{major}.{minor}.x. Line sets it instead, as 1.4 or 1.
+type MaintenanceBranch struct {
+	Branch string `yaml:"branch" json:"branch"`
+	Line   string `yaml:"line" json:"line,omitempty"`
+}
+
+// MaintenanceLine is the versions a maintenance branch may release. A major.minor line only takes
+// patch releases, a major line also takes minor releases.
+type MaintenanceLine struct {
+	Branch string
+	Major  uint64
+	// Minor is nil for a major line
+	Minor *uint64
+}
+
+func (me *MaintenanceLine) String() string {
+	if me.Minor == nil {
+		return strconv.FormatUint(me.Major, 10) + ".x"
+	}
+	return strconv.FormatUint(me.Major, 10) + "." + strconv.FormatUint(*me.Minor, 10) + ".x"
+}
+
+// Contains reports whether the core of the version is on the line.
+func (me *MaintenanceLine) Contains(v *semver.Version) bool {
+	return v.Major() == me.Major && (me.Minor == nil || v.Minor() == *me.Minor)
+}
+
+// Allows reports whether the bump stays on the line.
+func (me *MaintenanceLine) Allows(b Bump) bool {
+	if me.Minor == nil {
+		return b < BumpMajor
+	}
+	return b < BumpMinor
+}
+
+// Floor returns the first version of the line.
+func (me *MaintenanceLine) Floor() semver.Version {
+	if me.Minor == nil {
+		return *semver.New(me.Major, 0, 0, "", "")
+	}
+	return *semver.New(me.Major, *me.Minor, 0, "", "")
+}
+
+// pattern compiles the branch pattern, capturing the {major} and {minor} placeholders.
+func (me *MaintenanceBranch) pattern() (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(me.Branch)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.Replace(expr, `\{major\}`, `(?P<major>[0-9]+)`, 1)
+	expr = strings.Replace(expr, `\{minor\}`, `(?P<minor>[0-9]+)`, 1)
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "'%s': %v", me.Branch, err)
+	}
+
+	return re, nil
+}
+
+// validate checks the pattern compiles and the line is known, either from Line or the placeholders.
+func (me *MaintenanceBranch) validate() error {
+	if me.Branch == "" {
+		return errors.Wrap(ErrInvalidMaintenanceBranch, "branch must not be empty")
+	}
+
+	re, err := me.pattern()
+	if err != nil {
+		return err
+	}
+
+	if me.Line != "" {
+		_, _, err := parseLine(me.Line)
+		return err
+	}
+
+	if re.SubexpIndex("major") < 0 {
+		return errors.Wrapf(ErrInvalidMaintenanceBranch, "'%s' must capture {major} or set a line", me.Branch)
+	}
+
+	return nil
+}
+
+// match returns the line of the branch, or nil if the branch does not match the pattern.
+func (me *MaintenanceBranch) match(branch string) (*MaintenanceLine, error) {
+	re, err := me.pattern()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := re.FindStringSubmatch(branch)
+	if groups == nil {
+		return nil, nil
+	}
+
+	line := &MaintenanceLine{Branch: branch}
+
+	if me.Line != "" {
+		line.Major, line.Minor, err = parseLine(me.Line)
+		return line, err
+	}
+
+	line.Major, err = strconv.ParseUint(groups[re.SubexpIndex("major")], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "'%s': %v", branch, err)
+	}
+
+	if i := re.SubexpIndex("minor"); i >= 0 {
+		minor, err := strconv.ParseUint(groups[i], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "'%s': %v", branch, err)
+		}
+		line.Minor = &minor
+	}
+
+	return line, nil
+}
+
+// parseLine parses a line like 1.4, 1.4.x, 1 or 1.x.
+func parseLine(raw string) (uint64, *uint64, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(raw, "v"), ".x"), ".")
+	if len(parts) > 2 {
+		return 0, nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "line '%s' must be <major> or <major>.<minor>", raw)
+	}
+
+	major, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "line '%s' must be <major> or <major>.<minor>", raw)
+	}
+
+	if len(parts) == 1 {
+		return major, nil, nil
+	}
+
+	minor, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, nil, errors.Wrapf(ErrInvalidMaintenanceBranch, "line '%s' must be <major> or <major>.<minor>", raw)
+	}
+
+	return major, &minor, nil
+}
+
+// CurrentMaintenanceLine returns the line of the branch being versioned, or nil if it is not a
+// maintenance branch. Pull requests are on the line of the branch they target.
+func CurrentMaintenanceLine(ctx context.Context, gitp git.GitProvider, brc *Buildrc, env *ci.Environment, typ CommitType) (*MaintenanceLine, error) {
+
+	if len(brc.MaintenanceRaw) == 0 {
+		return nil, nil
+	}
+
+	var branch string
+
+	switch {
+	case typ == CommitTypePR:
+		if env == nil || env.TargetBranch == "" {
+			return nil, nil
+		}
+		branch = env.TargetBranch
+	case env != nil && env.SourceBranch != "":
+		branch = env.SourceBranch
+	default:
+		b, err := gitp.GetCurrentBranchFromRef(ctx, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		branch = b
+	}
+
+	line, err := brc.MaintenanceLine(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if line != nil {
+		zerolog.Ctx(ctx).Debug().Str("branch", branch).Str("line", line.String()).Msg("versioning on a maintenance branch")
+	}
+
+	return line, nil
+}
+
+// latestOnLine returns the highest tagged version on the line, or nil if there is none. All tags are
+// considered, not only those reachable from HEAD, so a version released elsewhere is never reused.
+func latestOnLine(ctx context.Context, gitp git.GitProvider, line *MaintenanceLine) (*semver.Version, error) {
+	tags, err := gitp.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *semver.Version
+
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag.Name)
+		if err != nil || !line.Contains(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+
+	return latest, nil
+}
+
+// getMaintenanceReleaseVersion returns the next release on the line. Every bump must stay on the line,
+// except with the patch indicator strategy, where a commit without the indicator is a patch release.
+func getMaintenanceReleaseVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts, line *MaintenanceLine) (semver.Version, error) {
+
+	var latest *semver.Version
+	var err error
+
+	if me.LatestTagOverride != "" {
+		latest, err = semver.NewVersion(me.LatestTagOverride)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		me.Explain.base(latest, "--latest-tag-override")
+	} else {
+		latest, err = latestOnLine(ctx, gitp, line)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		me.Explain.base(latest, "highest tag on %s", line.String())
+	}
+
+	if latest == nil {
+		zerolog.Ctx(ctx).Debug().Str("line", line.String()).Msg("no tags on the maintenance line, starting at its first version")
+		floor := line.Floor()
+		me.Explain.base(nil, "no tag on %s, starting at %s", line.String(), floor.String())
+		return floor, nil
+	}
+
+	if !line.Contains(latest) {
+		return semver.Version{}, errors.Wrapf(ErrOutsideMaintenanceLine, "latest tag %s is not on %s of branch %s", latest.Original(), line.String(), line.Branch)
+	}
+
+	var commits []*git.Commit
+
+	switch {
+	case me.CommitMessageOverride != "":
+		commits = []*git.Commit{{Message: me.CommitMessageOverride}}
+	case me.LatestTagOverride != "":
+		message, err := gitp.GetCurrentCommitMessageFromRef(ctx, "HEAD")
+		if err != nil {
+			return semver.Version{}, err
+		}
+		commits = []*git.Commit{{Message: message}}
+	default:
+		commits, err = getCommitsSinceTag(ctx, gitp, latest)
+		if err != nil {
+			return semver.Version{}, err
+		}
+	}
+
+	var bump Bump
+	for _, c := range commits {
+		b, rule, err := bumpFromMessage(ctx, brc, me.BumpStrategy, me.PatchIndicator, c.Message)
+		if err != nil {
+			return semver.Version{}, err
+		}
+
+		if !line.Allows(b) {
+			if me.BumpStrategy != BumpStrategyConventional {
+				b = BumpPatch
+				rule += ", clamped to patch on " + line.String()
+			} else {
+				return semver.Version{}, errors.Wrapf(ErrOutsideMaintenanceLine, "a %s bump of %s leaves %s of branch %s: %q", b.String(), latest.Original(), line.String(), line.Branch, firstLine(c.Message))
+			}
+		}
+
+		me.Explain.commit(c, b, rule)
+
+		if b > bump {
+			bump = b
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Int("commits", len(commits)).Str("bump", bump.String()).Str("line", line.String()).Msg("calculated maintenance bump")
+
+	me.Explain.bump(bump)
+
+	return bump.Apply(latest), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
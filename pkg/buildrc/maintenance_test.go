@@ -0,0 +1,215 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestMaintenanceLine(t *testing.T) {
+	brc, err := buildrc.ParseBuildrc([]byte(`
+maintenance-branches:
+  - branch: release/{major}.{minor}.x
+  - branch: support/v{major}
+  - branch: legacy-*
+    line: "0.9"
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		branch   string
+		expected string
+	}{
+		{branch: "release/1.4.x", expected: "1.4.x"},
+		{branch: "support/v2", expected: "2.x"},
+		{branch: "legacy-fixes", expected: "0.9.x"},
+		{branch: "main", expected: ""},
+		{branch: "release/1.4", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.branch, func(t *testing.T) {
+			line, err := brc.MaintenanceLine(test.branch)
+			require.NoError(t, err)
+			if test.expected == "" {
+				assert.Nil(t, line)
+				return
+			}
+			require.NotNil(t, line)
+			assert.Equal(t, test.expected, line.String())
+			assert.Equal(t, test.branch, line.Branch)
+		})
+	}
+}
+
+func TestParseBuildrcMaintenanceBranchesInvalid(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "maintenance-branches:\n  - branch: release/next\n", expected: ".buildrc:2:5:"},
+		{raw: "maintenance-branches:\n  - branch: release/*\n    line: one\n", expected: "line 'one' must be <major> or <major>.<minor>"},
+		{raw: "maintenance-branches:\n  - line: \"1.4\"\n", expected: "branch must not be empty"},
+		{raw: "maintenance-branches:\n  - branch: release/*\n    lines: \"1.4\"\n", expected: `unknown key "lines"`},
+		{raw: "scheme: calver\nmaintenance-branches:\n  - branch: release/{major}.x\n", expected: "maintenance-branches only apply to the semver scheme"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			_, err := buildrc.ParseBuildrc([]byte(test.raw))
+			assert.ErrorIs(t, err, buildrc.ErrInvalidBuildrc)
+			assert.ErrorContains(t, err, test.expected)
+		})
+	}
+}
+
+func TestGetVersionMaintenanceBranch(t *testing.T) {
+	tags := []*git.Tag{
+		{Name: "v1.4.0"},
+		{Name: "v1.4.1"},
+		{Name: "v1.4.2"},
+		{Name: "v1.5.0"},
+		{Name: "v2.0.0"},
+	}
+
+	tests := []struct {
+		name     string
+		env      *ci.Environment
+		strategy buildrc.BumpStrategy
+		channel  string
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+		err      error
+	}{
+		{
+			name:     "patch release on the line",
+			env:      &ci.Environment{CI: true, SourceBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Message: "fix: backport"}}, nil)
+			},
+			expected: "v1.4.3",
+		},
+		{
+			name:     "branch from the checkout outside ci",
+			env:      &ci.Environment{CI: true},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("release/1.4.x", nil)
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Message: "fix: backport"}}, nil)
+			},
+			expected: "v1.4.3",
+		},
+		{
+			name:     "feature leaves the line",
+			env:      &ci.Environment{CI: true, SourceBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Message: "fix: backport"}, {Message: "feat: new thing"}}, nil)
+			},
+			err: buildrc.ErrOutsideMaintenanceLine,
+		},
+		{
+			name:     "patch indicator never leaves the line",
+			env:      &ci.Environment{CI: true, SourceBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyPatchIndicator,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Message: "backport the fix"}}, nil)
+			},
+			expected: "v1.4.3",
+		},
+		{
+			name:     "release candidate on the line",
+			env:      &ci.Environment{CI: true, SourceBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			channel:  "rc",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Message: "fix: backport"}}, nil)
+			},
+			expected: "v1.4.3-rc.1",
+		},
+		{
+			name:     "first release of a new line",
+			env:      &ci.Environment{CI: true, SourceBranch: "release/1.6.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().ListTags(mock.Anything).Return(tags, nil)
+			},
+			expected: "v1.6.0",
+		},
+		{
+			name:     "main is not a maintenance branch",
+			env:      &ci.Environment{CI: true, SourceBranch: "main"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v2.0.0"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v2.0.0", "HEAD").Return([]*git.Commit{{Message: "feat: new thing"}}, nil)
+			},
+			expected: "v2.1.0",
+		},
+		{
+			name:     "pull request to the line",
+			env:      &ci.Environment{CI: true, PRNumber: 9, TargetBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.4.2"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.4.2", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}}, nil)
+			},
+			expected: "v1.4.2-pr.9.1+abcdef0",
+		},
+		{
+			name:     "pull request based outside the line",
+			env:      &ci.Environment{CI: true, PRNumber: 9, TargetBranch: "release/1.4.x"},
+			strategy: buildrc.BumpStrategyConventional,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.5.0"), nil)
+			},
+			err: buildrc.ErrOutsideMaintenanceLine,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			if test.env.PRNumber == 0 {
+				mockGitProvider.EXPECT().Dirty(mock.Anything).Return(false)
+				mockGitProvider.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				mockGitProvider.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+			}
+			test.setup(mockGitProvider)
+
+			brc := &buildrc.Buildrc{
+				MajorRaw:       2,
+				MaintenanceRaw: []buildrc.MaintenanceBranch{{Branch: "release/{major}.{minor}.x"}},
+			}
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, brc, &buildrc.GetVersionOpts{
+				Auto:           true,
+				BumpStrategy:   test.strategy,
+				PatchIndicator: "patch",
+				Channel:        test.channel,
+				CI:             test.env,
+			})
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
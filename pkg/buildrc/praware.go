@@ -0,0 +1,54 @@
+package buildrc
+
+import (
+	"context"
+
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+// getPRAwareVersion calculates the version from how HEAD relates to the main branch and its pull requests.
+// On a maintenance branch the line replaces the main branch and the major version of .buildrc.
+func getPRAwareVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts, prefix string) (string, error) {
+
+	if me.PullRequests == nil {
+		return "", errors.Errorf("the pr-aware strategy needs a pull request provider")
+	}
+
+	me.Type = CommitTypeRelease
+	if me.CI != nil && me.CI.PRNumber > 0 {
+		me.Type = CommitTypePR
+	}
+
+	line, err := CurrentMaintenanceLine(ctx, gitp, brc, me.CI, me.Type)
+	if err != nil {
+		return "", err
+	}
+
+	me.Explain.line(line)
+
+	major, mainBranch := brc.Major(), brc.MainBranch()
+	if line != nil {
+		major, mainBranch = 0, line.Branch
+	}
+
+	vers, strategy, err := git.CalculateNextPreReleaseTag(ctx, major, gitp, me.PullRequests, mainBranch)
+	if err != nil {
+		return "", err
+	}
+
+	if line != nil && !line.Contains(vers) {
+		return "", errors.Wrapf(ErrOutsideMaintenanceLine, "%s (%s) is not on %s of branch %s", vers.String(), strategy, line.String(), line.Branch)
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("strategy", string(strategy)).Str("version", vers.String()).Msg("calculated pr-aware version")
+
+	me.PRAwareStrategy = strategy
+
+	me.Explain.auto("the pr-aware strategy compared HEAD against %s and its pull requests: %s", mainBranch, strategy)
+	me.Explain.base(nil, "pr-aware strategy")
+	me.Explain.floor(major, false)
+
+	return prefix + vers.String(), nil
+}
@@ -0,0 +1,112 @@
+package buildrc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/git"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+var ErrInvalidGoVersion = errors.New("buildrc.ErrInvalidGoVersion")
+
+// GetGoPseudoVersion returns the version the go command resolves HEAD of the module in go.mod to. A commit
+// tagged with a valid module version is that version, any other commit is a pseudo-version like
+// v1.2.4-0.20240301120000-abcdefabcdef, built on the highest tag reachable from HEAD, the committer time
+// and the 12 character commit hash. Like the go command, only canonical tags matching the major version
+// suffix of the module path are considered.
+func GetGoPseudoVersion(ctx context.Context, gitp git.GitProvider) (string, error) {
+
+	path, err := GetGoPkg(ctx, gitp)
+	if err != nil {
+		return "", err
+	}
+
+	_, pathMajor, ok := module.SplitPathVersion(path)
+	if !ok {
+		return "", errors.Wrapf(ErrInvalidGoVersion, "invalid module path '%s'", path)
+	}
+
+	head, err := gitp.GetCommitFromRef(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	tags, err := goModuleTags(ctx, gitp, path, pathMajor)
+	if err != nil {
+		return "", err
+	}
+
+	for _, tag := range tags {
+		if tag.Commit == head.Hash {
+			return tag.Name, nil
+		}
+	}
+
+	older := ""
+	for _, tag := range tags {
+		// the tag is reachable from HEAD when it has no commits HEAD does not have
+		missing, err := gitp.ListCommitsBetweenRefs(ctx, head.Hash, tag.Commit)
+		if err != nil {
+			return "", err
+		}
+		if len(missing) == 0 {
+			older = tag.Name
+			break
+		}
+	}
+
+	if older == "" {
+		zerolog.Ctx(ctx).Debug().Msg("no previous tag, the pseudo-version is based on the module major")
+	}
+
+	major := module.PathMajorPrefix(pathMajor)
+	if major == "" {
+		major = "v0"
+	}
+
+	if len(head.Hash) < 12 {
+		return "", errors.Wrapf(ErrInvalidGoVersion, "commit hash '%s' is too short", head.Hash)
+	}
+
+	// the go command shortens revisions to 12 characters
+	vers := module.PseudoVersion(major, older, head.CommitDate, head.Hash[:12])
+
+	if err := module.Check(path, vers); err != nil {
+		return "", errors.Wrapf(ErrInvalidGoVersion, "%v", err)
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("module", path).Str("base", older).Str("version", vers).Msg("calculated go pseudo-version")
+
+	return vers, nil
+}
+
+// goModuleTags returns the tags that are canonical module versions with a major version matching the
+// module path, highest first.
+func goModuleTags(ctx context.Context, gitp git.GitProvider, path string, pathMajor string) ([]*git.Tag, error) {
+	tags, err := gitp.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := []*git.Tag{}
+	for _, tag := range tags {
+		if !modsemver.IsValid(tag.Name) || modsemver.Canonical(tag.Name) != tag.Name {
+			continue
+		}
+		if err := module.CheckPathMajor(tag.Name, pathMajor); err != nil {
+			zerolog.Ctx(ctx).Debug().Str("tag", tag.Name).Str("module", path).Msg("ignoring tag of another major version")
+			continue
+		}
+		valid = append(valid, tag)
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool {
+		return modsemver.Compare(valid[i].Name, valid[j].Name) > 0
+	})
+
+	return valid, nil
+}
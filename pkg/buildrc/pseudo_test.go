@@ -0,0 +1,90 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestGetGoPseudoVersion(t *testing.T) {
+	head := &git.Commit{
+		Hash: "abcdefabcdef0123456789abcdefabcdef012345",
+		// the author date is not used, the go command uses the committer date
+		Date:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		CommitDate: time.Date(2024, 3, 1, 13, 4, 5, 0, time.FixedZone("CET", 3600)),
+	}
+
+	// tags on HEAD, on its history, and on a branch HEAD does not contain
+	onHead := func(name string) *git.Tag { return &git.Tag{Name: name, Commit: head.Hash} }
+	reachable := func(name string) *git.Tag {
+		return &git.Tag{Name: name, Commit: "1111111111111111111111111111111111111111"}
+	}
+	unreachable := func(name string) *git.Tag {
+		return &git.Tag{Name: name, Commit: "2222222222222222222222222222222222222222"}
+	}
+
+	tests := []struct {
+		name     string
+		module   string
+		tags     []*git.Tag
+		expected string
+	}{
+		{name: "no tags", module: "github.com/walteh/buildrc", expected: "v0.0.0-20240301120405-abcdefabcdef"},
+		{name: "after a release", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("v1.2.3")}, expected: "v1.2.4-0.20240301120405-abcdefabcdef"},
+		{name: "after a prerelease", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("v1.3.0-rc.1")}, expected: "v1.3.0-rc.1.0.20240301120405-abcdefabcdef"},
+		{name: "highest reachable tag", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("v1.2.3"), unreachable("v1.9.0"), reachable("v1.10.0"), reachable("v1.4.0")}, expected: "v1.10.1-0.20240301120405-abcdefabcdef"},
+		{name: "tagged", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("v1.2.2"), onHead("v1.2.3")}, expected: "v1.2.3"},
+		{name: "major suffix without tags", module: "github.com/walteh/buildrc/v2", expected: "v2.0.0-20240301120405-abcdefabcdef"},
+		{name: "major suffix", module: "github.com/walteh/buildrc/v2", tags: []*git.Tag{reachable("v2.1.0")}, expected: "v2.1.1-0.20240301120405-abcdefabcdef"},
+		{name: "tags of another major are ignored", module: "github.com/walteh/buildrc/v2", tags: []*git.Tag{reachable("v2.1.0"), reachable("v1.5.0"), reachable("v3.0.0")}, expected: "v2.1.1-0.20240301120405-abcdefabcdef"},
+		{name: "only tags of another major", module: "github.com/walteh/buildrc/v2", tags: []*git.Tag{reachable("v1.5.0")}, expected: "v2.0.0-20240301120405-abcdefabcdef"},
+		{name: "v2 tag without a major suffix", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("v1.0.0"), onHead("v2.0.0")}, expected: "v1.0.1-0.20240301120405-abcdefabcdef"},
+		{name: "tags that are not canonical are ignored", module: "github.com/walteh/buildrc", tags: []*git.Tag{reachable("1.2.3"), reachable("v1.2"), reachable("v1.0.0")}, expected: "v1.0.1-0.20240301120405-abcdefabcdef"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fls := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fls, "go.mod", []byte("module "+test.module+"\n\ngo 1.21\n"), 0600))
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			mockGitProvider.EXPECT().Fs().Return(fls)
+			mockGitProvider.EXPECT().GetCommitFromRef(mock.Anything, "HEAD").Return(head, nil)
+			mockGitProvider.EXPECT().ListTags(mock.Anything).Return(append([]*git.Tag{}, test.tags...), nil)
+			mockGitProvider.EXPECT().ListCommitsBetweenRefs(mock.Anything, head.Hash, mock.Anything).RunAndReturn(func(_ context.Context, _ string, to string) ([]*git.Commit, error) {
+				if to == unreachable("").Commit {
+					return []*git.Commit{{Hash: to}}, nil
+				}
+				return []*git.Commit{}, nil
+			}).Maybe()
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{}, &buildrc.GetVersionOpts{
+				GoPseudoVersion: true,
+				ExcludeV:        true,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+func TestGetGoPseudoVersionKeepsTagErrors(t *testing.T) {
+	fls := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fls, "go.mod", []byte("module github.com/walteh/buildrc\n"), 0600))
+
+	mockGitProvider := mockery.NewMockGitProvider_git(t)
+	mockGitProvider.EXPECT().Fs().Return(fls)
+	mockGitProvider.EXPECT().GetCommitFromRef(mock.Anything, "HEAD").Return(&git.Commit{Hash: "abcdefabcdef0123456789abcdefabcdef012345"}, nil)
+	mockGitProvider.EXPECT().ListTags(mock.Anything).Return(nil, git.ErrNotAGitRepository)
+
+	_, err := buildrc.GetGoPseudoVersion(context.TODO(), mockGitProvider)
+	assert.ErrorIs(t, err, git.ErrNotAGitRepository)
+}
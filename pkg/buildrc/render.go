@@ -0,0 +1,272 @@
+package buildrc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	modsemver "golang.org/x/mod/semver"
+)
+
+// Format is an ecosystem a version is rendered for.
+type Format string
+
+const (
+	FormatSemver   Format = "semver"
+	FormatDocker   Format = "docker"
+	FormatPEP440   Format = "pep440"
+	FormatDebian   Format = "debian"
+	FormatRPM      Format = "rpm"
+	FormatNPM      Format = "npm"
+	FormatMaven    Format = "maven"
+	FormatGoModule Format = "go-module"
+)
+
+// Formats are the supported renderings, in the order they are listed.
+var Formats = []Format{FormatSemver, FormatDocker, FormatPEP440, FormatDebian, FormatRPM, FormatNPM, FormatMaven, FormatGoModule}
+
+var ErrUnknownFormat = errors.New("buildrc.ErrUnknownFormat")
+var ErrInvalidRendering = errors.New("buildrc.ErrInvalidRendering")
+
+// ParseFormat returns the format of the name, one of Formats.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+
+	return "", errors.Wrapf(ErrUnknownFormat, "'%s' must be one of [%s]", name, strings.Join(names, ", "))
+}
+
+var (
+	dockerTagRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	pep440Regex    = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)(?:(a|b|rc)([0-9]+))?(?:\.dev([0-9]+))?(?:\+([a-z0-9]+(?:\.[a-z0-9]+)*))?$`)
+	// hashRegex matches a commit hash or digest, rendered with a g prefix in pep440 like git describe does
+	hashRegex = regexp.MustCompile(`^[0-9a-f]*[a-f][0-9a-f]*$`)
+)
+
+// pep440Channels maps the prerelease channels python knows to their pep440 spelling.
+var pep440Channels = map[string]string{"alpha": "a", "beta": "b", "rc": "rc"}
+
+// Render converts the version to the form the ecosystem expects, none of them have a v prefix except go-module:
+//
+//   - semver and npm: 1.2.3-rc.1+abcdef0
+//   - docker: 1.2.3-rc.1_abcdef0, as tags can not contain +
+//   - pep440: 1.2.3rc1+gabcdef0 for alpha, beta and rc, any other prerelease is a dev release, 1.2.3.dev5+local.gabcdef0
+//   - debian: 1.2.3~rc.1+abcdef0, ~ sorts before the release
+//   - rpm: 1.2.3~rc.1^abcdef0, with - in identifiers written as _
+//   - maven: 1.2.3-rc.1, without build metadata
+//   - go-module: v1.2.3-rc.1, a canonical module version without build metadata
+//
+// The zero padding of calendar versions is kept, except for go-module which must be canonical.
+func Render(v *semver.Version, format Format) (string, error) {
+
+	core := coreOf(v)
+	pre := v.Prerelease()
+	meta := v.Metadata()
+
+	switch format {
+	case FormatSemver, FormatNPM:
+		return joinVersion(core, "-", pre, "+", meta), nil
+	case FormatDocker:
+		tag := joinVersion(core, "-", pre, "_", meta)
+		if !dockerTagRegex.MatchString(tag) {
+			return "", errors.Wrapf(ErrInvalidRendering, "docker tag '%s' must be at most 128 characters", tag)
+		}
+		return tag, nil
+	case FormatPEP440:
+		return renderPEP440(core, pre, meta), nil
+	case FormatDebian:
+		return joinVersion(core, "~", strings.ReplaceAll(pre, "-", "."), "+", strings.ReplaceAll(meta, "-", ".")), nil
+	case FormatRPM:
+		return joinVersion(core, "~", strings.ReplaceAll(pre, "-", "_"), "^", strings.ReplaceAll(meta, "-", "_")), nil
+	case FormatMaven:
+		return joinVersion(core, "-", pre, "", ""), nil
+	case FormatGoModule:
+		vers := "v" + joinVersion(semver.New(v.Major(), v.Minor(), v.Patch(), "", "").String(), "-", pre, "", "")
+		if !modsemver.IsValid(vers) || modsemver.Canonical(vers) != vers {
+			return "", errors.Wrapf(ErrInvalidRendering, "'%s' is not a canonical go module version", vers)
+		}
+		return vers, nil
+	default:
+		_, err := ParseFormat(string(format))
+		return "", err
+	}
+}
+
+// RenderAll renders the version in every format it can be rendered in.
+func RenderAll(v *semver.Version) map[string]string {
+	res := map[string]string{}
+	for _, f := range Formats {
+		if r, err := Render(v, f); err == nil {
+			res[string(f)] = r
+		}
+	}
+	return res
+}
+
+// ParseRendering parses a version rendered in the format back. Formats that drop information, like the
+// build metadata for maven or the prerelease channel of pep440 dev releases, parse to the version without it,
+// so rendering the result gives the same string again.
+func ParseRendering(s string, format Format) (*semver.Version, error) {
+
+	var raw string
+
+	switch format {
+	case FormatSemver, FormatNPM, FormatMaven:
+		raw = s
+	case FormatGoModule:
+		if !modsemver.IsValid(s) {
+			return nil, errors.Wrapf(ErrInvalidRendering, "'%s' is not a go module version", s)
+		}
+		raw = s
+	case FormatDocker:
+		core, meta, _ := strings.Cut(s, "_")
+		raw = joinVersion(core, "", "", "+", meta)
+	case FormatDebian:
+		rest, meta, _ := strings.Cut(s, "+")
+		core, pre, _ := strings.Cut(rest, "~")
+		raw = joinVersion(core, "-", pre, "+", meta)
+	case FormatRPM:
+		rest, meta, _ := strings.Cut(strings.ReplaceAll(s, "_", "-"), "^")
+		core, pre, _ := strings.Cut(rest, "~")
+		raw = joinVersion(core, "-", pre, "+", meta)
+	case FormatPEP440:
+		var err error
+		raw, err = parsePEP440(s)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		_, err := ParseFormat(string(format))
+		return nil, err
+	}
+
+	v, err := semver.StrictNewVersion(strings.TrimPrefix(raw, "v"))
+	if err != nil {
+		// calendar versions keep their zero padding, which strict parsing rejects
+		v, err = semver.NewVersion(raw)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidRendering, "%s version '%s': %v", format, s, err)
+		}
+	}
+
+	return v, nil
+}
+
+// coreOf returns the major.minor.patch of the version as it was written, so zero padding is kept.
+func coreOf(v *semver.Version) string {
+	core := strings.TrimPrefix(v.Original(), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	if strings.Count(core, ".") != 2 {
+		return semver.New(v.Major(), v.Minor(), v.Patch(), "", "").String()
+	}
+
+	return core
+}
+
+func joinVersion(core string, preSep string, pre string, metaSep string, meta string) string {
+	res := core
+	if pre != "" {
+		res += preSep + pre
+	}
+	if meta != "" && metaSep != "" {
+		res += metaSep + meta
+	}
+	return res
+}
+
+func renderPEP440(core string, pre string, meta string) string {
+	var res strings.Builder
+	res.WriteString(core)
+
+	local := []string{}
+
+	ids := []string{}
+	if pre != "" {
+		ids = strings.Split(pre, ".")
+	}
+
+	if len(ids) == 2 && pep440Channels[ids[0]] != "" && isNumeric(ids[1]) {
+		res.WriteString(pep440Channels[ids[0]] + ids[1])
+	} else if len(ids) > 0 {
+		// the last numeric identifier counts the dev releases, the rest is kept as the local version
+		counter := "0"
+		if isNumeric(ids[len(ids)-1]) {
+			counter = ids[len(ids)-1]
+			ids = ids[:len(ids)-1]
+		}
+		if len(ids) > 0 && ids[0] == "dev" {
+			ids = ids[1:]
+		}
+		res.WriteString(".dev" + counter)
+		local = append(local, ids...)
+	}
+
+	if meta != "" {
+		local = append(local, strings.Split(meta, ".")...)
+	}
+
+	if len(local) > 0 {
+		parts := []string{}
+		// pep440 local versions only separate with dots
+		for _, id := range local {
+			for _, part := range strings.FieldsFunc(strings.ToLower(id), func(r rune) bool { return r == '-' }) {
+				if len(part) >= 7 && hashRegex.MatchString(part) {
+					part = "g" + part
+				}
+				parts = append(parts, part)
+			}
+		}
+		res.WriteString("+" + strings.Join(parts, "."))
+	}
+
+	return res.String()
+}
+
+func parsePEP440(s string) (string, error) {
+	m := pep440Regex.FindStringSubmatch(s)
+	if m == nil || strings.Count(m[1], ".") != 2 {
+		return "", errors.Wrapf(ErrInvalidRendering, "'%s' is not a pep440 version of the form X.Y.Z[{a|b|rc}N][.devN][+local]", s)
+	}
+
+	pre := []string{}
+	if m[2] != "" {
+		for channel, short := range pep440Channels {
+			if short == m[2] {
+				pre = append(pre, channel, m[3])
+			}
+		}
+	}
+	if m[4] != "" {
+		pre = append(pre, "dev", m[4])
+	}
+
+	meta := []string{}
+	if m[5] != "" {
+		for _, id := range strings.Split(m[5], ".") {
+			if strings.HasPrefix(id, "g") && len(id) >= 8 && hashRegex.MatchString(id[1:]) {
+				id = id[1:]
+			}
+			meta = append(meta, id)
+		}
+	}
+
+	return joinVersion(m[1], "-", strings.Join(pre, "."), "+", strings.Join(meta, ".")), nil
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
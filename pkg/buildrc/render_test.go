@@ -0,0 +1,250 @@
+package buildrc_test
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/buildrc"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range buildrc.Formats {
+		got, err := buildrc.ParseFormat(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, got)
+	}
+
+	_, err := buildrc.ParseFormat("wheel")
+	assert.ErrorIs(t, err, buildrc.ErrUnknownFormat)
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected map[buildrc.Format]string
+	}{
+		{
+			version: "1.2.3",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatSemver:   "1.2.3",
+				buildrc.FormatDocker:   "1.2.3",
+				buildrc.FormatPEP440:   "1.2.3",
+				buildrc.FormatDebian:   "1.2.3",
+				buildrc.FormatRPM:      "1.2.3",
+				buildrc.FormatNPM:      "1.2.3",
+				buildrc.FormatMaven:    "1.2.3",
+				buildrc.FormatGoModule: "v1.2.3",
+			},
+		},
+		{
+			version: "1.2.0-rc.1",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatSemver:   "1.2.0-rc.1",
+				buildrc.FormatDocker:   "1.2.0-rc.1",
+				buildrc.FormatPEP440:   "1.2.0rc1",
+				buildrc.FormatDebian:   "1.2.0~rc.1",
+				buildrc.FormatRPM:      "1.2.0~rc.1",
+				buildrc.FormatNPM:      "1.2.0-rc.1",
+				buildrc.FormatMaven:    "1.2.0-rc.1",
+				buildrc.FormatGoModule: "v1.2.0-rc.1",
+			},
+		},
+		{
+			version: "v1.2.0-local.5+abcdef0.dirty.0123456789ab",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatSemver:   "1.2.0-local.5+abcdef0.dirty.0123456789ab",
+				buildrc.FormatDocker:   "1.2.0-local.5_abcdef0.dirty.0123456789ab",
+				buildrc.FormatPEP440:   "1.2.0.dev5+local.gabcdef0.dirty.g0123456789ab",
+				buildrc.FormatDebian:   "1.2.0~local.5+abcdef0.dirty.0123456789ab",
+				buildrc.FormatRPM:      "1.2.0~local.5^abcdef0.dirty.0123456789ab",
+				buildrc.FormatNPM:      "1.2.0-local.5+abcdef0.dirty.0123456789ab",
+				buildrc.FormatMaven:    "1.2.0-local.5",
+				buildrc.FormatGoModule: "v1.2.0-local.5",
+			},
+		},
+		{
+			version: "2.0.0-pr.12.3+1234567",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatDocker: "2.0.0-pr.12.3_1234567",
+				buildrc.FormatPEP440: "2.0.0.dev3+pr.12.1234567",
+				buildrc.FormatRPM:    "2.0.0~pr.12.3^1234567",
+			},
+		},
+		{
+			version: "1.0.0-nightly-2.4",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatPEP440: "1.0.0.dev4+nightly.2",
+				buildrc.FormatDebian: "1.0.0~nightly.2.4",
+				buildrc.FormatRPM:    "1.0.0~nightly_2.4",
+			},
+		},
+		{
+			version: "2026.01.3-beta.2",
+			expected: map[buildrc.Format]string{
+				buildrc.FormatSemver:   "2026.01.3-beta.2",
+				buildrc.FormatPEP440:   "2026.01.3b2",
+				buildrc.FormatDebian:   "2026.01.3~beta.2",
+				buildrc.FormatGoModule: "v2026.1.3-beta.2",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		v, err := semver.NewVersion(test.version)
+		require.NoError(t, err)
+		for format, expected := range test.expected {
+			t.Run(test.version+"/"+string(format), func(t *testing.T) {
+				got, err := buildrc.Render(v, format)
+				require.NoError(t, err)
+				assert.Equal(t, expected, got)
+			})
+		}
+	}
+}
+
+func TestRenderDockerTooLong(t *testing.T) {
+	v := semver.New(1, 2, 3, "local.1", fmt.Sprintf("%0130d", 1))
+
+	_, err := buildrc.Render(v, buildrc.FormatDocker)
+	assert.ErrorIs(t, err, buildrc.ErrInvalidRendering)
+
+	assert.NotContains(t, buildrc.RenderAll(v), string(buildrc.FormatDocker))
+	assert.Contains(t, buildrc.RenderAll(v), string(buildrc.FormatSemver))
+}
+
+func TestParseRenderingInvalid(t *testing.T) {
+	tests := []struct {
+		rendering string
+		format    buildrc.Format
+	}{
+		{rendering: "1.2", format: buildrc.FormatPEP440},
+		{rendering: "1.2.3.post1", format: buildrc.FormatPEP440},
+		{rendering: "1.2.3", format: buildrc.FormatGoModule},
+		{rendering: "one.two.three", format: buildrc.FormatSemver},
+		{rendering: "1.2.3", format: "wheel"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format)+"/"+test.rendering, func(t *testing.T) {
+			_, err := buildrc.ParseRendering(test.rendering, test.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// validRendering matches what each ecosystem accepts as a version.
+var validRendering = map[buildrc.Format]*regexp.Regexp{
+	buildrc.FormatSemver:   regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`),
+	buildrc.FormatNPM:      regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`),
+	buildrc.FormatDocker:   regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`),
+	buildrc.FormatPEP440:   regexp.MustCompile(`^[0-9]+(\.[0-9]+)*((a|b|rc)[0-9]+)?(\.dev[0-9]+)?(\+[a-z0-9]+(\.[a-z0-9]+)*)?$`),
+	buildrc.FormatDebian:   regexp.MustCompile(`^[0-9][A-Za-z0-9.+~]*$`),
+	buildrc.FormatRPM:      regexp.MustCompile(`^[0-9][A-Za-z0-9._+~^]*$`),
+	buildrc.FormatMaven:    regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`),
+	buildrc.FormatGoModule: regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`),
+}
+
+// lossless are the formats that keep every part of the version.
+var lossless = map[buildrc.Format]bool{
+	buildrc.FormatSemver: true,
+	buildrc.FormatNPM:    true,
+	buildrc.FormatDocker: true,
+	buildrc.FormatRPM:    true,
+}
+
+func randomVersion(r *rand.Rand) *semver.Version {
+	core := fmt.Sprintf("%d.%d.%d", r.Intn(30), r.Intn(30), r.Intn(30))
+	if r.Intn(4) == 0 {
+		core = fmt.Sprintf("%d.%02d.%d", 2020+r.Intn(10), 1+r.Intn(12), r.Intn(10))
+	}
+
+	pre := ""
+	switch r.Intn(7) {
+	case 1:
+		pre = fmt.Sprintf("alpha.%d", 1+r.Intn(20))
+	case 2:
+		pre = fmt.Sprintf("beta.%d", 1+r.Intn(20))
+	case 3:
+		pre = fmt.Sprintf("rc.%d", 1+r.Intn(20))
+	case 4:
+		pre = fmt.Sprintf("nightly-%d.%d", r.Intn(3), 1+r.Intn(20))
+	case 5:
+		pre = fmt.Sprintf("pr.%d.%d", 1+r.Intn(500), r.Intn(50))
+	case 6:
+		pre = fmt.Sprintf("local.%d", r.Intn(50))
+	}
+
+	meta := ""
+	if r.Intn(2) == 0 {
+		meta = fmt.Sprintf("%07x", r.Uint32()&0xfffffff)
+		if r.Intn(2) == 0 {
+			meta += fmt.Sprintf(".dirty.%012x", r.Uint64()&0xffffffffffff)
+		}
+	}
+
+	raw := core
+	if pre != "" {
+		raw += "-" + pre
+	}
+	if meta != "" {
+		raw += "+" + meta
+	}
+
+	return semver.MustParse(raw)
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		v := randomVersion(r)
+
+		for _, format := range buildrc.Formats {
+			rendered, err := buildrc.Render(v, format)
+			require.NoError(t, err, "%s %s", format, v.Original())
+
+			assert.Regexp(t, validRendering[format], rendered, "%s %s", format, v.Original())
+
+			parsed, err := buildrc.ParseRendering(rendered, format)
+			require.NoError(t, err, "%s %s from %s", format, rendered, v.Original())
+
+			again, err := buildrc.Render(parsed, format)
+			require.NoError(t, err, "%s %s", format, parsed.Original())
+			assert.Equal(t, rendered, again, "%s %s", format, v.Original())
+
+			if lossless[format] {
+				assert.True(t, v.Equal(parsed), "%s %s parsed to %s", format, v.Original(), parsed.Original())
+				assert.Equal(t, v.Metadata(), parsed.Metadata(), "%s %s", format, v.Original())
+			}
+		}
+	}
+}
+
+func TestRenderKeepsOrder(t *testing.T) {
+	// versions in ascending order, the renderings of releases and their alpha, beta and rc
+	// prereleases must parse back in the same order
+	ordered := []string{"1.2.0-alpha.1", "1.2.0-alpha.2", "1.2.0-beta.1", "1.2.0-rc.1", "1.2.0-rc.2", "1.2.0", "1.2.1-rc.1", "1.2.1"}
+
+	for i := 1; i < len(ordered); i++ {
+		lo, hi := semver.MustParse(ordered[i-1]), semver.MustParse(ordered[i])
+
+		for _, format := range []buildrc.Format{buildrc.FormatPEP440, buildrc.FormatDebian, buildrc.FormatGoModule} {
+			a, err := buildrc.Render(lo, format)
+			require.NoError(t, err)
+			b, err := buildrc.Render(hi, format)
+			require.NoError(t, err)
+
+			pa, err := buildrc.ParseRendering(a, format)
+			require.NoError(t, err)
+			pb, err := buildrc.ParseRendering(b, format)
+			require.NoError(t, err)
+
+			assert.True(t, pa.LessThan(pb), "%s: %s < %s", format, a, b)
+		}
+	}
+}
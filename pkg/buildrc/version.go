@@ -3,13 +3,13 @@ package buildrc
 import (
 	"context"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-faster/errors"
 
 	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/ci"
 	"github.com/walteh/buildrc/pkg/git"
 )
 
@@ -21,19 +21,84 @@ const (
 	CommitTypeRelease CommitType = "release"
 )
 
+// ErrNotReleasable is returned for a pull request or local version where a release was asked for.
+var ErrNotReleasable = errors.New("buildrc.ErrNotReleasable")
+
 type GetVersionOpts struct {
-	Type                  CommitType `json:"type"`
-	PatchIndicator        string     `json:"patch-indicator"`
-	PRNumber              uint64     `json:"pr-number"`
-	CommitMessageOverride string     `json:"commit-message-override"`
-	LatestTagOverride     string     `json:"latest-tag-override"`
-	Patch                 bool       `json:"patch"`
-	Auto                  bool       `json:"auto"`
-	ExcludeV              bool       `json:"exclude-v"`
+	Type                  CommitType   `json:"type"`
+	BumpStrategy          BumpStrategy `json:"bump-strategy"`
+	PatchIndicator        string       `json:"patch-indicator"`
+	PRNumber              uint64       `json:"pr-number"`
+	CommitMessageOverride string       `json:"commit-message-override"`
+	LatestTagOverride     string       `json:"latest-tag-override"`
+	Patch                 bool         `json:"patch"`
+	Auto                  bool         `json:"auto"`
+	ExcludeV              bool         `json:"exclude-v"`
+	// Channel cuts a prerelease like 1.3.0-rc.2 instead of a release, defaulting to the channel in .buildrc
+	Channel string `json:"channel"`
+	// GoPseudoVersion returns the version the go command resolves HEAD to instead, it always has the v prefix
+	GoPseudoVersion bool `json:"go-pseudo-version"`
+	// PRAware compares HEAD against the main branch and its pull requests, listed from PullRequests,
+	// instead of the type. PRAwareStrategy is set to how HEAD relates to them.
+	PRAware         bool                    `json:"pr-aware"`
+	PullRequests    git.PullRequestProvider `json:"-"`
+	PRAwareStrategy git.TagStragegy         `json:"-"`
+
+	// CI is the detected CI environment, consulted first in auto mode
+	CI *ci.Environment `json:"-"`
+	// Explain records how the version was calculated when set
+	Explain *Explanation `json:"-"`
+	// Now is the time calendar versions are calculated for, defaulting to SOURCE_DATE_EPOCH or the current time
+	Now time.Time `json:"-"`
 }
 
 func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts) (string, error) {
 
+	vers, err := getVersion(ctx, gitp, brc, me)
+	if err != nil {
+		return "", err
+	}
+
+	if me != nil && me.Explain != nil {
+		me.Explain.Type = me.Type
+		me.Explain.Version = vers
+		if me.Explain.Commits == nil {
+			me.Explain.Commits = []*ExplanationCommit{}
+		}
+	}
+
+	return vers, nil
+}
+
+// GetReleaseVersion returns the version of HEAD detected like in auto mode, refusing anything but a release
+// or a prerelease on a channel, so pull request and local versions are never tagged or released.
+func GetReleaseVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, env *ci.Environment, channel string) (string, error) {
+
+	opts := &GetVersionOpts{Auto: true, PatchIndicator: "patch", CI: env, Channel: channel}
+
+	vers, err := GetVersion(ctx, gitp, brc, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Type != CommitTypeRelease {
+		return "", errors.Wrapf(ErrNotReleasable, "%s is a %s version, pass --version to release it anyway", vers, opts.Type)
+	}
+
+	parsed, err := semver.NewVersion(vers)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Metadata() != "" || (parsed.Prerelease() != "" && channelOf(parsed) == "") {
+		return "", errors.Wrapf(ErrNotReleasable, "%s is neither a release nor a prerelease on a channel", vers)
+	}
+
+	return vers, nil
+}
+
+func getVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts) (string, error) {
+
 	zerolog.Ctx(ctx).Debug().Any("buildrc", brc).Msg("loading buildrc file")
 
 	if me == nil {
@@ -50,6 +115,32 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 		me.CommitMessageOverride = "patch"
 	}
 
+	exp := me.Explain
+	if exp != nil {
+		exp.Scheme = brc.Scheme()
+		exp.floor(brc.Major(), false)
+	}
+
+	if me.PRAware {
+		return getPRAwareVersion(ctx, gitp, brc, me, prefix)
+	}
+
+	if me.GoPseudoVersion {
+		exp.base(nil, "the go pseudo-version of HEAD is built on the latest module tag")
+		return GetGoPseudoVersion(ctx, gitp)
+	}
+
+	channel := me.Channel
+	if channel == "" {
+		channel = brc.Channel()
+	}
+
+	if channel != "" {
+		if err := ValidateChannel(channel); err != nil {
+			return "", err
+		}
+	}
+
 	if me.Type == CommitTypePR {
 		if me.PRNumber == 0 {
 			return "", errors.Errorf("'--pr-number=#' is required for type %s", me.Type)
@@ -57,8 +148,20 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 	}
 
 	if me.Auto {
+		env := me.CI
+		if env == nil {
+			env = &ci.Environment{}
+		}
+
 		me.Type = CommitTypeRelease
-		if gitp.Dirty(ctx) {
+		if env.PRNumber > 0 {
+			zerolog.Ctx(ctx).Debug().Uint64("pr", env.PRNumber).Str("provider", string(env.Provider)).Msg("pull request detected from ci environment")
+			exp.auto("the %s ci environment reports pull request #%d", env.Provider, env.PRNumber)
+			me.Type = CommitTypePR
+			me.PRNumber = env.PRNumber
+		} else if gitp.Dirty(ctx) {
+			// a dirty tree is never released, not even in ci, where a build step may have changed it
+			exp.auto("the working tree is dirty")
 			me.Type = CommitTypeLocal
 		} else {
 			svt, err := gitp.TryGetSemverTag(ctx)
@@ -66,7 +169,23 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 				return "", err
 			}
 
+			// a prerelease on another channel is promoted, reusing its core version
+			if svt != nil && svt.Prerelease() != "" && channelOf(svt) != channel {
+				zerolog.Ctx(ctx).Debug().Str("tag", svt.Original()).Str("channel", channel).Msg("promoting prerelease tag of HEAD")
+				exp.auto("HEAD is tagged %s on channel %q, which is promoted for channel %q", svt.Original(), channelOf(svt), channel)
+				svt = nil
+			}
+
 			if svt != nil {
+				exp.auto("HEAD is tagged %s", svt.Original())
+				exp.base(svt, "tag of HEAD")
+				if brc.Scheme() == SchemeCalver {
+					layout, err := brc.CalverLayout()
+					if err != nil {
+						return "", err
+					}
+					return prefix + layout.Format(svt), nil
+				}
 				return prefix + svt.String(), nil
 			}
 
@@ -77,57 +196,48 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 
 			me.PRNumber = n
 			if me.PRNumber > 0 {
+				exp.auto("the refs of HEAD name pull request #%d", n)
 				me.Type = CommitTypePR
+			} else {
+				exp.auto("HEAD is not tagged and no pull request names it, so it is a release")
 			}
 		}
 	}
 
+	if brc.Scheme() == SchemeCalver {
+		return getCalverVersion(ctx, gitp, brc, me, prefix, channel)
+	}
+
 	switch me.Type {
 	case CommitTypeRelease:
 		{
 
-			var latestHead *semver.Version
-			var message string
-			var err error
-
-			if me.LatestTagOverride != "" {
-				latestHead, err = semver.NewVersion(me.LatestTagOverride)
-				if err != nil {
-					return "", err
-				}
-			} else {
-				latestHead, err = gitp.GetLatestSemverTagFromRef(ctx, "HEAD")
-				if err != nil {
-					return "", err
-				}
+			line, err := CurrentMaintenanceLine(ctx, gitp, brc, me.CI, me.Type)
+			if err != nil {
+				return "", err
 			}
 
-			if me.CommitMessageOverride != "" {
-				message = me.CommitMessageOverride
+			exp.line(line)
+
+			var work semver.Version
+			if line != nil {
+				work, err = getMaintenanceReleaseVersion(ctx, gitp, brc, me, line)
 			} else {
-				message, err = gitp.GetCurrentCommitMessageFromRef(ctx, "HEAD")
-				if err != nil {
-					return "", err
-				}
+				work, err = getReleaseVersion(ctx, gitp, brc, me)
+			}
+			if err != nil {
+				return "", err
 			}
 
-			patch := strings.Contains(message, me.PatchIndicator)
-
-			if latestHead.Major() < brc.Major() {
-				latestHead, err = semver.NewVersion(strconv.FormatUint(brc.Major(), 10) + ".0.0")
+			if channel != "" {
+				if exp != nil {
+					exp.Channel = channel
+				}
+				next, err := NextPrerelease(ctx, gitp, &work, channel)
 				if err != nil {
 					return "", err
 				}
-				return prefix + latestHead.String(), nil
-			}
-
-			// we do not care about the prerelease or metadata and this safely removes it
-			work := *semver.New(latestHead.Major(), latestHead.Minor(), latestHead.Patch(), "", "")
-
-			if patch {
-				work = work.IncPatch()
-			} else {
-				work = work.IncMinor()
+				return prefix + next.String(), nil
 			}
 
 			return prefix + work.String(), nil
@@ -135,22 +245,38 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 		}
 	case CommitTypeLocal:
 		{
-			work := *semver.New(0, 0, 0, "local", time.Now().Format("2006.01.02.15.04.05"))
+			work, err := getLocalVersion(ctx, gitp, brc, me)
+			if err != nil {
+				return "", err
+			}
 			return prefix + work.String(), nil
 		}
 	case CommitTypePR:
 		{
 
-			latestHead, err := gitp.GetLatestSemverTagFromRef(ctx, "HEAD")
+			line, err := CurrentMaintenanceLine(ctx, gitp, brc, me.CI, me.Type)
 			if err != nil {
 				return "", err
 			}
 
-			if latestHead.Major() < brc.Major() {
+			exp.line(line)
+
+			base, bootstrapped, err := latestTagOrBootstrap(ctx, gitp, brc, exp)
+			if err != nil {
+				return "", err
+			}
+
+			if line != nil && !line.Contains(base) {
+				return "", errors.Wrapf(ErrOutsideMaintenanceLine, "latest tag %s is not on %s of branch %s", base.Original(), line.String(), line.Branch)
+			}
+
+			latestHead := base
+			if line == nil && latestHead.Major() < brc.Major() {
 				latestHead, err = semver.NewVersion(strconv.FormatUint(brc.Major(), 10) + ".0.0")
 				if err != nil {
 					return "", err
 				}
+				exp.floor(brc.Major(), true)
 			}
 
 			revision, err := gitp.GetCurrentShortHashFromRef(ctx, "HEAD")
@@ -158,9 +284,22 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 				return "", err
 			}
 
+			// without a tag every commit is counted
+			from := ""
+			if !bootstrapped {
+				from = "refs/tags/" + base.Original()
+			}
+
+			commits, err := gitp.ListCommitsBetweenRefs(ctx, from, "HEAD")
+			if err != nil {
+				return "", err
+			}
+
+			exp.commits(commits)
+
 			work := *latestHead
 
-			work, err = work.SetPrerelease("pr." + strconv.FormatUint(me.PRNumber, 10))
+			work, err = work.SetPrerelease(prPrerelease(me.PRNumber, len(commits)))
 			if err != nil {
 				return "", err
 			}
@@ -176,3 +315,193 @@ func GetVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *Get
 
 	return "", errors.Errorf("unknown type %s", me.Type)
 }
+
+// getReleaseVersion bumps the latest tag reachable from HEAD by the highest bump of the commits since it.
+func getReleaseVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts) (semver.Version, error) {
+
+	var latestHead *semver.Version
+	var commits []*git.Commit
+	var err error
+
+	if me.LatestTagOverride != "" {
+		latestHead, err = semver.NewVersion(me.LatestTagOverride)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		me.Explain.base(latestHead, "--latest-tag-override")
+	} else {
+		var bootstrapped bool
+		latestHead, bootstrapped, err = latestTagOrBootstrap(ctx, gitp, brc, me.Explain)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		// the bootstrap version is the first release, there is nothing to bump it past
+		if bootstrapped {
+			return *latestHead, nil
+		}
+	}
+
+	if me.CommitMessageOverride != "" {
+		commits = []*git.Commit{{Message: me.CommitMessageOverride}}
+	} else if me.LatestTagOverride != "" {
+		// the override is not necessarily a tag in this repository, so there is no range to walk
+		message, err := gitp.GetCurrentCommitMessageFromRef(ctx, "HEAD")
+		if err != nil {
+			return semver.Version{}, err
+		}
+		commits = []*git.Commit{{Message: message}}
+	} else {
+		commits, err = getCommitsSinceTag(ctx, gitp, latestHead)
+		if err != nil {
+			return semver.Version{}, err
+		}
+	}
+
+	var bump Bump
+	for _, c := range commits {
+		b, rule, err := bumpFromMessage(ctx, brc, me.BumpStrategy, me.PatchIndicator, c.Message)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		me.Explain.commit(c, b, rule)
+		if b > bump {
+			bump = b
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Int("commits", len(commits)).Str("bump", bump.String()).Msg("calculated release bump")
+
+	me.Explain.bump(bump)
+
+	if latestHead.Major() < brc.Major() {
+		me.Explain.floor(brc.Major(), true)
+		return *semver.New(brc.Major(), 0, 0, "", ""), nil
+	}
+
+	// we do not care about the prerelease or metadata and this safely removes it
+	return bump.Apply(latestHead), nil
+}
+
+// getCalverVersion calculates the next calendar version. Pull request and local versions are the next
+// release version with the same prerelease and metadata the semver scheme uses.
+func getCalverVersion(ctx context.Context, gitp git.GitProvider, brc *Buildrc, me *GetVersionOpts, prefix string, channel string) (string, error) {
+
+	layout, err := brc.CalverLayout()
+	if err != nil {
+		return "", err
+	}
+
+	now := me.Now
+	if now.IsZero() {
+		now, err = git.Now()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var latest *semver.Version
+
+	if me.LatestTagOverride != "" {
+		latest, err = semver.NewVersion(me.LatestTagOverride)
+		if err != nil {
+			return "", err
+		}
+		me.Explain.base(latest, "--latest-tag-override")
+	} else if latest, err = gitp.GetLatestSemverTagFromRef(ctx, "HEAD"); err != nil {
+		if !errors.Is(err, git.ErrNoSemverTags) {
+			return "", err
+		}
+		// the first version of a period does not need a previous tag
+		zerolog.Ctx(ctx).Debug().Err(err).Msg("no previous calendar version, starting at MICRO 0")
+		latest = nil
+		me.Explain.base(nil, "no tag reachable from HEAD, starting at MICRO 0")
+	} else {
+		me.Explain.base(latest, "latest tag reachable from HEAD")
+	}
+
+	work := *layout.Next(latest, now.UTC())
+
+	zerolog.Ctx(ctx).Debug().Str("layout", layout.String()).Str("next", layout.Format(&work)).Msg("calculated calendar version")
+
+	// commits are counted from the previous tag, or every commit without one
+	from := ""
+	if latest != nil && me.LatestTagOverride == "" {
+		from = "refs/tags/" + latest.Original()
+	}
+
+	switch me.Type {
+	case CommitTypeRelease:
+		if channel != "" {
+			if me.Explain != nil {
+				me.Explain.Channel = channel
+			}
+			next, err := NextPrerelease(ctx, gitp, &work, channel)
+			if err != nil {
+				return "", err
+			}
+			work = *next
+		}
+	case CommitTypeLocal:
+		work, err = describeLocal(ctx, gitp, work, from, me.Explain)
+		if err != nil {
+			return "", err
+		}
+	case CommitTypePR:
+		revision, err := gitp.GetCurrentShortHashFromRef(ctx, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		commits, err := gitp.ListCommitsBetweenRefs(ctx, from, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		me.Explain.commits(commits)
+		work, err = work.SetPrerelease(prPrerelease(me.PRNumber, len(commits)))
+		if err != nil {
+			return "", err
+		}
+		work, err = work.SetMetadata(revision)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.Errorf("unknown type %s", me.Type)
+	}
+
+	return prefix + layout.Format(&work), nil
+}
+
+// prPrerelease returns the prerelease of a pull request version, pr.<number>.<commits since the base tag>,
+// so versions of later pushes to the same pull request sort higher.
+func prPrerelease(number uint64, commits int) string {
+	return "pr." + strconv.FormatUint(number, 10) + "." + strconv.Itoa(commits)
+}
+
+// getCommitsSinceTag returns every commit between the tag and HEAD.
+// If HEAD is the tagged commit, the HEAD commit message is returned on its own.
+func getCommitsSinceTag(ctx context.Context, gitp git.GitProvider, tag *semver.Version) ([]*git.Commit, error) {
+
+	from := "refs/tags/" + tag.Original()
+
+	commits, err := gitp.ListCommitsBetweenRefs(ctx, from, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(commits) == 0 {
+		message, err := gitp.GetCurrentCommitMessageFromRef(ctx, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		return []*git.Commit{{Message: message}}, nil
+	}
+
+	hashes := make([]string, 0, len(commits))
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("range", from+"..HEAD").Strs("commits", hashes).Msg("using commit range for release bump")
+
+	return commits, nil
+}
@@ -0,0 +1,261 @@
+package buildrc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestGetVersionAggregatesCommitsSinceTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    buildrc.BumpStrategy
+		commits     []*git.Commit
+		headMessage string
+		expected    string
+	}{
+		{
+			name:     "highest bump wins",
+			strategy: buildrc.BumpStrategyConventional,
+			commits: []*git.Commit{
+				{Hash: "c3", Message: "fix: last"},
+				{Hash: "c2", Message: "feat: middle"},
+				{Hash: "c1", Message: "chore: first"},
+			},
+			expected: "v1.3.0",
+		},
+		{
+			name:     "breaking change anywhere in the range",
+			strategy: buildrc.BumpStrategyConventional,
+			commits: []*git.Commit{
+				{Hash: "c2", Message: "fix: last"},
+				{Hash: "c1", Message: "feat!: first"},
+			},
+			expected: "v2.0.0",
+		},
+		{
+			name:     "all patches",
+			strategy: buildrc.BumpStrategyConventional,
+			commits: []*git.Commit{
+				{Hash: "c2", Message: "fix: last"},
+				{Hash: "c1", Message: "fix: first"},
+			},
+			expected: "v1.2.4",
+		},
+		{
+			name:     "legacy strategy looks at every commit",
+			strategy: buildrc.BumpStrategyPatchIndicator,
+			commits: []*git.Commit{
+				{Hash: "c2", Message: "patch: last"},
+				{Hash: "c1", Message: "something new"},
+			},
+			expected: "v1.3.0",
+		},
+		{
+			name:        "head is the tag",
+			strategy:    buildrc.BumpStrategyConventional,
+			commits:     []*git.Commit{},
+			headMessage: "fix: tagged",
+			expected:    "v1.2.4",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+
+			mockGitProvider.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+			mockGitProvider.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return(test.commits, nil)
+			if len(test.commits) == 0 {
+				mockGitProvider.EXPECT().GetCurrentCommitMessageFromRef(mock.Anything, "HEAD").Return(test.headMessage, nil)
+			}
+
+			vers, err := buildrc.GetVersion(ctx, mockGitProvider, &buildrc.Buildrc{}, &buildrc.GetVersionOpts{
+				Type:           buildrc.CommitTypeRelease,
+				BumpStrategy:   test.strategy,
+				PatchIndicator: "patch",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+func TestGetVersionAutoConsultsCIEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      *ci.Environment
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+	}{
+		{
+			name: "pull request from ci",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitHubActions, PRNumber: 42},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}, {Hash: "1234567"}}, nil)
+			},
+			expected: "v1.2.3-pr.42.2+abcdef0",
+		},
+		{
+			name: "local when dirty in ci",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitLab},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(true)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v2.0.0"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v2.0.0", "HEAD").Return([]*git.Commit{{Message: "patch: one"}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListDirtyFiles(mock.Anything).Return([]string{"main.go"}, nil)
+				m.EXPECT().Fs().Return(dirtyFs(t))
+			},
+			expected: "v2.0.1-local.1+abcdef0.dirty.7f65a0f996de",
+		},
+		{
+			name: "clean tree in ci is a release",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitLab},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(semver.MustParse("v2.0.0"), nil)
+			},
+			expected: "v2.0.0",
+		},
+		{
+			name: "local when not in ci and dirty",
+			env:  &ci.Environment{},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(true)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Message: "patch: one"}, {Message: "patch: two"}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListDirtyFiles(mock.Anything).Return([]string{"main.go"}, nil)
+				m.EXPECT().Fs().Return(dirtyFs(t))
+			},
+			expected: "v1.2.4-local.2+abcdef0.dirty.7f65a0f996de",
+		},
+		{
+			name: "refs are used when ci has no pull request",
+			env:  nil,
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(7, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.0.0"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.0.0", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}}, nil)
+			},
+			expected: "v1.0.0-pr.7.1+abcdef0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			vers, err := buildrc.GetVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{}, &buildrc.GetVersionOpts{
+				Auto:           true,
+				PatchIndicator: "patch",
+				CI:             test.env,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
+
+// dirtyFs holds the changed file of a dirty working tree, its digest is 7f65a0f996de.
+func dirtyFs(t *testing.T) afero.Fs {
+	t.Helper()
+
+	fls := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fls, "main.go", []byte("package main\n"), 0600))
+
+	return fls
+}
+
+func TestGetReleaseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      *ci.Environment
+		channel  string
+		setup    func(m *mockery.MockGitProvider_git)
+		expected string
+		err      error
+	}{
+		{
+			name: "pull request in ci",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitHubActions, PRNumber: 42},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Hash: "abcdef0"}}, nil)
+			},
+			err: buildrc.ErrNotReleasable,
+		},
+		{
+			name: "dirty tree",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitLab},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(true)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Message: "patch: one"}}, nil)
+				m.EXPECT().GetCurrentShortHashFromRef(mock.Anything, "HEAD").Return("abcdef0", nil)
+				m.EXPECT().ListDirtyFiles(mock.Anything).Return([]string{}, nil)
+			},
+			err: buildrc.ErrNotReleasable,
+		},
+		{
+			name: "release",
+			env:  &ci.Environment{CI: true, Provider: ci.ProviderGitLab},
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Message: "patch: one"}}, nil)
+			},
+			expected: "v1.2.4",
+		},
+		{
+			name:    "prerelease on a channel",
+			env:     &ci.Environment{CI: true, Provider: ci.ProviderGitLab},
+			channel: "rc",
+			setup: func(m *mockery.MockGitProvider_git) {
+				m.EXPECT().Dirty(mock.Anything).Return(false)
+				m.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+				m.EXPECT().TryGetPRNumber(mock.Anything).Return(0, nil)
+				m.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v1.2.3"), nil)
+				m.EXPECT().ListCommitsBetweenRefs(mock.Anything, "refs/tags/v1.2.3", "HEAD").Return([]*git.Commit{{Message: "patch: one"}}, nil)
+				m.EXPECT().ListTags(mock.Anything).Return([]*git.Tag{{Name: "v1.2.3"}}, nil)
+			},
+			expected: "v1.2.4-rc.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockGitProvider := mockery.NewMockGitProvider_git(t)
+			test.setup(mockGitProvider)
+
+			vers, err := buildrc.GetReleaseVersion(context.TODO(), mockGitProvider, &buildrc.Buildrc{}, test.env, test.channel)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vers)
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package changelog
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+type GroupBy string
+
+const (
+	GroupByType        GroupBy = "type"
+	GroupByPullRequest GroupBy = "pull-request"
+)
+
+const Unreleased = "Unreleased"
+
+var ErrUnknownGroupBy = errors.New("changelog.ErrUnknownGroupBy")
+
+// Entry is a single commit in the changelog. Type is empty for commits that are not conventional commits.
+type Entry struct {
+	Hash         string `json:"hash"`
+	Type         string `json:"type"`
+	Scope        string `json:"scope,omitempty"`
+	Description  string `json:"description"`
+	Breaking     bool   `json:"breaking"`
+	BreakingNote string `json:"breaking-note,omitempty"`
+	PullRequest  int    `json:"pull-request,omitempty"`
+	Author       string `json:"author"`
+}
+
+func (me *Entry) ShortHash() string {
+	if len(me.Hash) > 7 {
+		return me.Hash[:7]
+	}
+	return me.Hash
+}
+
+type Section struct {
+	Title   string   `json:"title"`
+	Entries []*Entry `json:"entries"`
+}
+
+type Changelog struct {
+	Version  string     `json:"version"`
+	Date     time.Time  `json:"date"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Breaking []*Entry   `json:"breaking"`
+	Sections []*Section `json:"sections"`
+	// Entries are all entries, newest first, used by formats with their own grouping
+	Entries []*Entry `json:"-"`
+}
+
+type Options struct {
+	// From is the tag to start after, defaulting to the latest semver tag before To
+	From string
+	// To is the ref to end at, defaulting to HEAD
+	To      string
+	GroupBy GroupBy
+	// Version is the title of the changelog, defaulting to the semver tag of To or Unreleased
+	Version    string
+	MainBranch string
+}
+
+// typeTitles are the section titles of the well known conventional commit types, in the order they are listed.
+var typeTitles = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+}
+
+var (
+	// squash merges are titled "description (#123)" by GitHub and Gitea
+	squashMergeRegex = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+	mergeCommitRegex = regexp.MustCompile(`^Merge pull request #(\d+)`)
+)
+
+// Generate builds the changelog of the commits after From up to To. Merge commits are not listed,
+// they only attribute the commits they merged to their pull request.
+func Generate(ctx context.Context, gitp git.GitProvider, prp git.PullRequestProvider, opts *Options) (*Changelog, error) {
+
+	to := opts.To
+	if to == "" {
+		to = "HEAD"
+	}
+
+	mainBranch := opts.MainBranch
+	if mainBranch == "" {
+		mainBranch = git.DefaultMainBranch
+	}
+
+	groupBy := opts.GroupBy
+	if groupBy == "" {
+		groupBy = GroupByType
+	}
+
+	if groupBy != GroupByType && groupBy != GroupByPullRequest {
+		return nil, errors.Wrapf(ErrUnknownGroupBy, "'%s', must be one of [%s, %s]", groupBy, GroupByType, GroupByPullRequest)
+	}
+
+	toCommit, err := gitp.GetCurrentCommitFromRef(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	from := opts.From
+	version := opts.Version
+
+	if from == "" || version == "" {
+		current, previous, err := surroundingTags(ctx, gitp, to, toCommit)
+		if err != nil {
+			return nil, err
+		}
+		if from == "" {
+			from = previous
+		}
+		if version == "" {
+			version = current
+		}
+	}
+
+	commits, err := gitp.ListCommitsBetweenRefs(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// a pull request merged in the range was last updated after the start of it, so older ones are not listed
+	since := time.Time{}
+	if from != "" {
+		start, err := gitp.GetCommitFromRef(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		since = start.CommitDate
+	}
+
+	prs, err := prp.ListPullRequestsUpdatedSince(ctx, mainBranch, since)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := attributePullRequests(commits, prs)
+
+	now, err := git.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &Changelog{
+		Version:  version,
+		Date:     now.UTC(),
+		From:     from,
+		To:       to,
+		Breaking: []*Entry{},
+		Sections: []*Section{},
+		Entries:  []*Entry{},
+	}
+
+	if len(commits) > 0 {
+		cl.Date = commits[0].Date.UTC()
+	}
+
+	for _, c := range commits {
+		if len(c.Parents) > 1 {
+			continue
+		}
+
+		e := newEntry(c)
+		e.PullRequest = owners[c.Hash]
+
+		cl.Entries = append(cl.Entries, e)
+		if e.Breaking {
+			cl.Breaking = append(cl.Breaking, e)
+		}
+	}
+
+	if groupBy == GroupByPullRequest {
+		cl.Sections = groupByPullRequest(cl.Entries)
+	} else {
+		cl.Sections = groupByType(cl.Entries)
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("from", from).Str("to", to).Int("entries", len(cl.Entries)).Msg("generated changelog")
+
+	return cl, nil
+}
+
+// surroundingTags returns the semver tag of the commit, or Unreleased, and the latest semver tag before it,
+// or an empty string if there is none.
+func surroundingTags(ctx context.Context, gitp git.GitProvider, ref string, commit string) (string, string, error) {
+
+	latest, err := gitp.GetLatestSemverTagFromRef(ctx, ref)
+	if err != nil {
+		if errors.Is(err, git.ErrNoSemverTags) {
+			zerolog.Ctx(ctx).Debug().Err(err).Msg("no semver tag to start the changelog from, using all history")
+			return Unreleased, "", nil
+		}
+		return "", "", err
+	}
+
+	tagged, err := gitp.GetCurrentCommitFromRef(ctx, latest.Original())
+	if err != nil {
+		return "", "", err
+	}
+
+	if tagged != commit {
+		return Unreleased, latest.Original(), nil
+	}
+
+	// the tagged commit is the first commit, there is nothing before it
+	c, err := gitp.GetCommitFromRef(ctx, ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(c.Parents) == 0 {
+		return latest.Original(), "", nil
+	}
+
+	previous, err := gitp.GetLatestSemverTagFromRef(ctx, ref+"^")
+	if err != nil {
+		if errors.Is(err, git.ErrNoSemverTags) {
+			return latest.Original(), "", nil
+		}
+		return "", "", err
+	}
+
+	return latest.Original(), previous.Original(), nil
+}
+
+// attributePullRequests maps commits to the merged pull request they came from. A commit belongs to a pull
+// request if it is its head, if it is a squash merge or merge commit naming it, or if it was merged by it.
+func attributePullRequests(commits []*git.Commit, prs []*git.PullRequest) map[string]int {
+
+	heads := map[string]int{}
+	for _, pr := range prs {
+		if pr.Closed {
+			heads[pr.Head] = pr.Number
+		}
+	}
+
+	byHash := map[string]*git.Commit{}
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+
+	// commits on the first parent chain were made on the branch itself, not merged into it
+	mainline := map[string]bool{}
+	if len(commits) > 0 {
+		for c := commits[0]; c != nil; {
+			mainline[c.Hash] = true
+			if len(c.Parents) == 0 {
+				break
+			}
+			c = byHash[c.Parents[0]]
+		}
+	}
+
+	owners := map[string]int{}
+
+	for _, c := range commits {
+		if n, ok := heads[c.Hash]; ok {
+			owners[c.Hash] = n
+			continue
+		}
+
+		title := strings.SplitN(c.Message, "\n", 2)[0]
+
+		if m := squashMergeRegex.FindStringSubmatch(title); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			owners[c.Hash] = n
+			continue
+		}
+
+		if len(c.Parents) < 2 {
+			continue
+		}
+
+		n, ok := heads[c.Parents[1]]
+		if m := mergeCommitRegex.FindStringSubmatch(title); !ok && m != nil {
+			n, _ = strconv.Atoi(m[1])
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+
+		owners[c.Hash] = n
+
+		// everything the merge brought in belongs to the pull request
+		queue := []string{c.Parents[1]}
+		for len(queue) > 0 {
+			h := queue[0]
+			queue = queue[1:]
+
+			mc, ok := byHash[h]
+			if !ok || mainline[h] {
+				continue
+			}
+			if _, done := owners[h]; done {
+				continue
+			}
+			owners[h] = n
+			queue = append(queue, mc.Parents...)
+		}
+	}
+
+	return owners
+}
+
+func newEntry(c *git.Commit) *Entry {
+	e := &Entry{
+		Hash:        c.Hash,
+		Description: strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0]),
+		Author:      c.Author,
+	}
+
+	cc, ok := buildrc.ParseConventionalCommit(c.Message)
+	if !ok {
+		return e
+	}
+
+	e.Type = cc.Type
+	e.Scope = cc.Scope
+	e.Description = cc.Description
+	e.Breaking = cc.Breaking
+
+	for _, key := range []string{"BREAKING CHANGE", "BREAKING-CHANGE"} {
+		if note, ok := cc.Footers[key]; ok {
+			e.BreakingNote = strings.TrimSpace(note)
+		}
+	}
+
+	return e
+}
+
+// TypeTitle returns the section title of the conventional commit type.
+func TypeTitle(typ string) string {
+	if typ == "" {
+		return "Other Changes"
+	}
+	for _, t := range typeTitles {
+		if t.Type == typ {
+			return t.Title
+		}
+	}
+	return typ
+}
+
+func groupByType(entries []*Entry) []*Section {
+	byType := map[string][]*Entry{}
+	for _, e := range entries {
+		byType[e.Type] = append(byType[e.Type], e)
+	}
+
+	order := []string{}
+	for _, t := range typeTitles {
+		if _, ok := byType[t.Type]; ok {
+			order = append(order, t.Type)
+		}
+	}
+
+	unknown := []string{}
+	for typ := range byType {
+		if typ != "" && TypeTitle(typ) == typ {
+			unknown = append(unknown, typ)
+		}
+	}
+	sort.Strings(unknown)
+	order = append(order, unknown...)
+
+	if _, ok := byType[""]; ok {
+		order = append(order, "")
+	}
+
+	sections := []*Section{}
+	for _, typ := range order {
+		sections = append(sections, &Section{Title: TypeTitle(typ), Entries: byType[typ]})
+	}
+
+	return sections
+}
+
+func groupByPullRequest(entries []*Entry) []*Section {
+	byPR := map[int][]*Entry{}
+	for _, e := range entries {
+		byPR[e.PullRequest] = append(byPR[e.PullRequest], e)
+	}
+
+	numbers := []int{}
+	for n := range byPR {
+		if n != 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+
+	sections := []*Section{}
+	for _, n := range numbers {
+		sections = append(sections, &Section{Title: "#" + strconv.Itoa(n), Entries: byPR[n]})
+	}
+
+	if direct, ok := byPR[0]; ok {
+		sections = append(sections, &Section{Title: "Direct Commits", Entries: direct})
+	}
+
+	return sections
+}
@@ -0,0 +1,310 @@
+package changelog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/changelog"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+var date = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// since is the commit date of the previous tag, v1.1.0
+var since = time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+// history is newest first: a merged pull request (#12) with two commits, a squash merged
+// pull request (#10), a direct commit and a rebase merged pull request (#9).
+var history = []*git.Commit{
+	{Hash: "m100000000", Author: "octocat", Date: date, Parents: []string{"c100000000", "f200000000"}, Message: "Merge pull request #12 from octocat/feature"},
+	{Hash: "f200000000", Author: "octocat", Date: date, Parents: []string{"f100000000"}, Message: "feat(api): add endpoint"},
+	{Hash: "f100000000", Author: "octocat", Date: date, Parents: []string{"c000000000"}, Message: "fix: handle nil config\n\nBREAKING CHANGE: a nil config is now an error"},
+	{Hash: "c100000000", Author: "hubot", Date: date, Parents: []string{"c000000000"}, Message: "feat!: drop v1 config (#10)"},
+	{Hash: "c000000000", Author: "hubot", Date: date, Parents: []string{"h100000000"}, Message: "docs: update readme"},
+	{Hash: "h100000000", Author: "hubot", Date: date, Parents: []string{"b000000000"}, Message: "chore: bump deps"},
+}
+
+var pullRequests = []*git.PullRequest{
+	{Number: 13, Head: "o100000000", Open: true},
+	{Number: 12, Head: "f200000000", Closed: true},
+	{Number: 9, Head: "h100000000", Closed: true},
+}
+
+func newProviders(t *testing.T, tag string, tagged string) (*mockery.MockGitProvider_git, *mockery.MockPullRequestProvider_git) {
+	t.Helper()
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return("m100000000", nil)
+	gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse(tag), nil).Maybe()
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, tag).Return(tagged, nil).Maybe()
+	gitp.EXPECT().GetCommitFromRef(mock.Anything, "HEAD").Return(history[0], nil).Maybe()
+	gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD^").Return(semver.MustParse("v1.1.0"), nil).Maybe()
+	gitp.EXPECT().ListCommitsBetweenRefs(mock.Anything, "v1.1.0", "HEAD").Return(history, nil)
+	gitp.EXPECT().GetCommitFromRef(mock.Anything, "v1.1.0").Return(&git.Commit{Hash: "b000000000", CommitDate: since}, nil)
+
+	prp := mockery.NewMockPullRequestProvider_git(t)
+	prp.EXPECT().ListPullRequestsUpdatedSince(mock.Anything, "main", since).Return(pullRequests, nil)
+
+	return gitp, prp
+}
+
+func hashes(entries []*changelog.Entry) []string {
+	res := []string{}
+	for _, e := range entries {
+		res = append(res, e.ShortHash())
+	}
+	return res
+}
+
+func titles(sections []*changelog.Section) map[string][]string {
+	res := map[string][]string{}
+	for _, s := range sections {
+		res[s.Title] = hashes(s.Entries)
+	}
+	return res
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		groupBy      changelog.GroupBy
+		tag          string
+		tagged       string
+		wantVersion  string
+		wantSections []string
+		wantEntries  map[string][]string
+	}{
+		{
+			name:         "by type, unreleased",
+			groupBy:      changelog.GroupByType,
+			tag:          "v1.1.0",
+			tagged:       "b000000000",
+			wantVersion:  changelog.Unreleased,
+			wantSections: []string{"Features", "Bug Fixes", "Documentation", "Chores"},
+			wantEntries: map[string][]string{
+				"Features":      {"f200000", "c100000"},
+				"Bug Fixes":     {"f100000"},
+				"Documentation": {"c000000"},
+				"Chores":        {"h100000"},
+			},
+		},
+		{
+			name:         "by pull request, tagged",
+			groupBy:      changelog.GroupByPullRequest,
+			tag:          "v1.2.0",
+			tagged:       "m100000000",
+			wantVersion:  "v1.2.0",
+			wantSections: []string{"#12", "#10", "#9", "Direct Commits"},
+			wantEntries: map[string][]string{
+				"#12":            {"f200000", "f100000"},
+				"#10":            {"c100000"},
+				"#9":             {"h100000"},
+				"Direct Commits": {"c000000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitp, prp := newProviders(t, tt.tag, tt.tagged)
+
+			cl, err := changelog.Generate(ctx, gitp, prp, &changelog.Options{GroupBy: tt.groupBy})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantVersion, cl.Version)
+			assert.Equal(t, "v1.1.0", cl.From)
+			assert.Equal(t, date, cl.Date)
+
+			sections := []string{}
+			for _, s := range cl.Sections {
+				sections = append(sections, s.Title)
+			}
+			assert.Equal(t, tt.wantSections, sections)
+			assert.Equal(t, tt.wantEntries, titles(cl.Sections))
+
+			// merge commits are not listed, but breaking changes are called out
+			assert.Len(t, cl.Entries, 5)
+			assert.Equal(t, []string{"f100000", "c100000"}, hashes(cl.Breaking))
+			assert.Equal(t, "a nil config is now an error", cl.Breaking[0].BreakingNote)
+		})
+	}
+}
+
+func TestGenerateSurroundingTags(t *testing.T) {
+	ctx := context.Background()
+
+	first := &git.Commit{Hash: "a000000000", Author: "hubot", Date: date, Message: "feat: first"}
+
+	tests := []struct {
+		name        string
+		setup       func(gitp *mockery.MockGitProvider_git)
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			name: "no tags",
+			setup: func(gitp *mockery.MockGitProvider_git) {
+				gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrNoSemverTags)
+			},
+			wantVersion: changelog.Unreleased,
+		},
+		{
+			name: "tagged first commit",
+			setup: func(gitp *mockery.MockGitProvider_git) {
+				gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v0.1.0"), nil)
+				gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "v0.1.0").Return(first.Hash, nil)
+				gitp.EXPECT().GetCommitFromRef(mock.Anything, "HEAD").Return(first, nil)
+			},
+			wantVersion: "v0.1.0",
+		},
+		{
+			name: "first tag after untagged commits",
+			setup: func(gitp *mockery.MockGitProvider_git) {
+				gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v0.1.0"), nil)
+				gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "v0.1.0").Return(first.Hash, nil)
+				gitp.EXPECT().GetCommitFromRef(mock.Anything, "HEAD").Return(&git.Commit{Hash: first.Hash, Parents: []string{"b000000000"}}, nil)
+				gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD^").Return(nil, git.ErrNoSemverTags)
+			},
+			wantVersion: "v0.1.0",
+		},
+		{
+			name: "git errors are kept",
+			setup: func(gitp *mockery.MockGitProvider_git) {
+				gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(semver.MustParse("v0.1.0"), nil)
+				gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "v0.1.0").Return("", git.ErrRefNotFound)
+			},
+			wantErr: git.ErrRefNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitp := mockery.NewMockGitProvider_git(t)
+			gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return(first.Hash, nil)
+			tt.setup(gitp)
+
+			prp := mockery.NewMockPullRequestProvider_git(t)
+
+			if tt.wantErr == nil {
+				gitp.EXPECT().ListCommitsBetweenRefs(mock.Anything, "", "HEAD").Return([]*git.Commit{first}, nil)
+				prp.EXPECT().ListPullRequestsUpdatedSince(mock.Anything, "main", time.Time{}).Return([]*git.PullRequest{}, nil)
+			}
+
+			cl, err := changelog.Generate(ctx, gitp, prp, &changelog.Options{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantVersion, cl.Version)
+			assert.Empty(t, cl.From)
+			assert.Len(t, cl.Entries, 1)
+		})
+	}
+}
+
+func TestGenerateUnknownGroupBy(t *testing.T) {
+	_, err := changelog.Generate(context.Background(), nil, nil, &changelog.Options{GroupBy: "author"})
+	assert.ErrorIs(t, err, changelog.ErrUnknownGroupBy)
+}
+
+func TestRender(t *testing.T) {
+	ctx := context.Background()
+
+	gitp, prp := newProviders(t, "v1.2.0", "m100000000")
+
+	cl, err := changelog.Generate(ctx, gitp, prp, &changelog.Options{})
+	require.NoError(t, err)
+
+	md, err := cl.Render(changelog.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Equal(t, `# v1.2.0 (2024-03-01)
+
+## Breaking Changes
+
+- handle nil config (#12) (f100000)
+  a nil config is now an error
+- drop v1 config (#10) (c100000)
+
+## Features
+
+- **api:** add endpoint (#12) (f200000)
+- drop v1 config (#10) (c100000)
+
+## Bug Fixes
+
+- handle nil config (#12) (f100000)
+
+## Documentation
+
+- update readme (c000000)
+
+## Chores
+
+- bump deps (#9) (h100000)
+`, md)
+
+	kac, err := cl.Render(changelog.FormatKeepAChangelog)
+	require.NoError(t, err)
+	assert.Equal(t, `## [1.2.0] - 2024-03-01
+
+### Added
+
+- **api:** add endpoint (#12) (f200000)
+- **BREAKING** drop v1 config (#10) (c100000)
+
+### Fixed
+
+- **BREAKING** handle nil config (#12) (f100000)
+  a nil config is now an error
+`, kac)
+
+	js, err := cl.Render(changelog.FormatJSON)
+	require.NoError(t, err)
+	assert.Contains(t, js, `"version": "v1.2.0"`)
+	assert.Contains(t, js, `"pull-request": 12`)
+
+	_, err = cl.Render("html")
+	assert.ErrorIs(t, err, changelog.ErrUnknownFormat)
+}
+
+func TestPrepend(t *testing.T) {
+	section := "## [1.2.0] - 2024-03-01\n\n### Added\n\n- new\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name:     "new file",
+			existing: "",
+			want:     changelog.KeepAChangelogHeader + "\n" + section,
+		},
+		{
+			name:     "before the latest version",
+			existing: "# Changelog\n\nintro\n\n## [1.1.0] - 2024-01-01\n\n### Fixed\n\n- old\n",
+			want:     "# Changelog\n\nintro\n\n" + section + "\n## [1.1.0] - 2024-01-01\n\n### Fixed\n\n- old\n",
+		},
+		{
+			name:     "no versions yet",
+			existing: "# Changelog\n\nintro",
+			want:     "# Changelog\n\nintro\n\n" + section,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, changelog.Prepend(tt.existing, section))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+type Format string
+
+const (
+	FormatMarkdown       Format = "markdown"
+	FormatJSON           Format = "json"
+	FormatKeepAChangelog Format = "keep-a-changelog"
+)
+
+var ErrUnknownFormat = errors.New("changelog.ErrUnknownFormat")
+
+// KeepAChangelogHeader starts a new CHANGELOG.md, see https://keepachangelog.com.
+const KeepAChangelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// keepAChangelogCategories maps conventional commit types to the keep a changelog categories, in order.
+// Types that are not listed are not user facing and are left out, unless they are breaking.
+var keepAChangelogCategories = []struct {
+	Category string
+	Types    []string
+}{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"perf", "refactor"}},
+	{"Deprecated", []string{"deprecate"}},
+	{"Removed", []string{"revert"}},
+	{"Fixed", []string{"fix"}},
+	{"Security", []string{"security"}},
+}
+
+// Render renders the changelog in the format.
+func (me *Changelog) Render(format Format) (string, error) {
+	switch format {
+	case FormatMarkdown, "":
+		return me.Markdown(), nil
+	case FormatJSON:
+		byt, err := json.MarshalIndent(me, "", "\t")
+		if err != nil {
+			return "", err
+		}
+		return string(byt) + "\n", nil
+	case FormatKeepAChangelog:
+		return me.KeepAChangelog(), nil
+	default:
+		return "", errors.Wrapf(ErrUnknownFormat, "'%s', must be one of [%s, %s, %s]", format, FormatMarkdown, FormatJSON, FormatKeepAChangelog)
+	}
+}
+
+func (me *Entry) line() string {
+	var b strings.Builder
+
+	b.WriteString("- ")
+	if me.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", me.Scope)
+	}
+	b.WriteString(me.Description)
+	if me.PullRequest != 0 && !strings.HasSuffix(me.Description, fmt.Sprintf("(#%d)", me.PullRequest)) {
+		fmt.Fprintf(&b, " (#%d)", me.PullRequest)
+	}
+	fmt.Fprintf(&b, " (%s)", me.ShortHash())
+
+	return b.String()
+}
+
+func (me *Entry) breakingLine() string {
+	if me.BreakingNote == "" {
+		return me.line()
+	}
+	return me.line() + "\n  " + strings.ReplaceAll(me.BreakingNote, "\n", "\n  ")
+}
+
+// Markdown renders release notes, with breaking changes called out first.
+func (me *Changelog) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s (%s)\n", me.Version, me.Date.Format("2006-01-02"))
+
+	if len(me.Breaking) > 0 {
+		b.WriteString("\n## Breaking Changes\n\n")
+		for _, e := range me.Breaking {
+			b.WriteString(e.breakingLine() + "\n")
+		}
+	}
+
+	for _, s := range me.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			b.WriteString(e.line() + "\n")
+		}
+	}
+
+	if len(me.Entries) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+
+	return b.String()
+}
+
+// KeepAChangelog renders a version section of a keep a changelog file. Breaking changes
+// are listed under Changed unless their type maps to another category.
+func (me *Changelog) KeepAChangelog() string {
+	var b strings.Builder
+
+	if me.Version == Unreleased {
+		fmt.Fprintf(&b, "## [%s]\n", Unreleased)
+	} else {
+		fmt.Fprintf(&b, "## [%s] - %s\n", strings.TrimPrefix(me.Version, "v"), me.Date.Format("2006-01-02"))
+	}
+
+	for _, cat := range keepAChangelogCategories {
+		lines := []string{}
+		for _, e := range me.Entries {
+			if !slices.Contains(cat.Types, e.Type) && !(cat.Category == "Changed" && e.Breaking && categoryOf(e.Type) == "") {
+				continue
+			}
+			if e.Breaking {
+				lines = append(lines, strings.Replace(e.breakingLine(), "- ", "- **BREAKING** ", 1))
+			} else {
+				lines = append(lines, e.line())
+			}
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", cat.Category)
+		b.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+
+	return b.String()
+}
+
+// Prepend inserts the section before the first version section of an existing keep a changelog file,
+// or starts a new file if existing is empty.
+func Prepend(existing string, section string) string {
+	if strings.TrimSpace(existing) == "" {
+		return KeepAChangelogHeader + "\n" + section
+	}
+
+	lines := strings.SplitAfter(existing, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			return strings.Join(lines[:i], "") + section + "\n" + strings.Join(lines[i:], "")
+		}
+	}
+
+	if !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+
+	return existing + "\n" + section
+}
+
+func categoryOf(typ string) string {
+	for _, cat := range keepAChangelogCategories {
+		if slices.Contains(cat.Types, typ) {
+			return cat.Category
+		}
+	}
+	return ""
+}
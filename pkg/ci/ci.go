@@ -0,0 +1,128 @@
+package ci
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+)
+
+type Provider string
+
+const (
+	ProviderNone           Provider = ""
+	ProviderGeneric        Provider = "generic"
+	ProviderGitHubActions  Provider = "github-actions"
+	ProviderGitLab         Provider = "gitlab"
+	ProviderBuildkite      Provider = "buildkite"
+	ProviderCircleCI       Provider = "circleci"
+	ProviderJenkins        Provider = "jenkins"
+	ProviderAzurePipelines Provider = "azure-pipelines"
+)
+
+type Event string
+
+const (
+	EventUnknown     Event = ""
+	EventPush        Event = "push"
+	EventPullRequest Event = "pull-request"
+	EventTag         Event = "tag"
+	EventSchedule    Event = "schedule"
+	EventManual      Event = "manual"
+)
+
+// Environment is what could be learned about the build from the CI environment variables.
+// Fields the provider does not expose are left empty.
+type Environment struct {
+	CI           bool     `json:"ci"`
+	Provider     Provider `json:"provider"`
+	Event        Event    `json:"event"`
+	PRNumber     uint64   `json:"pr-number"`
+	SourceBranch string   `json:"source-branch"`
+	TargetBranch string   `json:"target-branch"`
+	Tag          string   `json:"tag"`
+	Commit       string   `json:"commit"`
+}
+
+type Detector interface {
+	Detect(ctx context.Context) (*Environment, error)
+}
+
+// providerDetector returns nil if the environment does not belong to its provider.
+type providerDetector func(getenv func(string) string) (*Environment, error)
+
+var _ Detector = (*EnvDetector)(nil)
+
+// EnvDetector detects the CI environment from environment variables. Providers are checked in order,
+// falling back to the conventional CI=true variable for providers without a dedicated detector.
+type EnvDetector struct {
+	getenv    func(string) string
+	detectors []providerDetector
+}
+
+func NewEnvDetector(getenv func(string) string) *EnvDetector {
+	return &EnvDetector{
+		getenv: getenv,
+		detectors: []providerDetector{
+			detectGitHubActions,
+			detectGitLab,
+			detectBuildkite,
+			detectCircleCI,
+			detectJenkins,
+			detectAzurePipelines,
+		},
+	}
+}
+
+func (me *EnvDetector) Detect(ctx context.Context) (*Environment, error) {
+	for _, detect := range me.detectors {
+		env, err := detect(me.getenv)
+		if err != nil {
+			return nil, err
+		}
+
+		if env == nil {
+			continue
+		}
+
+		env.CI = true
+		env.SourceBranch = strings.TrimPrefix(env.SourceBranch, "refs/heads/")
+		env.TargetBranch = strings.TrimPrefix(env.TargetBranch, "refs/heads/")
+
+		if env.Event == EventUnknown {
+			switch {
+			case env.PRNumber > 0:
+				env.Event = EventPullRequest
+			case env.Tag != "":
+				env.Event = EventTag
+			}
+		}
+
+		zerolog.Ctx(ctx).Debug().Any("environment", env).Msg("detected ci environment")
+
+		return env, nil
+	}
+
+	if ok, _ := strconv.ParseBool(me.getenv("CI")); ok {
+		zerolog.Ctx(ctx).Debug().Msg("detected generic ci environment")
+		return &Environment{CI: true, Provider: ProviderGeneric}, nil
+	}
+
+	return &Environment{}, nil
+}
+
+// parsePRNumber parses a pull request number, treating empty and 'false' as no pull request.
+func parsePRNumber(name string, value string) (uint64, error) {
+	if value == "" || value == "false" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid pull request number in %s", name)
+	}
+
+	return n, nil
+}
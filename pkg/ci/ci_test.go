@@ -0,0 +1,262 @@
+package ci_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/ci"
+)
+
+func TestEnvDetectorDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *ci.Environment
+	}{
+		{
+			name:     "not ci",
+			env:      map[string]string{},
+			expected: &ci.Environment{},
+		},
+		{
+			name: "generic ci",
+			env:  map[string]string{"CI": "true"},
+			expected: &ci.Environment{
+				CI:       true,
+				Provider: ci.ProviderGeneric,
+			},
+		},
+		{
+			name: "github actions pull request",
+			env: map[string]string{
+				"CI":                "true",
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_REF":        "refs/pull/42/merge",
+				"GITHUB_REF_NAME":   "42/merge",
+				"GITHUB_HEAD_REF":   "feature",
+				"GITHUB_BASE_REF":   "main",
+				"GITHUB_SHA":        "abc",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderGitHubActions,
+				Event:        ci.EventPullRequest,
+				PRNumber:     42,
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "github actions tag push",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REF":        "refs/tags/v1.2.3",
+				"GITHUB_REF_NAME":   "v1.2.3",
+				"GITHUB_SHA":        "abc",
+			},
+			expected: &ci.Environment{
+				CI:       true,
+				Provider: ci.ProviderGitHubActions,
+				Event:    ci.EventTag,
+				Tag:      "v1.2.3",
+				Commit:   "abc",
+			},
+		},
+		{
+			name: "github actions schedule",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "schedule",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_REF_NAME":   "main",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderGitHubActions,
+				Event:        ci.EventSchedule,
+				SourceBranch: "main",
+			},
+		},
+		{
+			name: "gitlab merge request",
+			env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_PIPELINE_SOURCE":                  "merge_request_event",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_COMMIT_SHA":                       "abc",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderGitLab,
+				Event:        ci.EventPullRequest,
+				PRNumber:     7,
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "gitlab branch push",
+			env: map[string]string{
+				"GITLAB_CI":          "true",
+				"CI_PIPELINE_SOURCE": "push",
+				"CI_COMMIT_BRANCH":   "main",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderGitLab,
+				Event:        ci.EventPush,
+				SourceBranch: "main",
+			},
+		},
+		{
+			name: "buildkite pull request",
+			env: map[string]string{
+				"BUILDKITE":                          "true",
+				"BUILDKITE_SOURCE":                   "webhook",
+				"BUILDKITE_PULL_REQUEST":             "12",
+				"BUILDKITE_PULL_REQUEST_BASE_BRANCH": "main",
+				"BUILDKITE_BRANCH":                   "feature",
+				"BUILDKITE_COMMIT":                   "abc",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderBuildkite,
+				Event:        ci.EventPullRequest,
+				PRNumber:     12,
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "buildkite branch build",
+			env: map[string]string{
+				"BUILDKITE":              "true",
+				"BUILDKITE_SOURCE":       "webhook",
+				"BUILDKITE_PULL_REQUEST": "false",
+				"BUILDKITE_BRANCH":       "main",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderBuildkite,
+				Event:        ci.EventPush,
+				SourceBranch: "main",
+			},
+		},
+		{
+			name: "circleci pull request",
+			env: map[string]string{
+				"CIRCLECI":            "true",
+				"CIRCLE_PULL_REQUEST": "https://github.com/org/repo/pull/99",
+				"CIRCLE_BRANCH":       "feature",
+				"CIRCLE_SHA1":         "abc",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderCircleCI,
+				Event:        ci.EventPullRequest,
+				PRNumber:     99,
+				SourceBranch: "feature",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "circleci tag",
+			env: map[string]string{
+				"CIRCLECI":   "true",
+				"CIRCLE_TAG": "v2.0.0",
+			},
+			expected: &ci.Environment{
+				CI:       true,
+				Provider: ci.ProviderCircleCI,
+				Event:    ci.EventTag,
+				Tag:      "v2.0.0",
+			},
+		},
+		{
+			name: "jenkins multibranch pull request",
+			env: map[string]string{
+				"JENKINS_URL":   "https://jenkins.example.com",
+				"BRANCH_NAME":   "PR-5",
+				"CHANGE_ID":     "5",
+				"CHANGE_BRANCH": "feature",
+				"CHANGE_TARGET": "main",
+				"GIT_COMMIT":    "abc",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderJenkins,
+				Event:        ci.EventPullRequest,
+				PRNumber:     5,
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "azure pipelines pull request",
+			env: map[string]string{
+				"TF_BUILD":                             "True",
+				"BUILD_REASON":                         "PullRequest",
+				"BUILD_SOURCEBRANCH":                   "refs/pull/3/merge",
+				"BUILD_SOURCEVERSION":                  "abc",
+				"SYSTEM_PULLREQUEST_PULLREQUESTID":     "3",
+				"SYSTEM_PULLREQUEST_SOURCEBRANCH":      "refs/heads/feature",
+				"SYSTEM_PULLREQUEST_TARGETBRANCH":      "refs/heads/main",
+				"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER": "",
+			},
+			expected: &ci.Environment{
+				CI:           true,
+				Provider:     ci.ProviderAzurePipelines,
+				Event:        ci.EventPullRequest,
+				PRNumber:     3,
+				SourceBranch: "feature",
+				TargetBranch: "main",
+				Commit:       "abc",
+			},
+		},
+		{
+			name: "azure pipelines tag",
+			env: map[string]string{
+				"TF_BUILD":           "True",
+				"BUILD_REASON":       "IndividualCI",
+				"BUILD_SOURCEBRANCH": "refs/tags/v1.0.0",
+			},
+			expected: &ci.Environment{
+				CI:       true,
+				Provider: ci.ProviderAzurePipelines,
+				Event:    ci.EventTag,
+				Tag:      "v1.0.0",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			det := ci.NewEnvDetector(func(key string) string {
+				return test.env[key]
+			})
+
+			env, err := det.Detect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, env)
+		})
+	}
+
+	t.Run("invalid pull request number", func(t *testing.T) {
+		det := ci.NewEnvDetector(func(key string) string {
+			return map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_IID": "abc"}[key]
+		})
+
+		_, err := det.Detect(context.Background())
+		require.Error(t, err)
+	})
+}
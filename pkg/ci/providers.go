@@ -0,0 +1,235 @@
+package ci
+
+import (
+	"path"
+	"strings"
+)
+
+// https://docs.github.com/en/actions/learn-github-actions/variables#default-environment-variables
+func detectGitHubActions(getenv func(string) string) (*Environment, error) {
+	if getenv("GITHUB_ACTIONS") != "true" {
+		return nil, nil
+	}
+
+	env := &Environment{
+		Provider:     ProviderGitHubActions,
+		SourceBranch: getenv("GITHUB_REF_NAME"),
+		TargetBranch: getenv("GITHUB_BASE_REF"),
+		Commit:       getenv("GITHUB_SHA"),
+	}
+
+	ref := getenv("GITHUB_REF")
+
+	switch getenv("GITHUB_EVENT_NAME") {
+	case "pull_request", "pull_request_target":
+		env.Event = EventPullRequest
+		env.SourceBranch = getenv("GITHUB_HEAD_REF")
+	case "push":
+		env.Event = EventPush
+	case "schedule":
+		env.Event = EventSchedule
+	case "workflow_dispatch", "repository_dispatch":
+		env.Event = EventManual
+	}
+
+	if strings.HasPrefix(ref, "refs/tags/") {
+		env.Event = EventTag
+		env.Tag = strings.TrimPrefix(ref, "refs/tags/")
+		env.SourceBranch = ""
+	}
+
+	// refs/pull/123/merge
+	if strings.HasPrefix(ref, "refs/pull/") {
+		n, err := parsePRNumber("GITHUB_REF", strings.Split(ref, "/")[2])
+		if err != nil {
+			return nil, err
+		}
+		env.PRNumber = n
+	}
+
+	return env, nil
+}
+
+// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+func detectGitLab(getenv func(string) string) (*Environment, error) {
+	if getenv("GITLAB_CI") != "true" {
+		return nil, nil
+	}
+
+	n, err := parsePRNumber("CI_MERGE_REQUEST_IID", getenv("CI_MERGE_REQUEST_IID"))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Provider:     ProviderGitLab,
+		PRNumber:     n,
+		SourceBranch: getenv("CI_COMMIT_BRANCH"),
+		Tag:          getenv("CI_COMMIT_TAG"),
+		Commit:       getenv("CI_COMMIT_SHA"),
+	}
+
+	if n > 0 {
+		env.SourceBranch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+		env.TargetBranch = getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+	}
+
+	switch getenv("CI_PIPELINE_SOURCE") {
+	case "merge_request_event", "external_pull_request_event":
+		env.Event = EventPullRequest
+	case "push":
+		env.Event = EventPush
+		if env.Tag != "" {
+			env.Event = EventTag
+		}
+	case "schedule":
+		env.Event = EventSchedule
+	case "web", "api", "trigger", "pipeline":
+		env.Event = EventManual
+	}
+
+	return env, nil
+}
+
+// https://buildkite.com/docs/pipelines/environment-variables
+func detectBuildkite(getenv func(string) string) (*Environment, error) {
+	if getenv("BUILDKITE") != "true" {
+		return nil, nil
+	}
+
+	n, err := parsePRNumber("BUILDKITE_PULL_REQUEST", getenv("BUILDKITE_PULL_REQUEST"))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Provider:     ProviderBuildkite,
+		PRNumber:     n,
+		SourceBranch: getenv("BUILDKITE_BRANCH"),
+		Tag:          getenv("BUILDKITE_TAG"),
+		Commit:       getenv("BUILDKITE_COMMIT"),
+	}
+
+	if n > 0 {
+		env.TargetBranch = getenv("BUILDKITE_PULL_REQUEST_BASE_BRANCH")
+	}
+
+	switch getenv("BUILDKITE_SOURCE") {
+	case "schedule":
+		env.Event = EventSchedule
+	case "ui", "api", "trigger_job":
+		env.Event = EventManual
+	case "webhook":
+		env.Event = EventPush
+		if n > 0 {
+			env.Event = EventPullRequest
+		} else if env.Tag != "" {
+			env.Event = EventTag
+		}
+	}
+
+	return env, nil
+}
+
+// https://circleci.com/docs/variables/#built-in-environment-variables
+func detectCircleCI(getenv func(string) string) (*Environment, error) {
+	if getenv("CIRCLECI") != "true" {
+		return nil, nil
+	}
+
+	// CIRCLE_PR_NUMBER is only set for forked pull requests, CIRCLE_PULL_REQUEST is the pull request url
+	number := getenv("CIRCLE_PR_NUMBER")
+	if number == "" && getenv("CIRCLE_PULL_REQUEST") != "" {
+		number = path.Base(getenv("CIRCLE_PULL_REQUEST"))
+	}
+
+	n, err := parsePRNumber("CIRCLE_PULL_REQUEST", number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Environment{
+		Provider:     ProviderCircleCI,
+		PRNumber:     n,
+		SourceBranch: getenv("CIRCLE_BRANCH"),
+		Tag:          getenv("CIRCLE_TAG"),
+		Commit:       getenv("CIRCLE_SHA1"),
+	}, nil
+}
+
+// https://www.jenkins.io/doc/book/pipeline/multibranch/#additional-environment-variables
+func detectJenkins(getenv func(string) string) (*Environment, error) {
+	if getenv("JENKINS_URL") == "" {
+		return nil, nil
+	}
+
+	n, err := parsePRNumber("CHANGE_ID", getenv("CHANGE_ID"))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Provider:     ProviderJenkins,
+		PRNumber:     n,
+		SourceBranch: getenv("BRANCH_NAME"),
+		Tag:          getenv("TAG_NAME"),
+		Commit:       getenv("GIT_COMMIT"),
+	}
+
+	if n > 0 {
+		env.SourceBranch = getenv("CHANGE_BRANCH")
+		env.TargetBranch = getenv("CHANGE_TARGET")
+	}
+
+	return env, nil
+}
+
+// https://learn.microsoft.com/en-us/azure/devops/pipelines/build/variables
+func detectAzurePipelines(getenv func(string) string) (*Environment, error) {
+	if !strings.EqualFold(getenv("TF_BUILD"), "true") {
+		return nil, nil
+	}
+
+	env := &Environment{
+		Provider: ProviderAzurePipelines,
+		Commit:   getenv("BUILD_SOURCEVERSION"),
+	}
+
+	ref := getenv("BUILD_SOURCEBRANCH")
+	if strings.HasPrefix(ref, "refs/tags/") {
+		env.Tag = strings.TrimPrefix(ref, "refs/tags/")
+	} else {
+		env.SourceBranch = ref
+	}
+
+	switch getenv("BUILD_REASON") {
+	case "PullRequest":
+		env.Event = EventPullRequest
+
+		// the number is only set for GitHub repositories, the id for Azure Repos
+		number := getenv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER")
+		if number == "" {
+			number = getenv("SYSTEM_PULLREQUEST_PULLREQUESTID")
+		}
+
+		n, err := parsePRNumber("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", number)
+		if err != nil {
+			return nil, err
+		}
+
+		env.PRNumber = n
+		env.SourceBranch = getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH")
+		env.TargetBranch = getenv("SYSTEM_PULLREQUEST_TARGETBRANCH")
+	case "IndividualCI", "BatchedCI":
+		env.Event = EventPush
+		if env.Tag != "" {
+			env.Event = EventTag
+		}
+	case "Schedule":
+		env.Event = EventSchedule
+	case "Manual":
+		env.Event = EventManual
+	}
+
+	return env, nil
+}
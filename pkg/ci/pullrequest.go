@@ -0,0 +1,77 @@
+package ci
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-faster/errors"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+var _ git.PullRequestProvider = (*PullRequestProvider)(nil)
+
+// PullRequestProvider is a git.PullRequestProvider that only knows about the pull request
+// the CI environment is building. It has no history, so merged pull requests are never found.
+type PullRequestProvider struct {
+	det Detector
+}
+
+func NewPullRequestProvider(det Detector) *PullRequestProvider {
+	return &PullRequestProvider{det: det}
+}
+
+func (me *PullRequestProvider) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
+	if head != "HEAD" {
+		return []*git.PullRequest{}, nil
+	}
+
+	env, err := me.det.Detect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if env.PRNumber == 0 {
+		return []*git.PullRequest{}, nil
+	}
+
+	return []*git.PullRequest{
+		{
+			Number: int(env.PRNumber),
+			Head:   env.SourceBranch,
+			Open:   true,
+		},
+	}, nil
+}
+
+// ListPullRequestsUpdatedSince finds no pull requests, the ci environment only knows the pull request of HEAD.
+func (me *PullRequestProvider) ListPullRequestsUpdatedSince(_ context.Context, _ string, _ time.Time) ([]*git.PullRequest, error) {
+	return []*git.PullRequest{}, nil
+}
+
+// CheckedOutHead returns the branch HEAD is on and the pull request it is built for, 0 if unknown.
+// CI checkouts are usually detached, then the source branch and pull request of the environment are used.
+func CheckedOutHead(ctx context.Context, gitp git.GitProvider, det Detector) (string, uint64, error) {
+	branch, err := gitp.GetCurrentBranchFromRef(ctx, "HEAD")
+	if err != nil {
+		return "", 0, err
+	}
+
+	if branch != "HEAD" {
+		return branch, 0, nil
+	}
+
+	if det == nil {
+		return "", 0, git.ErrDetachedHead
+	}
+
+	env, err := det.Detect(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if env.PRNumber == 0 && env.SourceBranch == "" {
+		return "", 0, errors.Wrap(git.ErrDetachedHead, "the ci environment has no source branch")
+	}
+
+	return env.SourceBranch, env.PRNumber, nil
+}
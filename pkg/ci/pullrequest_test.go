@@ -0,0 +1,120 @@
+package ci_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestPullRequestProviderListRecentPullRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		head     string
+		expected []*git.PullRequest
+	}{
+		{
+			name: "pull request build",
+			env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+			},
+			head:     "HEAD",
+			expected: []*git.PullRequest{{Number: 7, Head: "feature", Open: true}},
+		},
+		{
+			name:     "branch build",
+			env:      map[string]string{"GITLAB_CI": "true", "CI_COMMIT_BRANCH": "main"},
+			head:     "HEAD",
+			expected: []*git.PullRequest{},
+		},
+		{
+			name: "other heads are unknown",
+			env: map[string]string{
+				"GITLAB_CI":            "true",
+				"CI_MERGE_REQUEST_IID": "7",
+			},
+			head:     "main",
+			expected: []*git.PullRequest{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prp := ci.NewPullRequestProvider(ci.NewEnvDetector(func(key string) string {
+				return test.env[key]
+			}))
+
+			prs, err := prp.ListRecentPullRequests(context.Background(), test.head)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, prs)
+		})
+	}
+}
+
+func TestCheckedOutHead(t *testing.T) {
+	tests := []struct {
+		name           string
+		branch         string
+		env            map[string]string
+		expectedBranch string
+		expectedNumber uint64
+		expectedErr    error
+	}{
+		{
+			name:           "branch",
+			branch:         "feature",
+			env:            map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_IID": "7"},
+			expectedBranch: "feature",
+		},
+		{
+			name:   "detached pull request build",
+			branch: "HEAD",
+			env: map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+			},
+			expectedBranch: "feature",
+			expectedNumber: 7,
+		},
+		{
+			name:           "detached branch build",
+			branch:         "HEAD",
+			env:            map[string]string{"GITLAB_CI": "true", "CI_COMMIT_BRANCH": "main"},
+			expectedBranch: "main",
+		},
+		{
+			name:        "detached outside of ci",
+			branch:      "HEAD",
+			env:         map[string]string{},
+			expectedErr: git.ErrDetachedHead,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gitp := mockery.NewMockGitProvider_git(t)
+			gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return(test.branch, nil)
+
+			branch, number, err := ci.CheckedOutHead(context.Background(), gitp, ci.NewEnvDetector(func(key string) string {
+				return test.env[key]
+			}))
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedBranch, branch)
+			assert.Equal(t, test.expectedNumber, number)
+		})
+	}
+}
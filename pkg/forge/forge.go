@@ -0,0 +1,94 @@
+package forge
+
+import (
+	"context"
+
+	"github.com/go-faster/errors"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/gitea"
+	"github.com/walteh/buildrc/pkg/github"
+	"github.com/walteh/buildrc/pkg/gitlab"
+)
+
+// Name is the kind of forge hosting a repository.
+type Name string
+
+const (
+	GitHub Name = "github"
+	GitLab Name = "gitlab"
+	Gitea  Name = "gitea"
+)
+
+// Forge is a hosted api implementing all of the remote provider interfaces.
+type Forge interface {
+	git.PullRequestProvider
+	git.ReleaseProvider
+	git.RemoteRepositoryMetadataProvider
+}
+
+// Options are the api options of the forge hosting a remote, only the options of Name are set.
+type Options struct {
+	Name   Name
+	GitHub *github.Options
+	GitLab *gitlab.Options
+	Gitea  *gitea.Options
+}
+
+// Detect returns the options of the forge hosting the remote, or false if the remote is not hosted on a
+// known forge. A non empty name skips the detection and returns the options of that forge.
+func Detect(remote *git.RemoteURL, name Name, getenv func(string) string) (*Options, bool) {
+	ghopts, isGitHub := github.DetectOptions(remote, getenv)
+	glopts, isGitLab := gitlab.DetectOptions(remote, getenv)
+	gtopts, isGitea := gitea.DetectOptions(remote, getenv)
+
+	// gitea is checked first, gitea actions sets the github actions variables github detection relies on
+	switch {
+	case name == Gitea || (name == "" && isGitea):
+		return &Options{Name: Gitea, Gitea: gtopts}, true
+	case name == GitHub || (name == "" && isGitHub):
+		return &Options{Name: GitHub, GitHub: ghopts}, true
+	case name == GitLab || (name == "" && isGitLab):
+		return &Options{Name: GitLab, GitLab: glopts}, true
+	default:
+		return nil, false
+	}
+}
+
+// NewClient returns the api client of the forge. The git provider is used to resolve the branch of HEAD,
+// the ci detector to resolve it when HEAD is detached.
+func (me *Options) NewClient(ctx context.Context, gitp git.GitProvider, det ci.Detector) (Forge, error) {
+	switch me.Name {
+	case Gitea:
+		opts := *me.Gitea
+		opts.CI = det
+		return gitea.NewClient(ctx, gitp, &opts)
+	case GitHub:
+		opts := *me.GitHub
+		opts.CI = det
+		return github.NewClient(ctx, gitp, &opts)
+	case GitLab:
+		opts := *me.GitLab
+		opts.CI = det
+		return gitlab.NewClient(ctx, gitp, &opts)
+	default:
+		return nil, errors.Errorf("unknown forge '%s'", me.Name)
+	}
+}
+
+// Credentials returns the username and password to use git over https with the token of the forge,
+// or empty strings if there is no token.
+func (me *Options) Credentials() (string, string) {
+	switch {
+	case me.Gitea != nil && me.Gitea.Token != "":
+		return "oauth2", me.Gitea.Token
+	case me.GitHub != nil && me.GitHub.Token != "":
+		return "x-access-token", me.GitHub.Token
+	case me.GitLab != nil && me.GitLab.Token != "":
+		return "oauth2", me.GitLab.Token
+	case me.GitLab != nil && me.GitLab.JobToken != "":
+		return "gitlab-ci-token", me.GitLab.JobToken
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,82 @@
+package forge_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/forge"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name         string
+		remote       string
+		forced       forge.Name
+		env          map[string]string
+		expected     forge.Name
+		ok           bool
+		wantUsername string
+		wantPassword string
+	}{
+		{
+			name:         "github.com",
+			remote:       "git@github.com:walteh/buildrc.git",
+			env:          map[string]string{"GITHUB_TOKEN": "ghtoken"},
+			expected:     forge.GitHub,
+			ok:           true,
+			wantUsername: "x-access-token",
+			wantPassword: "ghtoken",
+		},
+		{
+			name:         "gitea actions sets the github actions variables",
+			remote:       "https://gitea.example.com/walteh/buildrc.git",
+			env:          map[string]string{"GITEA_ACTIONS": "true", "GITHUB_SERVER_URL": "https://gitea.example.com", "GITEA_TOKEN": "gttoken"},
+			expected:     forge.Gitea,
+			ok:           true,
+			wantUsername: "oauth2",
+			wantPassword: "gttoken",
+		},
+		{
+			name:         "gitlab ci job token",
+			remote:       "https://gitlab.example.com/group/sub/buildrc.git",
+			env:          map[string]string{"CI_SERVER_HOST": "gitlab.example.com", "CI_JOB_TOKEN": "jobtoken"},
+			expected:     forge.GitLab,
+			ok:           true,
+			wantUsername: "gitlab-ci-token",
+			wantPassword: "jobtoken",
+		},
+		{
+			name:     "forced on an unknown host",
+			remote:   "https://git.example.com/walteh/buildrc.git",
+			forced:   forge.Gitea,
+			expected: forge.Gitea,
+			ok:       true,
+		},
+		{
+			name:   "unknown host",
+			remote: "https://git.example.com/walteh/buildrc.git",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			remote, err := git.ParseRemoteURL(test.remote)
+			require.NoError(t, err)
+
+			opts, ok := forge.Detect(remote, test.forced, func(k string) string { return test.env[k] })
+			require.Equal(t, test.ok, ok)
+			if !ok {
+				assert.Nil(t, opts)
+				return
+			}
+
+			assert.Equal(t, test.expected, opts.Name)
+
+			username, password := opts.Credentials()
+			assert.Equal(t, test.wantUsername, username)
+			assert.Equal(t, test.wantPassword, password)
+		})
+	}
+}
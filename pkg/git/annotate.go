@@ -0,0 +1,244 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog"
+)
+
+// maxTagMessageCommits caps how many commits are listed in a generated tag message.
+const maxTagMessageCommits = 100
+
+// CreateTagOptions configures an annotated tag. The tagger defaults to user.name and user.email
+// from the git config, or to the committer of the tagged commit if they are not set.
+type CreateTagOptions struct {
+	Message     string
+	TaggerName  string
+	TaggerEmail string
+	// Signer signs the tag when set
+	Signer TagSigner
+}
+
+// PushOptions configures a push. Username and Password are only used for http remotes,
+// ssh remotes authenticate through the ssh agent.
+type PushOptions struct {
+	// Remote is the name of the remote, defaulting to origin
+	Remote   string
+	Username string
+	Password string
+}
+
+func (me *PushOptions) remote() string {
+	if me == nil || me.Remote == "" {
+		return DefaultRemoteName
+	}
+	return me.Remote
+}
+
+// encodeTag builds the annotated tag object for the target commit, appending the signature if there is a signer.
+func encodeTag(name string, target string, tagger object.Signature, opts *CreateTagOptions) (*plumbing.MemoryObject, error) {
+
+	if opts == nil {
+		opts = &CreateTagOptions{}
+	}
+
+	if strings.TrimSpace(opts.Message) == "" {
+		return nil, errors.Errorf("tag '%s' needs a message", name)
+	}
+
+	if tagger.When.IsZero() {
+		now, err := Now()
+		if err != nil {
+			return nil, err
+		}
+		tagger.When = now
+	}
+
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     tagger,
+		Message:    strings.TrimSpace(opts.Message) + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     plumbing.NewHash(target),
+	}
+
+	obj := &plumbing.MemoryObject{}
+	if err := tag.Encode(obj); err != nil {
+		return nil, err
+	}
+
+	if opts.Signer == nil {
+		return obj, nil
+	}
+
+	rdr, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := opts.Signer.Sign(rdr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "signing tag '%s'", name)
+	}
+
+	tag.PGPSignature = string(sig)
+	if !strings.HasSuffix(tag.PGPSignature, "\n") {
+		tag.PGPSignature += "\n"
+	}
+
+	signed := &plumbing.MemoryObject{}
+	if err := tag.Encode(signed); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+func readEncodedObject(obj plumbing.EncodedObject) ([]byte, error) {
+	rdr, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+
+	return io.ReadAll(rdr)
+}
+
+// TagMessage summarizes the commits since the previous version, newest first, for an annotated tag.
+func TagMessage(tag string, previous string, commits []*Commit) string {
+	var b strings.Builder
+
+	b.WriteString(tag + "\n\n")
+
+	noun := "commits"
+	if len(commits) == 1 {
+		noun = "commit"
+	}
+
+	if previous == "" {
+		fmt.Fprintf(&b, "%d %s:\n\n", len(commits), noun)
+	} else {
+		fmt.Fprintf(&b, "%d %s since %s:\n\n", len(commits), noun, previous)
+	}
+
+	for i, c := range commits {
+		if i == maxTagMessageCommits {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(commits)-maxTagMessageCommits)
+			break
+		}
+		fmt.Fprintf(&b, "- %s %s\n", c.ShortHash(), strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0]))
+	}
+
+	return b.String()
+}
+
+// TagVersion creates an annotated tag of the version on HEAD, with a message summarizing the commits since
+// the previous semver tag, and pushes it if push is not nil. It refuses to tag a dirty worktree or a commit
+// that already has a release or a prerelease on the same channel, so a tagged prerelease can be promoted.
+func TagVersion(ctx context.Context, gitp GitProvider, vers *semver.Version, opts *CreateTagOptions, push *PushOptions) (*Tag, error) {
+
+	if gitp.Dirty(ctx) {
+		return nil, ErrDirtyWorktree
+	}
+
+	existing, err := gitp.TryGetSemverTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil && (existing.Prerelease() == "" || prereleaseChannel(existing) == prereleaseChannel(vers)) {
+		return nil, errors.Wrapf(ErrAlreadyTagged, "HEAD is tagged %s", existing.Original())
+	}
+
+	name := TagName(vers)
+
+	// a promoted prerelease is tagged on HEAD, so the previous version is looked up from its parent
+	from := "HEAD"
+	if existing != nil {
+		from = "HEAD^"
+	}
+
+	// without a previous version the message lists the whole history, other errors must not do the same
+	previous := ""
+	prev, err := gitp.GetLatestSemverTagFromRef(ctx, from)
+	if err != nil && !errors.Is(err, ErrNoSemverTags) {
+		return nil, err
+	}
+	if prev != nil {
+		previous = prev.Original()
+	}
+
+	if opts == nil {
+		opts = &CreateTagOptions{}
+	}
+
+	if opts.Message == "" {
+		commits, err := gitp.ListCommitsBetweenRefs(ctx, previous, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+
+		cp := *opts
+		cp.Message = TagMessage(name, previous, commits)
+		opts = &cp
+	}
+
+	tag, err := gitp.CreateTag(ctx, name, "HEAD", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("tag", tag.Name).Str("commit", tag.Commit).Bool("signed", opts.Signer != nil).Msg("created tag")
+
+	if push == nil {
+		return tag, nil
+	}
+
+	if err := gitp.PushTag(ctx, name, push); err != nil {
+		return nil, errors.Wrapf(err, "pushing tag '%s' to %s", name, push.remote())
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("tag", tag.Name).Str("remote", push.remote()).Msg("pushed tag")
+
+	return tag, nil
+}
+
+// prereleaseChannel returns the first prerelease identifier, like rc for 1.3.0-rc.2, or an empty string for a release.
+func prereleaseChannel(v *semver.Version) string {
+	channel, _, _ := strings.Cut(v.Prerelease(), ".")
+	return channel
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func isHTTPRemote(url string) bool {
+	url = strings.ToLower(url)
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
+
+// TagName returns the name of the tag for the version. Zero padded calendar versions like 26.01.0
+// keep their padding, which semver drops when the version is printed.
+func TagName(vers *semver.Version) string {
+	orig := strings.TrimPrefix(vers.Original(), "v")
+	core := strings.SplitN(strings.SplitN(orig, "+", 2)[0], "-", 2)[0]
+
+	if strings.Count(core, ".") == 2 {
+		return "v" + orig
+	}
+
+	return "v" + vers.String()
+}
@@ -0,0 +1,277 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+// newBareRemote creates a bare repository and adds it to the repo as the origin remote.
+func newBareRemote(t *testing.T, repo *testRepo) *gogit.Repository {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	bare, err := gogit.PlainInit(dir, true)
+	require.NoError(t, err)
+
+	repo.remote("origin", dir)
+
+	return bare
+}
+
+func remoteTag(t *testing.T, bare *gogit.Repository, name string) *object.Tag {
+	t.Helper()
+
+	ref, err := bare.Reference(plumbing.NewTagReferenceName(name), false)
+	require.NoError(t, err)
+
+	tag, err := bare.TagObject(ref.Hash())
+	require.NoError(t, err)
+
+	return tag
+}
+
+func TestTagVersion(t *testing.T) {
+	ctx := context.Background()
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			c1 := repo.commit("first", map[string]string{"a.txt": "a"})
+			repo.tag("v1.0.0", c1, true)
+			repo.commit("feat: second", map[string]string{"a.txt": "b"})
+			c3 := repo.commit("fix: third\n\nwith a body", map[string]string{"a.txt": "c"})
+
+			bare := newBareRemote(t, repo)
+
+			prov := repo.open(backend)
+
+			// builds the ref index before the tag exists
+			before, err := prov.TryGetSemverTag(ctx)
+			require.NoError(t, err)
+			require.Nil(t, before)
+
+			tag, err := git.TagVersion(ctx, prov, semver.MustParse("1.1.0"), &git.CreateTagOptions{
+				TaggerName:  "releaser",
+				TaggerEmail: "releaser@example.com",
+			}, &git.PushOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, &git.Tag{Name: "v1.1.0", Commit: c3, Annotated: true}, tag)
+
+			pushed := remoteTag(t, bare, "v1.1.0")
+			assert.Equal(t, c3, pushed.Target.String())
+			assert.Equal(t, "releaser", pushed.Tagger.Name)
+			assert.Equal(t, "releaser@example.com", pushed.Tagger.Email)
+			assert.Contains(t, pushed.Message, "2 commits since v1.0.0:")
+			assert.Contains(t, pushed.Message, "- "+c3[:7]+" fix: third\n")
+			assert.NotContains(t, pushed.Message, "with a body")
+			assert.Empty(t, pushed.PGPSignature)
+
+			// the ref index is invalidated, so the new tag is seen
+			after, err := prov.TryGetSemverTag(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, after)
+			assert.Equal(t, "v1.1.0", after.Original())
+
+			_, err = git.TagVersion(ctx, prov, semver.MustParse("1.1.1"), nil, nil)
+			assert.ErrorIs(t, err, git.ErrAlreadyTagged)
+
+			_, err = prov.CreateTag(ctx, "v1.1.0", c1, &git.CreateTagOptions{Message: "again"})
+			assert.ErrorIs(t, err, git.ErrTagExists)
+		})
+	}
+}
+
+func TestTagVersionPromotesPrerelease(t *testing.T) {
+	ctx := context.Background()
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			c1 := repo.commit("first", map[string]string{"a.txt": "a"})
+			repo.tag("v1.0.0", c1, true)
+			c2 := repo.commit("feat: second", map[string]string{"a.txt": "b"})
+			repo.tag("v1.1.0-rc.1", c2, true)
+
+			prov := repo.open(backend)
+
+			_, err := git.TagVersion(ctx, prov, semver.MustParse("1.1.0-rc.2"), nil, nil)
+			assert.ErrorIs(t, err, git.ErrAlreadyTagged, "a prerelease on the same channel")
+
+			tag, err := git.TagVersion(ctx, prov, semver.MustParse("1.1.0"), nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, &git.Tag{Name: "v1.1.0", Commit: c2, Annotated: true}, tag)
+
+			// the summary starts from before the promoted prerelease
+			assert.Contains(t, remoteTag(t, repo.repo, "v1.1.0").Message, "1 commit since v1.0.0:")
+
+			_, err = git.TagVersion(ctx, prov, semver.MustParse("1.1.1"), nil, nil)
+			assert.ErrorIs(t, err, git.ErrAlreadyTagged, "a release")
+		})
+	}
+}
+
+func TestCreateTagNilOptions(t *testing.T) {
+	ctx := context.Background()
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			c1 := repo.commit("first", nil)
+
+			var tag *git.Tag
+			var err error
+			require.NotPanics(t, func() {
+				tag, err = repo.open(backend).CreateTag(ctx, "v1.0.0", c1, nil)
+			})
+			assert.ErrorContains(t, err, "needs a message")
+			assert.Nil(t, tag)
+		})
+	}
+}
+
+func TestTagVersionDirty(t *testing.T) {
+	ctx := context.Background()
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			repo.commit("first", map[string]string{"a.txt": "a"})
+
+			require.NoError(t, os.WriteFile(filepath.Join(repo.dir, "a.txt"), []byte("changed"), 0600))
+
+			_, err := git.TagVersion(ctx, repo.open(backend), semver.MustParse("1.0.0"), nil, nil)
+			assert.ErrorIs(t, err, git.ErrDirtyWorktree)
+		})
+	}
+}
+
+func TestTagVersionKeepsTagErrors(t *testing.T) {
+	ctx := context.Background()
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().Dirty(mock.Anything).Return(false)
+	gitp.EXPECT().TryGetSemverTag(mock.Anything).Return(nil, nil)
+	gitp.EXPECT().GetLatestSemverTagFromRef(mock.Anything, "HEAD").Return(nil, git.ErrRefNotFound)
+
+	_, err := git.TagVersion(ctx, gitp, semver.MustParse("1.0.0"), nil, nil)
+	assert.ErrorIs(t, err, git.ErrRefNotFound)
+}
+
+func TestTagVersionGPGSigned(t *testing.T) {
+	ctx := context.Background()
+
+	entity, err := openpgp.NewEntity("releaser", "", "releaser@example.com", nil)
+	require.NoError(t, err)
+
+	var private bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+
+	var public bytes.Buffer
+	w, err = armor.Encode(&public, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	signer, err := git.NewTagSigner(private.Bytes(), nil)
+	require.NoError(t, err)
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			repo.commit("first", map[string]string{"a.txt": "a"})
+
+			bare := newBareRemote(t, repo)
+
+			_, err := git.TagVersion(ctx, repo.open(backend), semver.MustParse("0.1.0"), &git.CreateTagOptions{Signer: signer}, &git.PushOptions{Remote: "origin"})
+			require.NoError(t, err)
+
+			pushed := remoteTag(t, bare, "v0.1.0")
+			assert.Contains(t, pushed.PGPSignature, "-----BEGIN PGP SIGNATURE-----")
+
+			_, err = pushed.Verify(public.String())
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTagVersionSSHSigned(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+
+	dir := t.TempDir()
+	keyfile := filepath.Join(dir, "id_ed25519")
+	out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "releaser@example.com", "-f", keyfile).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	key, err := os.ReadFile(keyfile)
+	require.NoError(t, err)
+	pub, err := os.ReadFile(keyfile + ".pub")
+	require.NoError(t, err)
+
+	allowed := filepath.Join(dir, "allowed_signers")
+	require.NoError(t, os.WriteFile(allowed, append([]byte("releaser@example.com "), pub...), 0600))
+
+	signer, err := git.NewTagSigner(key, nil)
+	require.NoError(t, err)
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			repo := newTestRepo(t)
+			repo.commit("first", map[string]string{"a.txt": "a"})
+
+			_, err := git.TagVersion(ctx, repo.open(backend), semver.MustParse("0.1.0"), &git.CreateTagOptions{Signer: signer}, nil)
+			require.NoError(t, err)
+
+			cmd := exec.Command("git", "-c", "gpg.ssh.allowedSignersFile="+allowed, "verify-tag", "v0.1.0")
+			cmd.Dir = repo.dir
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			assert.Contains(t, string(out), `Good "git" signature for releaser@example.com`)
+		})
+	}
+}
+
+func TestNewTagSignerInvalidKey(t *testing.T) {
+	_, err := git.NewTagSigner([]byte("not a key"), nil)
+	assert.ErrorIs(t, err, git.ErrInvalidSigningKey)
+}
+
+func TestTagName(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3":            "v1.2.3",
+		"v1.2.3-pr.1+abc":  "v1.2.3-pr.1+abc",
+		"1.2":              "v1.2.0",
+		"26.01.0":          "v26.01.0",
+		"v2026.10.3-pr.12": "v2026.10.3-pr.12",
+	}
+
+	for raw, expected := range tests {
+		t.Run(raw, func(t *testing.T) {
+			assert.Equal(t, expected, git.TagName(semver.MustParse(raw)))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"io/fs"
+	"os"
 	"path/filepath"
 
 	"github.com/go-git/go-billy/v5"
@@ -41,8 +42,17 @@ func (me *AferoBillyFs) Chroot(path string) (billy.Filesystem, error) {
 	return NewAferoBillyFs(me.Fs(), path), nil
 }
 
+// createDir creates the parent directory of the file, like the billy os filesystem does
+// before creating or renaming a file.
+func (me *AferoBillyFs) createDir(filename string) error {
+	return me.Fs().MkdirAll(filepath.Dir(filename), 0755)
+}
+
 // Create implements billy.Filesystem.
 func (me *AferoBillyFs) Create(filename string) (billy.File, error) {
+	if err := me.createDir(filename); err != nil {
+		return nil, err
+	}
 	fle, err := me.Fs().Create(filename)
 	if err != nil {
 		return nil, err
@@ -71,6 +81,11 @@ func (me *AferoBillyFs) Open(filename string) (billy.File, error) {
 
 // OpenFile implements billy.Filesystem.
 func (me *AferoBillyFs) OpenFile(filename string, flag int, perm fs.FileMode) (billy.File, error) {
+	if flag&os.O_CREATE != 0 {
+		if err := me.createDir(filename); err != nil {
+			return nil, err
+		}
+	}
 	fle, err := me.Fs().OpenFile(filename, flag, perm)
 	if err != nil {
 		return nil, err
@@ -83,6 +98,14 @@ func (me *AferoBillyFs) ReadDir(path string) ([]fs.FileInfo, error) {
 	return afero.ReadDir(me.Fs(), path)
 }
 
+// Rename implements billy.Filesystem.
+func (me *AferoBillyFs) Rename(oldpath string, newpath string) error {
+	if err := me.createDir(newpath); err != nil {
+		return err
+	}
+	return me.Fs().Rename(oldpath, newpath)
+}
+
 // Readlink implements billy.Filesystem.
 func (me *AferoBillyFs) Readlink(link string) (string, error) {
 	p, err := me.Fs().ReadlinkIfPossible(link)
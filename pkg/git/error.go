@@ -8,4 +8,15 @@ var (
 	ErrNoGitProvider GitError = GitError(errors.Errorf("no git provider found"))
 	ErrNoMatchingPR  GitError = GitError(errors.Errorf("no matching PR found"))
 	ErrRefNotFound   GitError = GitError(errors.Errorf("ref not found"))
+	ErrDetachedHead  GitError = GitError(errors.Errorf("HEAD is detached"))
+	ErrNoSemverTags  GitError = GitError(errors.Errorf("no semver tags found"))
+
+	ErrNotAGitRepository GitError = GitError(errors.Errorf("not a git repository"))
+	ErrInvalidRemoteURL  GitError = GitError(errors.Errorf("invalid remote url"))
+	ErrNoForge           GitError = GitError(errors.Errorf("release needs a forge (github, gitlab or gitea)"))
+
+	ErrDirtyWorktree     GitError = GitError(errors.Errorf("worktree has uncommitted changes"))
+	ErrAlreadyTagged     GitError = GitError(errors.Errorf("commit already has a semver tag"))
+	ErrTagExists         GitError = GitError(errors.Errorf("tag already exists"))
+	ErrInvalidSigningKey GitError = GitError(errors.Errorf("invalid signing key"))
 )
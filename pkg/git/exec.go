@@ -1,104 +1,716 @@
 package git
 
-// var _ GitProvider = (*ExecGitProvider)(nil)
-
-// type ExecGitProvider struct {
-// }
-
-// func NewExecGitProvider() *ExecGitProvider {
-// 	return &ExecGitProvider{}
-// }
-
-// func (me *ExecGitProvider) GetContentHash(ctx context.Context) (string, error) {
-
-// 	resolved, err := resolveCommitHash(ctx, "HEAD")
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	out, err := exec.Command("bash", "-c", fmt.Sprintf("git ls-tree -r %s | sha256sum", resolved)).Output()
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	// Output is a byte slice, we convert it to string
-// 	// Also, sha256sum outputs the hash followed by a '-', we only want the hash
-// 	sha256sum := strings.Fields(string(out))[0]
-
-// 	return sha256sum, nil
-// }
-
-// func resolveCommitHash(ctx context.Context, sha string) (string, error) {
-
-// 	out, err := exec.Command("bash", "-c", fmt.Sprintf("git rev-parse %s", sha)).Output()
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	// Output is a byte slice, we convert it to string
-// 	// Also, sha256sum outputs the hash followed by a '-', we only want the hash
-// 	sha256sum := strings.Fields(string(out))[0]
-
-// 	return sha256sum, nil
-// }
-
-// func (me *ExecGitProvider) GetCurrentCommitHash(ctx context.Context) (string, error) {
-// 	return resolveCommitHash(ctx, "HEAD")
-// }
-
-// func (me *ExecGitProvider) GetCurrentBranch(ctx context.Context) (string, error) {
-// 	out, err := exec.Command("bash", "-c", "git branch --show-current").Output()
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	return strings.TrimSpace(string(out)), nil
-// }
-
-// func (me *ExecGitProvider) GetLatestSemverTagFromRef(ctx context.Context, ref string) (*semver.Version, error) {
-
-// 	resolved, err := resolveCommitHash(ctx, ref)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	cmd := exec.Command("git", "tag", "--merged", resolved)
-// 	output, err := cmd.Output()
-// 	if err != nil {
-// 		return nil, errors.Errorf("failed to execute git command: %v", err)
-// 	}
-
-// 	// Parse the output
-// 	tags := strings.Split(string(output), "\n")
-// 	var versions []*semver.Version
-
-// 	for _, tag := range tags {
-// 		// Skip empty lines
-// 		if len(tag) == 0 {
-// 			continue
-// 		}
-
-// 		// Attempt to parse each tag as a semver version
-// 		ver, err := semver.NewVersion(tag)
-// 		if err != nil {
-// 			return nil, errors.Errorf("failed to parse tag '%s' as semver: %v", tag, err)
-// 		}
-// 		versions = append(versions, ver)
-// 	}
-
-// 	// Return error if no semver tags found
-// 	if len(versions) == 0 {
-// 		zerolog.Ctx(ctx).Warn().Strs("tags", tags).Str("commit", resolved).Str("output", string(output)).Msg("no semver tags found")
-// 		return nil, errors.Errorf("no semver tags found from ref '%s'", ref)
-// 	}
-
-// 	// Sort the versions in descending order
-// 	sort.Sort(sort.Reverse(semver.Collection(versions)))
-
-// 	// Return the latest version
-// 	return versions[0], nil
-// }
-
-// func (me *ExecGitProvider) GetLocalRepositoryMetadata(ctx context.Context) (*LocalRepositoryMetadata, error) {
-// 	panic("implement me")
-// }
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+var _ GitProvider = (*ExecGitProvider)(nil)
+
+// ExecGitProvider is a GitProvider that shells out to the git executable. It supports
+// repositories go-git cannot read, such as partial clones and sparse checkouts.
+// It is safe for concurrent use.
+type ExecGitProvider struct {
+	dir  string
+	root afero.Fs
+
+	mu    sync.Mutex
+	index *refIndex
+}
+
+func NewExecGitProvider(afo afero.Fs, dir string) (*ExecGitProvider, error) {
+
+	if !filepath.IsAbs(dir) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		dir = abs
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.Wrap(err, "git executable not found")
+	}
+
+	prov := &ExecGitProvider{
+		dir:  dir,
+		root: afero.NewBasePathFs(afo, dir),
+	}
+
+	if _, err := prov.git(context.Background(), nil, "rev-parse", "--git-dir"); err != nil {
+		return nil, errors.Wrapf(ErrNotAGitRepository, "%v", err)
+	}
+
+	// GIT_WORK_TREE may point somewhere other than dir
+	if top, err := prov.git(context.Background(), nil, "rev-parse", "--show-toplevel"); err == nil && strings.TrimSpace(top) != "" {
+		prov.root = afero.NewBasePathFs(afo, strings.TrimSpace(top))
+	}
+
+	return prov, nil
+}
+
+func (me *ExecGitProvider) Fs() afero.Fs {
+	return me.root
+}
+
+// git runs a git command in the repository directory and returns its stdout.
+func (me *ExecGitProvider) git(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	return me.gitEnv(ctx, nil, stdin, args...)
+}
+
+// gitEnv runs a git command like git, with extra environment variables.
+func (me *ExecGitProvider) gitEnv(ctx context.Context, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = me.dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C"), env...)
+	cmd.Stdin = stdin
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		zerolog.Ctx(ctx).Trace().Strs("args", args).Str("stderr", stderr.String()).Msg("git command failed")
+		return "", errors.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
+
+// candidateRefs mirrors the go-git provider, which falls back to the origin remote for missing branches.
+func candidateRefs(ref string) []string {
+	switch ref {
+	case "master", "main":
+		ref = "refs/heads/" + ref
+	}
+
+	candidates := []string{ref}
+	if strings.Contains(ref, "heads") {
+		candidates = append(candidates, strings.Replace(ref, "heads", "remotes/origin", 1))
+	}
+
+	return candidates
+}
+
+// resolve returns the commit hash a ref points to, peeling annotated tags.
+func (me *ExecGitProvider) resolve(ctx context.Context, ref string) (string, error) {
+	for _, candidate := range candidateRefs(ref) {
+		out, err := me.git(ctx, nil, "rev-parse", "--verify", "--quiet", "--end-of-options", candidate+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(out), nil
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("ref", ref).Msg("ref not found")
+
+	return "", ErrRefNotFound
+}
+
+func (me *ExecGitProvider) GetContentHashFromRef(ctx context.Context, ref string) (string, error) {
+	hash, err := me.resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := me.git(ctx, nil, "rev-parse", "--verify", hash+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+func (me *ExecGitProvider) GetCurrentCommitFromRef(ctx context.Context, ref string) (string, error) {
+	return me.resolve(ctx, ref)
+}
+
+func (me *ExecGitProvider) GetCommitFromRef(ctx context.Context, ref string) (*Commit, error) {
+	hash, err := me.resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := me.readCommits(ctx, []string{hash})
+	if err != nil {
+		return nil, err
+	}
+
+	return commits[0], nil
+}
+
+func (me *ExecGitProvider) GetCurrentShortHashFromRef(ctx context.Context, ref string) (string, error) {
+	commitHash, err := me.GetCurrentCommitFromRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return commitHash[:7], nil
+}
+
+func (me *ExecGitProvider) GetCurrentCommitMessageFromRef(ctx context.Context, ref string) (string, error) {
+	hash, err := me.resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	commits, err := me.readCommits(ctx, []string{hash})
+	if err != nil {
+		return "", err
+	}
+
+	return commits[0].Message, nil
+}
+
+func (me *ExecGitProvider) GetCurrentBranchFromRef(ctx context.Context, ref string) (string, error) {
+	if _, err := me.resolve(ctx, ref); err != nil {
+		return "", err
+	}
+
+	if _, err := hex.DecodeString(ref); err == nil && len(ref) == 40 {
+		return ref, nil
+	}
+
+	if ref == "HEAD" {
+		out, err := me.git(ctx, nil, "symbolic-ref", "-q", "HEAD")
+		if err != nil {
+			// detached head
+			return "HEAD", nil
+		}
+		return plumbing.ReferenceName(strings.TrimSpace(out)).Short(), nil
+	}
+
+	for _, candidate := range candidateRefs(ref) {
+		out, err := me.git(ctx, nil, "rev-parse", "--verify", "--quiet", "--symbolic-full-name", "--end-of-options", candidate)
+		if err == nil && strings.TrimSpace(out) != "" {
+			return plumbing.ReferenceName(strings.TrimSpace(out)).Short(), nil
+		}
+	}
+
+	return ref, nil
+}
+
+// refs returns the ref index, building it on first use.
+func (me *ExecGitProvider) refs(ctx context.Context) (*refIndex, error) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if me.index != nil {
+		return me.index, nil
+	}
+
+	entries, err := me.forEachRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := newRefIndex()
+	for _, e := range entries {
+		index.add(e.commit, e.name)
+	}
+	index.sort()
+
+	zerolog.Ctx(ctx).Debug().Int("commits", len(index.names)).Int("tagged", len(index.semvers)).Msg("built ref index")
+
+	me.index = index
+
+	return index, nil
+}
+
+type execRef struct {
+	name      plumbing.ReferenceName
+	commit    string
+	annotated bool
+}
+
+// forEachRef lists the refs matching the patterns that point to a commit, peeling annotated tags.
+func (me *ExecGitProvider) forEachRef(ctx context.Context, patterns ...string) ([]*execRef, error) {
+	args := append([]string{"for-each-ref", "--format=%(refname)%00%(objecttype)%00%(objectname)%00%(*objecttype)%00%(*objectname)"}, patterns...)
+
+	out, err := me.git(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []*execRef{}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+
+		switch {
+		case fields[1] == "commit":
+			refs = append(refs, &execRef{name: plumbing.ReferenceName(fields[0]), commit: fields[2]})
+		case fields[1] == "tag" && fields[3] == "commit":
+			refs = append(refs, &execRef{name: plumbing.ReferenceName(fields[0]), commit: fields[4], annotated: true})
+		default:
+			zerolog.Ctx(ctx).Debug().Str("ref", fields[0]).Msg("ref does not point to a commit, skipping")
+		}
+	}
+
+	return refs, nil
+}
+
+func (me *ExecGitProvider) GetLatestSemverTagFromRef(ctx context.Context, ref string) (*semver.Version, error) {
+	hash, err := me.resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := me.refs(ctx)
+	if err != nil {
+		return nil, errors.Errorf("failed to iterate over tags: %v", err)
+	}
+
+	// commits missing from the output, such as the parents of a shallow boundary, have no parents
+	out, err := me.git(ctx, nil, "rev-list", "--parents", hash)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		graph[fields[0]] = fields[1:]
+	}
+
+	latestSemver, err := findNearestSemverTag(hash, index, func(h string) ([]string, error) {
+		return graph[h], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if latestSemver == nil {
+		zerolog.Ctx(ctx).Warn().Int("tagged", len(index.semvers)).Msgf("no semver tags found from ref '%s'", ref)
+		return nil, errors.Wrapf(ErrNoSemverTags, "from ref '%s'", ref)
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("semver", latestSemver.String()).Msgf("latest semver tag from ref '%s'", ref)
+	return latestSemver, nil
+}
+
+// ListCommitsBetweenRefs returns the commits reachable from 'to' that are not reachable from 'from',
+// newest first. If 'from' is empty, every commit reachable from 'to' is returned.
+func (me *ExecGitProvider) ListCommitsBetweenRefs(ctx context.Context, from string, to string) ([]*Commit, error) {
+	toHash, err := me.resolve(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"rev-list", "--date-order", toHash}
+
+	if from != "" {
+		fromHash, err := me.resolve(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--not", fromHash)
+	}
+
+	out, err := me.git(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := strings.Fields(out)
+
+	commits, err := me.readCommits(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("from", from).Str("to", to).Int("commits", len(commits)).Msg("listed commits between refs")
+
+	return commits, nil
+}
+
+// readCommits reads and parses the raw commit objects in a single git process.
+func (me *ExecGitProvider) readCommits(ctx context.Context, hashes []string) ([]*Commit, error) {
+	commits := make([]*Commit, 0, len(hashes))
+
+	if len(hashes) == 0 {
+		return commits, nil
+	}
+
+	out, err := me.git(ctx, strings.NewReader(strings.Join(hashes, "\n")+"\n"), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	rdr := bufio.NewReader(strings.NewReader(out))
+
+	for range hashes {
+		header, err := rdr.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) != 3 || fields[1] != "commit" {
+			return nil, errors.Errorf("unexpected cat-file output %q", strings.TrimSpace(header))
+		}
+
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		body := make([]byte, size+1)
+		if _, err := io.ReadFull(rdr, body); err != nil {
+			return nil, err
+		}
+
+		commit, err := parseRawCommit(fields[0], string(body[:size]))
+		if err != nil {
+			return nil, err
+		}
+
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+func parseRawCommit(hash string, raw string) (*Commit, error) {
+	headers, message, _ := strings.Cut(raw, "\n\n")
+
+	commit := &Commit{
+		Hash:    hash,
+		Parents: []string{},
+		Message: message,
+	}
+
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "parent":
+			commit.Parents = append(commit.Parents, value)
+		case "author":
+			name, email, when, err := parseRawSignature(value)
+			if err != nil {
+				return nil, err
+			}
+			commit.Author = name
+			commit.AuthorEmail = email
+			commit.Date = when
+		case "committer":
+			_, _, when, err := parseRawSignature(value)
+			if err != nil {
+				return nil, err
+			}
+			commit.CommitDate = when
+		}
+	}
+
+	return commit, nil
+}
+
+// parseRawSignature parses 'Name <email> 1690000000 +0000'.
+func parseRawSignature(sig string) (string, string, time.Time, error) {
+	open := strings.LastIndex(sig, "<")
+	closing := strings.LastIndex(sig, ">")
+	if open < 0 || closing < open {
+		return "", "", time.Time{}, errors.Errorf("invalid signature %q", sig)
+	}
+
+	name := strings.TrimSpace(sig[:open])
+	email := sig[open+1 : closing]
+
+	fields := strings.Fields(sig[closing+1:])
+	if len(fields) != 2 {
+		return "", "", time.Time{}, errors.Errorf("invalid signature %q", sig)
+	}
+
+	secs, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	tz, err := time.Parse("-0700", fields[1])
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return name, email, time.Unix(secs, 0).In(tz.Location()), nil
+}
+
+// ListTags returns every tag in the repository along with the commit it points to.
+// Annotated tags are peeled to their target commit, tags that do not point to a commit are skipped.
+func (me *ExecGitProvider) ListTags(ctx context.Context) ([]*Tag, error) {
+	refs, err := me.forEachRef(ctx, "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]*Tag, 0, len(refs))
+	for _, ref := range refs {
+		tags = append(tags, &Tag{
+			Name:      ref.name.Short(),
+			Commit:    ref.commit,
+			Annotated: ref.annotated,
+		})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// ListChangedFilesBetweenRefs returns the sorted paths that differ between the trees of 'from' and 'to'.
+// Both sides of a rename are included. If 'from' is empty, every file in 'to' is returned.
+func (me *ExecGitProvider) ListChangedFilesBetweenRefs(ctx context.Context, from string, to string) ([]string, error) {
+	toHash, err := me.resolve(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"ls-tree", "-r", "-z", "--name-only", toHash}
+
+	if from != "" {
+		fromHash, err := me.resolve(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"diff", "--name-only", "--no-renames", "--no-ext-diff", "-z", fromHash, toHash}
+	}
+
+	out, err := me.git(ctx, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, name)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (me *ExecGitProvider) TryGetPRNumber(ctx context.Context) (uint64, error) {
+	hash, err := me.resolve(ctx, "HEAD")
+	if err != nil {
+		return 0, err
+	}
+
+	index, err := me.refs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return prNumberFromRefNames(ctx, index.names[hash])
+}
+
+func (me *ExecGitProvider) TryGetSemverTag(ctx context.Context) (*semver.Version, error) {
+	hash, err := me.resolve(ctx, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := me.refs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.latestSemver(hash), nil
+}
+
+// GetRemoteURL returns the url of the named remote, or of the default remote if name is empty.
+func (me *ExecGitProvider) GetRemoteURL(ctx context.Context, name string) (string, error) {
+	urls, err := me.remoteURLs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return selectRemote(name, urls)
+}
+
+func (me *ExecGitProvider) GetLocalRepositoryMetadata(ctx context.Context) (*LocalRepositoryMetadata, error) {
+	urls, err := me.remoteURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteURL, err := selectRemote("", urls)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLocalRepositoryMetadata(remoteURL)
+}
+
+// remoteURLs maps the name of every configured remote to its first url.
+func (me *ExecGitProvider) remoteURLs(ctx context.Context) (map[string]string, error) {
+	urls := map[string]string{}
+
+	// exits 1 when no remotes are configured
+	out, err := me.git(ctx, nil, "config", "--get-regexp", `^remote\..*\.url$`)
+	if err != nil {
+		return urls, nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".url")
+		if _, ok := urls[name]; !ok {
+			urls[name] = value
+		}
+	}
+
+	return urls, nil
+}
+
+func (me *ExecGitProvider) Dirty(ctx context.Context) bool {
+	out, err := me.git(ctx, nil, "status", "--porcelain")
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(out) != ""
+}
+
+// ListDirtyFiles returns the sorted paths of the tracked files that differ from HEAD, staged or not.
+// Untracked files are ignored.
+func (me *ExecGitProvider) ListDirtyFiles(ctx context.Context) ([]string, error) {
+	// without renames a rename is listed as the deleted and the added path
+	out, err := me.git(ctx, nil, "status", "--porcelain=v1", "-z", "--untracked-files=no", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, entry := range strings.Split(out, "\x00") {
+		// XY <path>
+		if len(entry) > 3 {
+			paths = append(paths, entry[3:])
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// CreateTag creates an annotated tag of the commit ref points to with git mktag. The ref index is rebuilt on next use.
+func (me *ExecGitProvider) CreateTag(ctx context.Context, name string, ref string, opts *CreateTagOptions) (*Tag, error) {
+	if opts == nil {
+		opts = &CreateTagOptions{}
+	}
+
+	hash, err := me.resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	refname := plumbing.NewTagReferenceName(name).String()
+
+	if _, err := me.git(ctx, nil, "rev-parse", "--verify", "--quiet", "--end-of-options", refname); err == nil {
+		return nil, errors.Wrapf(ErrTagExists, "'%s'", name)
+	}
+
+	// exits 1 when the key is not set
+	userName, _ := me.git(ctx, nil, "config", "user.name")
+	userEmail, _ := me.git(ctx, nil, "config", "user.email")
+
+	committer, err := me.git(ctx, nil, "log", "-1", "--format=%cn%x00%ce", hash)
+	if err != nil {
+		return nil, err
+	}
+	committerName, committerEmail, _ := strings.Cut(strings.TrimSpace(committer), "\x00")
+
+	tagger := object.Signature{
+		Name:  firstNonEmpty(opts.TaggerName, strings.TrimSpace(userName), committerName),
+		Email: firstNonEmpty(opts.TaggerEmail, strings.TrimSpace(userEmail), committerEmail),
+	}
+
+	obj, err := encodeTag(name, hash, tagger, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := readEncodedObject(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := me.git(ctx, bytes.NewReader(raw), "mktag")
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty old value makes the update fail if the tag was created in the meantime
+	if _, err := me.git(ctx, nil, "update-ref", refname, strings.TrimSpace(out), ""); err != nil {
+		return nil, err
+	}
+
+	me.mu.Lock()
+	me.index = nil
+	me.mu.Unlock()
+
+	return &Tag{Name: name, Commit: hash, Annotated: true}, nil
+}
+
+// PushTag pushes the tag to the remote. Credentials are passed through the environment so
+// they do not show up in the process list.
+func (me *ExecGitProvider) PushTag(ctx context.Context, name string, opts *PushOptions) error {
+	urls, err := me.remoteURLs(ctx)
+	if err != nil {
+		return err
+	}
+
+	url, ok := urls[opts.remote()]
+	if !ok {
+		return errors.Errorf("remote '%s' not found", opts.remote())
+	}
+
+	env := []string{}
+	if opts != nil && opts.Password != "" && isHTTPRemote(url) {
+		creds := base64.StdEncoding.EncodeToString([]byte(opts.Username + ":" + opts.Password))
+		env = append(env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=Authorization: Basic "+creds)
+	}
+
+	refname := plumbing.NewTagReferenceName(name).String()
+
+	_, err = me.gitEnv(ctx, env, nil, "push", "--porcelain", opts.remote(), refname+":"+refname)
+
+	return err
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/spf13/afero"
+)
+
+// gitLayout holds the absolute paths of a repository. GitDir and CommonDir differ for linked
+// worktrees, where refs and objects live in the main repository and HEAD in the worktree's own git dir.
+type gitLayout struct {
+	WorkTree  string
+	GitDir    string
+	CommonDir string
+}
+
+// resolveGitLayout finds the git dir for the work tree at dir. It honors the GIT_DIR, GIT_WORK_TREE
+// and GIT_COMMON_DIR environment variables, '.git' files containing 'gitdir: <path>' as created for
+// submodules and 'git worktree add', and the 'commondir' file of linked worktrees.
+func resolveGitLayout(afo afero.Fs, dir string) (*gitLayout, error) {
+	abs := func(base, path string) string {
+		path = strings.TrimSpace(path)
+		if filepath.IsAbs(path) {
+			return filepath.Clean(path)
+		}
+		return filepath.Join(base, path)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &gitLayout{WorkTree: abs(cwd, dir)}
+
+	if env := os.Getenv("GIT_WORK_TREE"); env != "" {
+		layout.WorkTree = abs(cwd, env)
+	}
+
+	if env := os.Getenv("GIT_DIR"); env != "" {
+		layout.GitDir = abs(cwd, env)
+	} else {
+		dotgit := filepath.Join(layout.WorkTree, ".git")
+
+		info, err := afo.Stat(dotgit)
+		if err != nil {
+			return nil, errors.Wrapf(ErrNotAGitRepository, "%s", layout.WorkTree)
+		}
+
+		if info.IsDir() {
+			layout.GitDir = dotgit
+		} else {
+			byt, err := afero.ReadFile(afo, dotgit)
+			if err != nil {
+				return nil, err
+			}
+
+			gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(byt)), "gitdir:")
+			if !ok {
+				return nil, errors.Wrapf(ErrNotAGitRepository, "invalid gitdir file %s", dotgit)
+			}
+
+			layout.GitDir = abs(layout.WorkTree, gitdir)
+		}
+	}
+
+	layout.CommonDir = layout.GitDir
+
+	if env := os.Getenv("GIT_COMMON_DIR"); env != "" {
+		layout.CommonDir = abs(cwd, env)
+	} else if byt, err := afero.ReadFile(afo, filepath.Join(layout.GitDir, "commondir")); err == nil {
+		layout.CommonDir = abs(layout.GitDir, string(byt))
+	}
+
+	for _, d := range []string{layout.GitDir, layout.CommonDir} {
+		if ok, err := afero.DirExists(afo, d); err != nil || !ok {
+			return nil, errors.Wrapf(ErrNotAGitRepository, "git dir %s does not exist", d)
+		}
+	}
+
+	return layout, nil
+}
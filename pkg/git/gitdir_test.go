@@ -0,0 +1,118 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestGitProviderRepositoryLayouts(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// setup returns the directory to open the provider in
+		setup          func(t *testing.T, repo *testRepo) string
+		expectedBranch string
+	}{
+		{
+			name: "plain repository",
+			setup: func(t *testing.T, repo *testRepo) string {
+				return repo.dir
+			},
+			expectedBranch: "main",
+		},
+		{
+			name: "linked worktree",
+			setup: func(t *testing.T, repo *testRepo) string {
+				wt := filepath.Join(t.TempDir(), "wt")
+				runGit(t, repo.dir, "worktree", "add", "-b", "feature", wt, "main")
+				return wt
+			},
+			expectedBranch: "feature",
+		},
+		{
+			name: "gitdir file",
+			setup: func(t *testing.T, repo *testRepo) string {
+				// the layout git uses for submodules, with the git dir absorbed into the superproject
+				modules := filepath.Join(t.TempDir(), "modules")
+				require.NoError(t, os.MkdirAll(modules, 0755))
+				require.NoError(t, os.Rename(filepath.Join(repo.dir, ".git"), filepath.Join(modules, "sub")))
+				rel, err := filepath.Rel(repo.dir, filepath.Join(modules, "sub"))
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(filepath.Join(repo.dir, ".git"), []byte("gitdir: "+rel+"\n"), 0600))
+				return repo.dir
+			},
+			expectedBranch: "main",
+		},
+		{
+			name: "git dir and work tree from the environment",
+			setup: func(t *testing.T, repo *testRepo) string {
+				t.Setenv("GIT_DIR", filepath.Join(repo.dir, ".git"))
+				t.Setenv("GIT_WORK_TREE", repo.dir)
+				return t.TempDir()
+			},
+			expectedBranch: "main",
+		},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			t.Run(backend+"/"+test.name, func(t *testing.T) {
+				repo := newTestRepo(t)
+				c1 := repo.commit("first", map[string]string{"a.txt": "a"})
+				repo.tag("v1.0.0", c1, true)
+
+				dir := test.setup(t, repo)
+
+				prov, err := git.NewGitProvider(afero.NewOsFs(), dir, backend)
+				require.NoError(t, err)
+
+				head, err := prov.GetCurrentCommitFromRef(ctx, "HEAD")
+				require.NoError(t, err)
+				assert.Equal(t, c1, head)
+
+				branch, err := prov.GetCurrentBranchFromRef(ctx, "HEAD")
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedBranch, branch)
+
+				v, err := prov.GetLatestSemverTagFromRef(ctx, "HEAD")
+				require.NoError(t, err)
+				assert.Equal(t, "1.0.0", v.String())
+
+				byt, err := afero.ReadFile(prov.Fs(), "a.txt")
+				require.NoError(t, err)
+				assert.Equal(t, "a", string(byt))
+
+				assert.False(t, prov.Dirty(ctx), "expected a clean work tree")
+
+				real, err := prov.Fs().(*afero.BasePathFs).RealPath("a.txt")
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(real, []byte("changed"), 0600))
+
+				assert.True(t, prov.Dirty(ctx), "expected a dirty work tree")
+			})
+		}
+
+		t.Run(backend+"/not a repository", func(t *testing.T) {
+			_, err := git.NewGitProvider(afero.NewOsFs(), t.TempDir(), backend)
+			require.ErrorIs(t, err, git.ErrNotAGitRepository)
+		})
+	}
+}
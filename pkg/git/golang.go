@@ -3,19 +3,22 @@ package git
 import (
 	"context"
 	"encoding/hex"
-	"fmt"
-	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-faster/errors"
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage"
 	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/filesystem/dotgit"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
@@ -23,34 +26,40 @@ import (
 
 var _ GitProvider = (*GitGoGitProvider)(nil)
 
+// GitGoGitProvider is a GitProvider backed by go-git. It is safe for concurrent use,
+// calls are serialized and share a single opened repository and ref index.
 type GitGoGitProvider struct {
-	store  storage.Storer
-	dotgit *AferoBillyFs
-	root   afero.Fs
+	store    storage.Storer
+	worktree billy.Filesystem
+	root     afero.Fs
+
+	mu    sync.Mutex
+	repo  *git.Repository
+	index *refIndex
 }
 
 func NewGitGoGitProvider(afo afero.Fs, dir string) (*GitGoGitProvider, error) {
 
-	if !filepath.IsAbs(dir) {
-		abs, err := filepath.Abs(dir)
-		if err != nil {
-			return nil, err
-		}
-
-		dir = abs
-
+	layout, err := resolveGitLayout(afo, dir)
+	if err != nil {
+		return nil, err
 	}
 
 	prov := &GitGoGitProvider{}
 
-	prov.root = afero.NewBasePathFs(afo, dir)
+	prov.root = afero.NewBasePathFs(afo, layout.WorkTree)
+	prov.worktree = NewAferoBillyFs(afo, layout.WorkTree)
+
+	var gitdir billy.Filesystem = NewAferoBillyFs(afo, layout.GitDir)
+	if layout.CommonDir != layout.GitDir {
+		gitdir = dotgit.NewRepositoryFilesystem(gitdir, NewAferoBillyFs(afo, layout.CommonDir))
+	}
 
 	buf := cache.NewObjectLRUDefault()
 
-	prov.dotgit = NewAferoBillyFs(prov.root, ".git")
-	wrk := filesystem.NewStorage(prov.dotgit, buf)
+	wrk := filesystem.NewStorage(gitdir, buf)
 
-	err := wrk.Init()
+	err = wrk.Init()
 	if err != nil {
 		return nil, err
 	}
@@ -60,15 +69,76 @@ func NewGitGoGitProvider(afo afero.Fs, dir string) (*GitGoGitProvider, error) {
 	return prov, nil
 }
 
+// lock takes the provider lock and opens the repository on first use.
+// The returned func must be called to release the lock.
+func (me *GitGoGitProvider) lock() (*git.Repository, func(), error) {
+	me.mu.Lock()
+
+	if me.repo == nil {
+		repo, err := git.Open(me.store, me.worktree)
+		if err != nil {
+			me.mu.Unlock()
+			return nil, nil, err
+		}
+		me.repo = repo
+	}
+
+	return me.repo, me.mu.Unlock, nil
+}
+
+// refs returns the ref index, building it on first use. The provider lock must be held.
+func (me *GitGoGitProvider) refs(ctx context.Context, repo *git.Repository) (*refIndex, error) {
+	if me.index != nil {
+		return me.index, nil
+	}
+
+	iter, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	index := newRefIndex()
+
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		// a detached HEAD is not listed by git for-each-ref either
+		if ref.Type() != plumbing.HashReference || ref.Name() == plumbing.HEAD {
+			return nil
+		}
+
+		commit, _, err := peelCommit(repo, ref)
+		if err != nil {
+			zerolog.Ctx(ctx).Debug().Err(err).Str("ref", ref.Name().String()).Msg("ref does not point to a commit, skipping")
+			return nil
+		}
+
+		index.add(commit.Hash.String(), ref.Name())
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index.sort()
+
+	zerolog.Ctx(ctx).Debug().Int("commits", len(index.names)).Int("tagged", len(index.semvers)).Msg("built ref index")
+
+	me.index = index
+
+	return index, nil
+}
+
 func (me *GitGoGitProvider) Fs() afero.Fs {
 	return me.root
 }
 
 func (me *GitGoGitProvider) GetContentHashFromRef(ctx context.Context, ref string) (string, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return "", err
 	}
+	defer unlock()
 
 	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
 	if err != nil {
@@ -85,11 +155,27 @@ func (me *GitGoGitProvider) GetContentHashFromRef(ctx context.Context, ref strin
 	return tree.Hash.String(), nil
 }
 
+func (me *GitGoGitProvider) GetCommitFromRef(ctx context.Context, ref string) (*Commit, error) {
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCommit(commit), nil
+}
+
 func (me *GitGoGitProvider) GetCurrentCommitFromRef(ctx context.Context, ref string) (string, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return "", err
 	}
+	defer unlock()
 
 	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
 	if err != nil {
@@ -100,10 +186,11 @@ func (me *GitGoGitProvider) GetCurrentCommitFromRef(ctx context.Context, ref str
 }
 
 func (me *GitGoGitProvider) GetCurrentCommitMessageFromRef(ctx context.Context, ref string) (string, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return "", err
 	}
+	defer unlock()
 
 	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
 	if err != nil {
@@ -114,10 +201,11 @@ func (me *GitGoGitProvider) GetCurrentCommitMessageFromRef(ctx context.Context,
 }
 
 func (me *GitGoGitProvider) GetCurrentBranchFromRef(ctx context.Context, ref string) (string, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return "", err
 	}
+	defer unlock()
 
 	_, reffer, err := me.getCommitFromRef(ctx, repo, ref)
 	if err != nil {
@@ -167,11 +255,16 @@ func (me *GitGoGitProvider) getCommitFromRef(ctx context.Context, repo *git.Repo
 	if err != nil {
 		resolved, err = repo.Reference(plumbing.ReferenceName(strings.Replace(string(refname), "heads", "remotes/origin", 1)), true)
 		if err != nil {
-			return nil, nil, ErrRefNotFound
+			// short names like v1.0.0 and revisions like HEAD^, as git rev-parse accepts them
+			hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+			if err != nil {
+				return nil, nil, ErrRefNotFound
+			}
+			return me.getCommitFromCommitHashString(ctx, repo, hash.String())
 		}
 	}
 
-	commit, err := repo.CommitObject(resolved.Hash())
+	commit, _, err := peelCommit(repo, resolved)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -179,138 +272,270 @@ func (me *GitGoGitProvider) getCommitFromRef(ctx context.Context, repo *git.Repo
 	return commit, resolved, nil
 }
 
-func getAllTagsForCommit(_ context.Context, repo *git.Repository, commit *object.Commit) ([]string, error) {
-	var tags []string
-	tagrefs, err := repo.References()
+func (me *GitGoGitProvider) GetLatestSemverTagFromRef(ctx context.Context, ref string) (*semver.Version, error) {
+
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return nil, err
 	}
-	defer tagrefs.Close()
-	err = tagrefs.ForEach(func(ref *plumbing.Reference) error {
-		tagCommit, err := repo.CommitObject(ref.Hash())
+	defer unlock()
+
+	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := me.refs(ctx, repo)
+	if err != nil {
+		return nil, errors.Errorf("failed to iterate over tags: %v", err)
+	}
+
+	latestSemver, err := findNearestSemverTag(commit.Hash.String(), index, func(hash string) ([]string, error) {
+		c, err := repo.CommitObject(plumbing.NewHash(hash))
 		if err != nil {
-			return nil
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				zerolog.Ctx(ctx).Debug().Str("commit", hash).Msg("commit not found, history is likely shallow")
+				return nil, nil
+			}
+			return nil, err
 		}
 
-		if commit.Hash.String() == tagCommit.Hash.String() {
-			tags = append(tags, ref.Name().Short())
+		parents := make([]string, 0, len(c.ParentHashes))
+		for _, p := range c.ParentHashes {
+			parents = append(parents, p.String())
 		}
-		return nil
+		return parents, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return tags, nil
-}
+	// Return error if no semver tags found
+	if latestSemver == nil {
+		zerolog.Ctx(ctx).Warn().Int("tagged", len(index.semvers)).Msgf("no semver tags found from ref '%s'", ref)
+		return nil, errors.Wrapf(ErrNoSemverTags, "from ref '%s'", ref)
+	}
 
-func (me *GitGoGitProvider) GetLatestSemverTagFromRef(ctx context.Context, ref string) (*semver.Version, error) {
+	zerolog.Ctx(ctx).Debug().Str("semver", latestSemver.String()).Msgf("latest semver tag from ref '%s'", ref)
+	return latestSemver, nil
+}
 
-	repo, err := git.Open(me.store, me.dotgit)
+// ListCommitsBetweenRefs returns the commits reachable from 'to' that are not reachable from 'from',
+// newest first. If 'from' is empty, every commit reachable from 'to' is returned.
+func (me *GitGoGitProvider) ListCommitsBetweenRefs(ctx context.Context, from string, to string) ([]*Commit, error) {
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	commit, reffer, err := me.getCommitFromRef(ctx, repo, ref)
+	toCommit, _, err := me.getCommitFromRef(ctx, repo, to)
 	if err != nil {
 		return nil, err
 	}
 
-	var latestSemver *semver.Version
-
-	tagz := make(map[*semver.Version]*object.Commit)
+	exclude := map[plumbing.Hash]bool{}
 
-	for commit != nil {
+	if from != "" {
+		fromCommit, _, err := me.getCommitFromRef(ctx, repo, from)
+		if err != nil {
+			return nil, err
+		}
 
-		tags, err := repo.Tags()
+		err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		})
 		if err != nil {
-			break
+			return nil, err
 		}
-		defer tags.Close()
+	}
 
-		err = tags.ForEach(func(refr *plumbing.Reference) error {
-			tagCommit, err := repo.CommitObject(refr.Hash())
-			if err != nil {
-				return nil
-			}
+	commits := []*Commit{}
+
+	err = object.NewCommitIterCTime(toCommit, exclude, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, newCommit(c))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if commit.Hash.String() == tagCommit.Hash.String() {
-				v, err := semver.NewVersion(refr.Name().Short())
-				if err == nil {
-					tagz[v] = tagCommit
-				}
+	zerolog.Ctx(ctx).Debug().Str("from", from).Str("to", to).Int("commits", len(commits)).Msg("listed commits between refs")
 
-			}
+	return commits, nil
+}
+
+// ListTags returns every tag in the repository along with the commit it points to.
+// Annotated tags are peeled to their target commit, tags that do not point to a commit are skipped.
+func (me *GitGoGitProvider) ListTags(ctx context.Context) ([]*Tag, error) {
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tagrefs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	defer tagrefs.Close()
+
+	tags := []*Tag{}
+
+	err = tagrefs.ForEach(func(ref *plumbing.Reference) error {
+		commit, annotated, err := peelCommit(repo, ref)
+		if err != nil {
+			zerolog.Ctx(ctx).Debug().Err(err).Str("tag", ref.Name().Short()).Msg("tag does not point to a commit, skipping")
 			return nil
+		}
+
+		tags = append(tags, &Tag{
+			Name:      ref.Name().Short(),
+			Commit:    commit.Hash.String(),
+			Annotated: annotated,
 		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// ListChangedFilesBetweenRefs returns the sorted paths that differ between the trees of 'from' and 'to'.
+// Both sides of a rename are included. If 'from' is empty, every file in 'to' is returned.
+func (me *GitGoGitProvider) ListChangedFilesBetweenRefs(ctx context.Context, from string, to string) ([]string, error) {
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	toCommit, _, err := me.getCommitFromRef(ctx, repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	toTree, err := toCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var fromTree *object.Tree
+
+	if from != "" {
+		fromCommit, _, err := me.getCommitFromRef(ctx, repo, from)
 		if err != nil {
-			return nil, errors.Errorf("failed to iterate over tags: %v", err)
+			return nil, err
 		}
 
-		if reffer.Name().IsTag() {
-			break
+		fromTree, err = fromCommit.Tree()
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		if len(tagz) == 0 {
-			commit, err = commit.Parents().Next()
-			if err != nil {
-				break
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	files := []string{}
+
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name == "" || seen[name] {
+				continue
 			}
-		} else {
-			break
+			seen[name] = true
+			files = append(files, name)
 		}
 	}
-	if err != nil {
-		return nil, errors.Errorf("failed to iterate over tags: %v", err)
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// peelCommit returns the commit a reference points to, peeling annotated tags. The bool reports whether the ref was an annotated tag.
+func peelCommit(repo *git.Repository, ref *plumbing.Reference) (*object.Commit, bool, error) {
+	commit, err := repo.CommitObject(ref.Hash())
+	if err == nil {
+		return commit, false, nil
 	}
 
-	for v := range tagz {
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		return nil, false, err
+	}
 
-		if latestSemver == nil || v.GreaterThan(latestSemver) {
-			latestSemver = v
-		}
+	commit, err = tag.Commit()
+	if err != nil {
+		return nil, true, err
 	}
 
-	// Return error if no semver tags found
-	if latestSemver == nil {
-		zerolog.Ctx(ctx).Warn().Any("tags", tagz).Msgf("no semver tags found from ref '%s'", ref)
-		return nil, errors.Errorf("no semver tags found from ref '%s'", ref)
+	return commit, true, nil
+}
+
+func newCommit(c *object.Commit) *Commit {
+	parents := make([]string, 0, len(c.ParentHashes))
+	for _, p := range c.ParentHashes {
+		parents = append(parents, p.String())
 	}
 
-	zerolog.Ctx(ctx).Debug().Str("semver", latestSemver.String()).Msgf("latest semver tag from ref '%s'", ref)
-	return latestSemver, nil
+	return &Commit{
+		Hash:        c.Hash.String(),
+		Author:      c.Author.Name,
+		AuthorEmail: c.Author.Email,
+		Date:        c.Author.When,
+		CommitDate:  c.Committer.When,
+		Parents:     parents,
+		Message:     c.Message,
+	}
 }
 
 func (me *GitGoGitProvider) GetLocalRepositoryMetadata(_ context.Context) (*LocalRepositoryMetadata, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
+	defer unlock()
 
-	remotes, err := repo.Remotes()
+	urls, err := remoteURLs(repo)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
-	if len(remotes) == 0 {
-		fmt.Println("No remotes found")
-		return nil, errors.Errorf("no remotes found")
+	remoteURL, err := selectRemote("", urls)
+	if err != nil {
+		return nil, err
 	}
 
-	remoteURL := remotes[0].Config().URLs[0]
-	splitURL := strings.Split(remoteURL, "/")
-	repoNameWithGit := splitURL[len(splitURL)-1]
+	return newLocalRepositoryMetadata(remoteURL)
+}
+
+// remoteURLs maps the name of every configured remote to its first url.
+func remoteURLs(repo *git.Repository) (map[string]string, error) {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
 
-	// Remove .git from repo name
-	repoName := strings.TrimSuffix(repoNameWithGit, ".git")
+	urls := map[string]string{}
+	for _, remote := range remotes {
+		if len(remote.Config().URLs) > 0 {
+			urls[remote.Config().Name] = remote.Config().URLs[0]
+		}
+	}
 
-	return &LocalRepositoryMetadata{
-		Owner:  strings.Join(splitURL[len(splitURL)-2:len(splitURL)-1], "/"),
-		Name:   repoName,
-		Remote: remoteURL,
-	}, nil
+	return urls, nil
 }
 
 func (me *GitGoGitProvider) GetCurrentShortHashFromRef(ctx context.Context, ref string) (string, error) {
@@ -324,10 +549,11 @@ func (me *GitGoGitProvider) GetCurrentShortHashFromRef(ctx context.Context, ref
 
 func (me *GitGoGitProvider) TryGetPRNumber(ctx context.Context) (uint64, error) {
 
-	repo, err := git.Open(me.store, me.dotgit)
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return 0, err
 	}
+	defer unlock()
 
 	commit, _, err := me.getCommitFromRef(ctx, repo, "HEAD")
 	if err != nil {
@@ -336,33 +562,21 @@ func (me *GitGoGitProvider) TryGetPRNumber(ctx context.Context) (uint64, error)
 
 	zerolog.Ctx(ctx).Debug().Str("commit", commit.Hash.String()).Msg("searching for PR logs")
 
-	tagz, err := getAllTagsForCommit(ctx, repo, commit)
+	index, err := me.refs(ctx, repo)
 	if err != nil {
 		return 0, err
 	}
 
-	zerolog.Ctx(ctx).Debug().Str("tags", strings.Join(tagz, ",")).Msg("found tags")
-
-	for _, tag := range tagz {
-		if strings.HasPrefix(tag, "pull") {
-			work := strings.Split(tag, "/")
-			inter, err := strconv.ParseUint(work[len(work)-2], 10, 64)
-			if err != nil {
-				return 0, err
-			}
-
-			return inter, nil
-		}
-	}
-
-	return 0, nil
+	return prNumberFromRefNames(ctx, index.names[commit.Hash.String()])
 }
 
 func (me *GitGoGitProvider) Dirty(_ context.Context) bool {
-	repo, err := git.PlainOpen(me.dotgit.Name() + "/..")
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return false
 	}
+	defer unlock()
+
 	wt, err := repo.Worktree()
 	if err != nil {
 		return false
@@ -375,56 +589,158 @@ func (me *GitGoGitProvider) Dirty(_ context.Context) bool {
 	return !status.IsClean()
 }
 
-func (me *GitGoGitProvider) TryGetSemverTag(ctx context.Context) (*semver.Version, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+// ListDirtyFiles returns the sorted paths of the tracked files that differ from HEAD, staged or not.
+// Untracked files are ignored.
+func (me *GitGoGitProvider) ListDirtyFiles(_ context.Context) ([]string, error) {
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	commit, _, err := me.getCommitFromRef(ctx, repo, "HEAD")
+	wt, err := repo.Worktree()
 	if err != nil {
 		return nil, err
 	}
 
-	tagz, err := getAllTagsForCommit(ctx, repo, commit)
+	status, err := wt.Status()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, tag := range tagz {
-		v, err := semver.NewVersion(tag)
-		if err != nil {
+	paths := []string{}
+	for path, s := range status {
+		if s.Worktree == git.Untracked || (s.Staging == git.Unmodified && s.Worktree == git.Unmodified) {
 			continue
 		}
+		paths = append(paths, path)
+		if s.Extra != "" {
+			// the original path of a rename
+			paths = append(paths, s.Extra)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func (me *GitGoGitProvider) TryGetSemverTag(ctx context.Context) (*semver.Version, error) {
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	commit, _, err := me.getCommitFromRef(ctx, repo, "HEAD")
+	if err != nil {
+		return nil, err
+	}
 
-		return v, nil
+	index, err := me.refs(ctx, repo)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return index.latestSemver(commit.Hash.String()), nil
 }
 
-func (me *GitGoGitProvider) GetRemoteURL(_ context.Context) (string, error) {
-	repo, err := git.Open(me.store, me.dotgit)
+// GetRemoteURL returns the url of the named remote, or of the default remote if name is empty.
+func (me *GitGoGitProvider) GetRemoteURL(_ context.Context, name string) (string, error) {
+	repo, unlock, err := me.lock()
 	if err != nil {
 		return "", err
 	}
+	defer unlock()
 
-	remotes, err := repo.Remotes()
+	urls, err := remoteURLs(repo)
 	if err != nil {
 		return "", err
 	}
 
-	// get for main branch
-	var remoteURL string
-	for _, remote := range remotes {
-		if remote.Config().Name == "origin" {
-			remoteURL = remote.Config().URLs[0]
-		}
+	return selectRemote(name, urls)
+}
+
+// CreateTag creates an annotated tag of the commit ref points to. The ref index is rebuilt on next use.
+func (me *GitGoGitProvider) CreateTag(ctx context.Context, name string, ref string, opts *CreateTagOptions) (*Tag, error) {
+	if opts == nil {
+		opts = &CreateTagOptions{}
+	}
+
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	commit, _, err := me.getCommitFromRef(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	refname := plumbing.NewTagReferenceName(name)
+
+	if _, err := repo.Storer.Reference(refname); err == nil {
+		return nil, errors.Wrapf(ErrTagExists, "'%s'", name)
+	}
+
+	var user struct{ Name, Email string }
+	if cfg, err := repo.ConfigScoped(config.SystemScope); err == nil {
+		user.Name, user.Email = cfg.User.Name, cfg.User.Email
+	}
+
+	tagger := object.Signature{
+		Name:  firstNonEmpty(opts.TaggerName, user.Name, commit.Committer.Name),
+		Email: firstNonEmpty(opts.TaggerEmail, user.Email, commit.Committer.Email),
+	}
+
+	obj, err := encodeTag(name, commit.Hash.String(), tagger, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refname, hash)); err != nil {
+		return nil, err
+	}
+
+	me.index = nil
+
+	return &Tag{Name: name, Commit: commit.Hash.String(), Annotated: true}, nil
+}
+
+// PushTag pushes the tag to the remote. A tag the remote already has is not an error.
+func (me *GitGoGitProvider) PushTag(ctx context.Context, name string, opts *PushOptions) error {
+	repo, unlock, err := me.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	remote, err := repo.Remote(opts.remote())
+	if err != nil {
+		return errors.Wrapf(err, "remote '%s'", opts.remote())
+	}
+
+	refname := plumbing.NewTagReferenceName(name)
+
+	push := &git.PushOptions{
+		RemoteName: opts.remote(),
+		RefSpecs:   []config.RefSpec{config.RefSpec(refname + ":" + refname)},
+	}
+
+	if opts != nil && opts.Password != "" && len(remote.Config().URLs) > 0 && isHTTPRemote(remote.Config().URLs[0]) {
+		push.Auth = &http.BasicAuth{Username: opts.Username, Password: opts.Password}
 	}
 
-	if remoteURL == "" {
-		return "", errors.Errorf("could not get remote URL")
+	err = remote.PushContext(ctx, push)
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
 	}
 
-	return remoteURL, nil
+	return err
 }
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rs/zerolog"
+)
+
+type Commit struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Date        time.Time
+	// CommitDate is the committer date, Date is the author date
+	CommitDate time.Time
+	Parents    []string
+	Message    string
+}
+
+type Tag struct {
+	Name      string
+	Commit    string
+	Annotated bool
+}
+
+func (me *Commit) ShortHash() string {
+	if len(me.Hash) < 7 {
+		return me.Hash
+	}
+	return me.Hash[:7]
+}
+
+func (me *Commit) IsMerge() bool {
+	return len(me.Parents) > 1
+}
+
+// refIndex maps commit hashes to the refs that point to them, with annotated tags peeled.
+type refIndex struct {
+	names   map[string][]string
+	semvers map[string][]*semver.Version
+}
+
+func newRefIndex() *refIndex {
+	return &refIndex{
+		names:   map[string][]string{},
+		semvers: map[string][]*semver.Version{},
+	}
+}
+
+func (me *refIndex) add(commit string, ref plumbing.ReferenceName) {
+	me.names[commit] = append(me.names[commit], ref.Short())
+
+	if ref.IsTag() {
+		if v, err := semver.NewVersion(ref.Short()); err == nil {
+			me.semvers[commit] = append(me.semvers[commit], v)
+		}
+	}
+}
+
+func (me *refIndex) sort() {
+	for _, names := range me.names {
+		sort.Strings(names)
+	}
+}
+
+// latestSemver returns the highest semver tag pointing to the commit, or nil if there is none.
+func (me *refIndex) latestSemver(commit string) *semver.Version {
+	var latest *semver.Version
+	for _, v := range me.semvers[commit] {
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// findNearestSemverTag walks every ancestor of the commit breadth first, stopping at the first
+// tagged commit on each path, and returns the highest semver tag among those nearest tagged ancestors.
+// The parents func may return no parents for commits that are missing, as found in shallow clones.
+func findNearestSemverTag(commit string, index *refIndex, parents func(hash string) ([]string, error)) (*semver.Version, error) {
+
+	var latestSemver *semver.Version
+
+	seen := map[string]bool{commit: true}
+	queue := []string{commit}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if v := index.latestSemver(current); v != nil {
+			if latestSemver == nil || v.GreaterThan(latestSemver) {
+				latestSemver = v
+			}
+			continue
+		}
+
+		ps, err := parents(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range ps {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			queue = append(queue, p)
+		}
+	}
+
+	return latestSemver, nil
+}
+
+// prNumberFromRefNames returns the pull request number from a 'pull/<number>/...' ref name, or 0 if there is none.
+func prNumberFromRefNames(ctx context.Context, names []string) (uint64, error) {
+
+	zerolog.Ctx(ctx).Debug().Str("tags", strings.Join(names, ",")).Msg("found tags")
+
+	for _, tag := range names {
+		if strings.HasPrefix(tag, "pull") {
+			work := strings.Split(tag, "/")
+			inter, err := strconv.ParseUint(work[len(work)-2], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+
+			return inter, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"context"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/afero"
@@ -39,6 +40,10 @@ func (me *memoryPullRequestProvider) ListRecentPullRequests(_ context.Context, _
 	return me.prs, nil
 }
 
+func (me *memoryPullRequestProvider) ListPullRequestsUpdatedSince(_ context.Context, _ string, _ time.Time) ([]*PullRequest, error) {
+	return me.prs, nil
+}
+
 /* /////////////////////////////////////////
 	RELEASE PROVIDER
 ///////////////////////////////////////// */
@@ -80,7 +85,7 @@ func (me *memoryReleaseProvider) GetReleaseByID(_ context.Context, id string) (*
 
 func (me *memoryReleaseProvider) TagRelease(_ context.Context, _ GitProvider, vers *semver.Version) (*Release, error) {
 	return &Release{
-		Tag:        "v" + vers.String(),
+		Tag:        TagName(vers),
 		CommitHash: "1234567890",
 	}, nil
 }
@@ -37,6 +37,19 @@ type LocalRepositoryMetadataProvider interface {
 	GetLocalRepositoryMetadata(ctx context.Context) (*LocalRepositoryMetadata, error)
 }
 
+func newLocalRepositoryMetadata(remoteURL string) (*LocalRepositoryMetadata, error) {
+	remote, err := ParseRemoteURL(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalRepositoryMetadata{
+		Owner:  remote.Org(),
+		Name:   remote.Name,
+		Remote: remoteURL,
+	}, nil
+}
+
 type DockerBakeTemplateTags []string
 
 func GetCommitMetadata(ctx context.Context, me GitProvider, ref string) (*CommitMetadata, error) {
@@ -82,13 +95,22 @@ func buildSpecifcTag(ctx context.Context, comt GitProvider, version *semver.Vers
 	return version.String(), nil
 }
 
-func BuildDockerBakeTemplateTags(ctx context.Context, comt GitProvider, version *semver.Version) (DockerBakeTemplateTags, error) {
+// BuildDockerBakeTemplateTags returns the docker metadata tags of the version. The floating latest, major and
+// major.minor tags are only enabled on the main branch, so releases from maintenance branches never move them.
+// If mainBranch is empty, DefaultMainBranch is used.
+func BuildDockerBakeTemplateTags(ctx context.Context, comt GitProvider, version *semver.Version, mainBranch string) (DockerBakeTemplateTags, error) {
+
+	if mainBranch == "" {
+		mainBranch = DefaultMainBranch
+	}
 
 	branch, err := comt.GetCurrentBranchFromRef(ctx, "HEAD")
 	if err != nil {
 		return nil, err
 	}
 
+	floating := branch == mainBranch
+
 	bstag, err := buildSpecifcTag(ctx, comt, version)
 	if err != nil {
 		return nil, err
@@ -101,9 +123,9 @@ func BuildDockerBakeTemplateTags(ctx context.Context, comt GitProvider, version
 	strs = append(strs, "type=schedule")
 	strs = append(strs, fmt.Sprintf("type=semver,pattern=v{{version}},value=%s", version.String()))
 	strs = append(strs, "type=sha")
-	strs = append(strs, fmt.Sprintf("type=raw,value=latest,enable=%v", branch == "main"))
-	strs = append(strs, fmt.Sprintf("type=semver,pattern=v{{major}}.{{minor}},value=%s,enable=%v", version.String(), branch == "main"))
-	strs = append(strs, fmt.Sprintf("type=semver,pattern=v{{major}},value=%s,enable=%v", version.String(), branch == "main"))
+	strs = append(strs, fmt.Sprintf("type=raw,value=latest,enable=%v", floating))
+	strs = append(strs, fmt.Sprintf("type=semver,pattern=v{{major}}.{{minor}},value=%s,enable=%v", version.String(), floating))
+	strs = append(strs, fmt.Sprintf("type=semver,pattern=v{{major}},value=%s,enable=%v", version.String(), floating))
 
 	return strs, nil
 }
@@ -150,6 +172,11 @@ func BuildDockerBakeLabels(ctx context.Context, name string, repo RemoteReposito
 		return nil, err
 	}
 
+	created, err := Now()
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]string{
 		"org.opencontainers.image.title":         name,
 		"org.opencontainers.image.source":        localRepoMeta.Remote,
@@ -159,7 +186,7 @@ func BuildDockerBakeLabels(ctx context.Context, name string, repo RemoteReposito
 		"org.opencontainers.image.revision":      commitMetadata.Head,
 		"org.opencontainers.image.vendor":        localRepoMeta.Owner,
 		"org.opencontainers.image.licenses":      repoMetadata.License,
-		"org.opencontainers.image.created":       time.Now().Format(time.RFC3339),
+		"org.opencontainers.image.created":       created.Format(time.RFC3339),
 		"org.opencontainers.image.authors":       localRepoMeta.Owner,
 		"org.opencontainers.image.ref.name":      commitMetadata.Tag.String(),
 		"org.opencontainers.image.description":   repoMetadata.Description,
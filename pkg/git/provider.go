@@ -4,21 +4,46 @@ import (
 	"context"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
 	"github.com/spf13/afero"
 )
 
+const (
+	GitBackendGoGit = "go-git"
+	GitBackendExec  = "exec"
+)
+
 type GitProvider interface {
 	LocalRepositoryMetadataProvider
 	GetCurrentShortHashFromRef(ctx context.Context, ref string) (string, error)
 	GetCurrentCommitFromRef(ctx context.Context, ref string) (string, error)
 	GetCurrentCommitMessageFromRef(ctx context.Context, ref string) (string, error)
+	GetCommitFromRef(ctx context.Context, ref string) (*Commit, error)
 	GetCurrentBranchFromRef(ctx context.Context, ref string) (string, error)
 	GetLatestSemverTagFromRef(ctx context.Context, ref string) (*semver.Version, error)
+	ListCommitsBetweenRefs(ctx context.Context, from string, to string) ([]*Commit, error)
+	ListTags(ctx context.Context) ([]*Tag, error)
+	ListChangedFilesBetweenRefs(ctx context.Context, from string, to string) ([]string, error)
 	GetContentHashFromRef(ctx context.Context, ref string) (string, error)
 	TryGetPRNumber(ctx context.Context) (uint64, error)
 	TryGetSemverTag(ctx context.Context) (*semver.Version, error)
-	GetRemoteURL(ctx context.Context) (string, error)
+	GetRemoteURL(ctx context.Context, remote string) (string, error)
 	Dirty(ctx context.Context) bool
+	ListDirtyFiles(ctx context.Context) ([]string, error)
+	CreateTag(ctx context.Context, name string, ref string, opts *CreateTagOptions) (*Tag, error)
+	PushTag(ctx context.Context, name string, opts *PushOptions) error
 
 	Fs() afero.Fs
 }
+
+// NewGitProvider returns the GitProvider for the backend, defaulting to go-git.
+func NewGitProvider(afo afero.Fs, dir string, backend string) (GitProvider, error) {
+	switch backend {
+	case GitBackendGoGit, "":
+		return NewGitGoGitProvider(afo, dir)
+	case GitBackendExec:
+		return NewExecGitProvider(afo, dir)
+	default:
+		return nil, errors.Errorf("unknown git backend '%s', must be one of [%s, %s]", backend, GitBackendGoGit, GitBackendExec)
+	}
+}
@@ -0,0 +1,504 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestGitProviderListCommitsBetweenRefs(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newTestRepo(t)
+	c1 := repo.commit("first", map[string]string{"a.txt": "a"})
+	repo.tag("v1.0.0", c1, false)
+	c2 := repo.commit("second", map[string]string{"b.txt": "b"})
+	repo.checkout("feature", c2)
+	c3 := repo.commit("feature work", map[string]string{"c.txt": "c"})
+	repo.checkout("main", "")
+	c4 := repo.commit("main work", map[string]string{"d.txt": "d"})
+	c5 := repo.commit("merge feature", nil, c4, c3)
+	repo.tag("v1.1.0", c5, true)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{
+			name:     "from tag to head",
+			from:     "refs/tags/v1.0.0",
+			to:       "HEAD",
+			expected: []string{c5, c4, c3, c2},
+		},
+		{
+			name:     "from annotated tag to head",
+			from:     "refs/tags/v1.1.0",
+			to:       "HEAD",
+			expected: []string{},
+		},
+		{
+			name:     "from empty includes root",
+			from:     "",
+			to:       "refs/heads/feature",
+			expected: []string{c3, c2, c1},
+		},
+		{
+			name:     "between branches",
+			from:     "refs/heads/feature",
+			to:       "main",
+			expected: []string{c5, c4},
+		},
+		{
+			name:     "from commit hash",
+			from:     c2,
+			to:       c4,
+			expected: []string{c4},
+		},
+	}
+
+	for _, backend := range backends {
+		prov := repo.open(backend)
+
+		t.Run(backend, func(t *testing.T) {
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					commits, err := prov.ListCommitsBetweenRefs(ctx, test.from, test.to)
+					require.NoError(t, err)
+
+					hashes := []string{}
+					for _, c := range commits {
+						hashes = append(hashes, c.Hash)
+					}
+
+					assert.Equal(t, test.expected, hashes)
+				})
+			}
+
+			t.Run("commit details", func(t *testing.T) {
+				commits, err := prov.ListCommitsBetweenRefs(ctx, c4, c5)
+				require.NoError(t, err)
+				require.Len(t, commits, 2)
+
+				merge := commits[0]
+				assert.Equal(t, "merge feature", merge.Message)
+				assert.Equal(t, "buildrc", merge.Author)
+				assert.Equal(t, "buildrc@example.com", merge.AuthorEmail)
+				assert.Equal(t, []string{c4, c3}, merge.Parents)
+				assert.True(t, merge.IsMerge())
+				assert.False(t, merge.Date.IsZero())
+				assert.Equal(t, c5[:7], merge.ShortHash())
+			})
+
+			t.Run("unknown ref", func(t *testing.T) {
+				_, err := prov.ListCommitsBetweenRefs(ctx, "refs/tags/nope", "HEAD")
+				require.ErrorIs(t, err, git.ErrRefNotFound)
+			})
+		})
+	}
+}
+
+func TestGitProviderListTags(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		setup func(repo *testRepo) []*git.Tag
+	}{
+		{
+			name: "no tags",
+			setup: func(repo *testRepo) []*git.Tag {
+				repo.commit("first", map[string]string{"a.txt": "a"})
+				return []*git.Tag{}
+			},
+		},
+		{
+			name: "lightweight and annotated",
+			setup: func(repo *testRepo) []*git.Tag {
+				c1 := repo.commit("first", map[string]string{"a.txt": "a"})
+				c2 := repo.commit("second", map[string]string{"a.txt": "b"})
+				repo.tag("v1.0.0", c1, false)
+				repo.tag("v1.1.0", c2, true)
+				repo.tag("not-semver", c2, false)
+				return []*git.Tag{
+					{Name: "not-semver", Commit: c2, Annotated: false},
+					{Name: "v1.0.0", Commit: c1, Annotated: false},
+					{Name: "v1.1.0", Commit: c2, Annotated: true},
+				}
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			t.Run(backend+"/"+test.name, func(t *testing.T) {
+				repo := newTestRepo(t)
+				expected := test.setup(repo)
+
+				tags, err := repo.open(backend).ListTags(ctx)
+				require.NoError(t, err)
+
+				assert.Equal(t, expected, tags)
+			})
+		}
+	}
+}
+
+func TestGitProviderListChangedFilesBetweenRefs(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newTestRepo(t)
+	c1 := repo.commit("first", map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	c2 := repo.commit("modify", map[string]string{"a.txt": "aa"})
+	c3 := repo.commit("add and delete", map[string]string{"dir/c.txt": "c", "dir/b.txt": ""})
+	repo.tag("v1.0.0", c2, true)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{
+			name:     "single modification",
+			from:     c1,
+			to:       c2,
+			expected: []string{"a.txt"},
+		},
+		{
+			name:     "add and delete from annotated tag",
+			from:     "refs/tags/v1.0.0",
+			to:       "HEAD",
+			expected: []string{"dir/b.txt", "dir/c.txt"},
+		},
+		{
+			name:     "across several commits",
+			from:     c1,
+			to:       c3,
+			expected: []string{"a.txt", "dir/b.txt", "dir/c.txt"},
+		},
+		{
+			name:     "from empty lists everything",
+			from:     "",
+			to:       c1,
+			expected: []string{"a.txt", "dir/b.txt"},
+		},
+		{
+			name:     "no changes",
+			from:     c3,
+			to:       "HEAD",
+			expected: []string{},
+		},
+	}
+
+	for _, backend := range backends {
+		prov := repo.open(backend)
+
+		t.Run(backend, func(t *testing.T) {
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					files, err := prov.ListChangedFilesBetweenRefs(ctx, test.from, test.to)
+					require.NoError(t, err)
+					assert.Equal(t, test.expected, files)
+				})
+			}
+		})
+	}
+}
+
+func TestGitProviderGetLatestSemverTagFromRef(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		setup    func(repo *testRepo) string
+		expected string
+	}{
+		{
+			name: "lightweight tag on head",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, false)
+				return "HEAD"
+			},
+			expected: "1.0.0",
+		},
+		{
+			name: "annotated tag on head",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, true)
+				return "HEAD"
+			},
+			expected: "1.0.0",
+		},
+		{
+			name: "annotated tag on ancestor",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.2.0", c1, true)
+				repo.commit("second", nil)
+				repo.commit("third", nil)
+				return "HEAD"
+			},
+			expected: "1.2.0",
+		},
+		{
+			name: "nearest tag wins over older higher tag",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v3.0.0", c1, false)
+				c2 := repo.commit("second", nil)
+				repo.tag("v1.1.0", c2, false)
+				repo.commit("third", nil)
+				return "HEAD"
+			},
+			expected: "1.1.0",
+		},
+		{
+			name: "highest tag on the same commit",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, false)
+				repo.tag("v1.0.1", c1, true)
+				repo.tag("latest", c1, false)
+				return "HEAD"
+			},
+			expected: "1.0.1",
+		},
+		{
+			name: "tag only reachable through second parent of a merge",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.checkout("feature", c1)
+				c2 := repo.commit("feature", map[string]string{"f.txt": "f"})
+				repo.tag("v0.5.0", c2, true)
+				repo.checkout("main", "")
+				c3 := repo.commit("main", map[string]string{"m.txt": "m"})
+				repo.commit("merge", nil, c3, c2)
+				return "HEAD"
+			},
+			expected: "0.5.0",
+		},
+		{
+			name: "highest of the nearest tags across both sides of a merge",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, false)
+				repo.checkout("release", c1)
+				c2 := repo.commit("release", map[string]string{"r.txt": "r"})
+				repo.tag("v1.1.0", c2, false)
+				repo.checkout("main", "")
+				c3 := repo.commit("main", map[string]string{"m.txt": "m"})
+				repo.tag("v1.0.1", c3, true)
+				repo.commit("merge", nil, c3, c2)
+				return "HEAD"
+			},
+			expected: "1.1.0",
+		},
+		{
+			name: "tag is not found past a tagged merge",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v9.0.0", c1, false)
+				repo.checkout("feature", c1)
+				c2 := repo.commit("feature", map[string]string{"f.txt": "f"})
+				repo.checkout("main", "")
+				c3 := repo.commit("main", map[string]string{"m.txt": "m"})
+				c4 := repo.commit("merge", nil, c3, c2)
+				repo.tag("v2.0.0", c4, true)
+				repo.commit("after", nil)
+				return "HEAD"
+			},
+			expected: "2.0.0",
+		},
+		{
+			name: "from a branch ref",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, false)
+				repo.checkout("feature", c1)
+				c2 := repo.commit("feature", nil)
+				repo.tag("v1.1.0-pr.1", c2, false)
+				repo.checkout("main", "")
+				return "refs/heads/feature"
+			},
+			expected: "1.1.0-pr.1",
+		},
+		{
+			name: "from a tag ref",
+			setup: func(repo *testRepo) string {
+				c1 := repo.commit("first", nil)
+				repo.tag("v1.0.0", c1, true)
+				c2 := repo.commit("second", nil)
+				repo.tag("v1.1.0", c2, true)
+				return "refs/tags/v1.0.0"
+			},
+			expected: "1.0.0",
+		},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			t.Run(backend+"/"+test.name, func(t *testing.T) {
+				repo := newTestRepo(t)
+				ref := test.setup(repo)
+
+				v, err := repo.open(backend).GetLatestSemverTagFromRef(ctx, ref)
+				require.NoError(t, err)
+				assert.Equal(t, test.expected, v.String())
+			})
+		}
+
+		t.Run(backend+"/no semver tags", func(t *testing.T) {
+			repo := newTestRepo(t)
+			c1 := repo.commit("first", nil)
+			repo.tag("latest", c1, true)
+
+			_, err := repo.open(backend).GetLatestSemverTagFromRef(ctx, "HEAD")
+			assert.ErrorIs(t, err, git.ErrNoSemverTags)
+		})
+	}
+}
+
+func TestGitProviderTryGetSemverTagAnnotated(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newTestRepo(t)
+	c1 := repo.commit("first", nil)
+	repo.tag("v1.4.0", c1, true)
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			v, err := repo.open(backend).TryGetSemverTag(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, v)
+			assert.Equal(t, "1.4.0", v.String())
+		})
+	}
+}
+
+func TestGitProviderCommitQueries(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newTestRepo(t)
+	c1 := repo.commit("first\n\nwith a body\n", map[string]string{"a.txt": "a"})
+	repo.tag("v1.0.0", c1, true)
+	c2 := repo.commit("second", map[string]string{"a.txt": "b"})
+	repo.checkout("feature", c1)
+	repo.remote("origin", "https://github.com/walteh/buildrc.git")
+	repo.remote("upstream", "git@gitlab.com:group/sub/buildrc.git")
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			prov := repo.open(backend)
+
+			head, err := prov.GetCurrentCommitFromRef(ctx, "HEAD")
+			require.NoError(t, err)
+			assert.Equal(t, c1, head)
+
+			short, err := prov.GetCurrentShortHashFromRef(ctx, "main")
+			require.NoError(t, err)
+			assert.Equal(t, c2[:7], short)
+
+			tagged, err := prov.GetCurrentCommitFromRef(ctx, "refs/tags/v1.0.0")
+			require.NoError(t, err)
+			assert.Equal(t, c1, tagged)
+
+			tagged, err = prov.GetCurrentCommitFromRef(ctx, "v1.0.0")
+			require.NoError(t, err)
+			assert.Equal(t, c1, tagged)
+
+			parent, err := prov.GetCurrentCommitFromRef(ctx, "main^")
+			require.NoError(t, err)
+			assert.Equal(t, c1, parent)
+
+			msg, err := prov.GetCurrentCommitMessageFromRef(ctx, "HEAD")
+			require.NoError(t, err)
+			assert.Equal(t, "first\n\nwith a body\n", msg)
+
+			commit, err := prov.GetCommitFromRef(ctx, "main")
+			require.NoError(t, err)
+			assert.Equal(t, c2, commit.Hash)
+			assert.Equal(t, []string{c1}, commit.Parents)
+			assert.True(t, commit.CommitDate.Equal(time.Date(2023, 1, 1, 0, 2, 0, 0, time.UTC)), "committer date %s", commit.CommitDate)
+
+			branch, err := prov.GetCurrentBranchFromRef(ctx, "HEAD")
+			require.NoError(t, err)
+			assert.Equal(t, "feature", branch)
+
+			branch, err = prov.GetCurrentBranchFromRef(ctx, "main")
+			require.NoError(t, err)
+			assert.Equal(t, "main", branch)
+
+			first, err := prov.GetContentHashFromRef(ctx, c1)
+			require.NoError(t, err)
+			second, err := prov.GetContentHashFromRef(ctx, c2)
+			require.NoError(t, err)
+			assert.NotEqual(t, first, second)
+			assert.Len(t, first, 40)
+
+			pr, err := prov.TryGetPRNumber(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(0), pr)
+
+			url, err := prov.GetRemoteURL(ctx, "")
+			require.NoError(t, err)
+			assert.Equal(t, "https://github.com/walteh/buildrc.git", url)
+
+			upstream, err := prov.GetRemoteURL(ctx, "upstream")
+			require.NoError(t, err)
+			assert.Equal(t, "git@gitlab.com:group/sub/buildrc.git", upstream)
+
+			_, err = prov.GetRemoteURL(ctx, "nope")
+			require.Error(t, err)
+
+			meta, err := prov.GetLocalRepositoryMetadata(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, &git.LocalRepositoryMetadata{Owner: "walteh", Name: "buildrc", Remote: url}, meta)
+
+			_, err = prov.GetCurrentCommitFromRef(ctx, "refs/heads/nope")
+			require.ErrorIs(t, err, git.ErrRefNotFound)
+		})
+	}
+}
+
+func TestGitProviderListDirtyFiles(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newTestRepo(t)
+	repo.commit("first", map[string]string{"a.txt": "a", "dir/b.txt": "b", "c.txt": "c"})
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			prov := repo.open(backend)
+
+			files, err := prov.ListDirtyFiles(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{}, files)
+		})
+	}
+
+	// an unstaged change, a staged change, a deletion and an untracked file
+	require.NoError(t, os.WriteFile(filepath.Join(repo.dir, "a.txt"), []byte("aa"), 0600))
+	repo.write(map[string]string{"dir/b.txt": "bb"})
+	require.NoError(t, os.Remove(filepath.Join(repo.dir, "c.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.dir, "untracked.txt"), []byte("u"), 0600))
+
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			prov := repo.open(backend)
+
+			files, err := prov.ListDirtyFiles(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a.txt", "c.txt", "dir/b.txt"}, files)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/go-faster/errors"
 	"github.com/rs/zerolog"
@@ -12,12 +13,17 @@ import (
 type PullRequest struct {
 	Number int
 	Head   string
-	Closed bool
-	Open   bool
+	// Closed is set for a merged pull request, one closed without merging is neither open nor closed
+	Closed  bool
+	Open    bool
+	Updated time.Time
 }
 
 type PullRequestProvider interface {
 	ListRecentPullRequests(ctx context.Context, head string) ([]*PullRequest, error)
+	// ListPullRequestsUpdatedSince lists the pull requests targeting the branch that were updated at or
+	// after since, newest first. Unlike ListRecentPullRequests it is not limited to one page.
+	ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*PullRequest, error)
 }
 
 func (me *PullRequest) PreReleaseTag() string {
@@ -48,7 +54,7 @@ func getLatestPullRequestForRef(ctx context.Context, prprov PullRequestProvider,
 	return nil, errors.Errorf("no open or merged PRs found")
 }
 
-func getLatestMergedPullRequestThatHasAMatchingContentHash(ctx context.Context, prprov PullRequestProvider, git GitProvider) (*PullRequest, error) {
+func getLatestMergedPullRequestThatHasAMatchingContentHash(ctx context.Context, prprov PullRequestProvider, git GitProvider, mainBranch string) (*PullRequest, error) {
 
 	mycontenthash, err := git.GetContentHashFromRef(ctx, "HEAD")
 	if err != nil {
@@ -60,11 +66,11 @@ func getLatestMergedPullRequestThatHasAMatchingContentHash(ctx context.Context,
 		return nil, err
 	}
 
-	if branch != "main" {
-		return nil, errors.Errorf("not on main branch")
+	if branch != mainBranch {
+		return nil, errors.Errorf("not on %s branch", mainBranch)
 	}
 
-	prs, err := prprov.ListRecentPullRequests(ctx, "main")
+	prs, err := prprov.ListRecentPullRequests(ctx, mainBranch)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+const DefaultRemoteName = "origin"
+
+// RemoteURL is a parsed git remote. Namespace is the full path between the host and the repository,
+// which is more than one segment for GitLab subgroups and Azure DevOps projects.
+type RemoteURL struct {
+	Host      string
+	Namespace string
+	Name      string
+}
+
+// scpLikeURL matches the scp style syntax git accepts for ssh remotes, [user@]host:path.
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ParseRemoteURL parses https, ssh, git and scp style remote urls, ignoring credentials and ports.
+func ParseRemoteURL(raw string) (*RemoteURL, error) {
+	raw = strings.TrimSpace(raw)
+
+	var host, path string
+
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidRemoteURL, "%q: %v", raw, err)
+		}
+		if u.Scheme == "file" {
+			return nil, errors.Wrapf(ErrInvalidRemoteURL, "%q: local remotes have no host", raw)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if m := scpLikeURL.FindStringSubmatch(raw); m != nil {
+		host, path = m[1], m[2]
+	}
+
+	host = strings.ToLower(host)
+
+	segments := []string{}
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	switch {
+	case host == "ssh.dev.azure.com" || host == "vs-ssh.visualstudio.com":
+		// git@ssh.dev.azure.com:v3/org/project/repo
+		if len(segments) > 0 && segments[0] == "v3" {
+			segments = segments[1:]
+		}
+		host = "dev.azure.com"
+	default:
+		// https://dev.azure.com/org/project/_git/repo
+		for i, s := range segments {
+			if s == "_git" && i == len(segments)-2 {
+				segments = append(segments[:i], segments[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if host == "" || len(segments) < 2 {
+		return nil, errors.Wrapf(ErrInvalidRemoteURL, "%q", raw)
+	}
+
+	name := strings.TrimSuffix(segments[len(segments)-1], ".git")
+	if name == "" {
+		return nil, errors.Wrapf(ErrInvalidRemoteURL, "%q", raw)
+	}
+
+	return &RemoteURL{
+		Host:      host,
+		Namespace: strings.Join(segments[:len(segments)-1], "/"),
+		Name:      name,
+	}, nil
+}
+
+// Org is the top level segment of the namespace, the user, organization or top level group.
+func (me *RemoteURL) Org() string {
+	org, _, _ := strings.Cut(me.Namespace, "/")
+	return org
+}
+
+// HTTPS returns the canonical https url of the repository, without credentials or a .git suffix.
+func (me *RemoteURL) HTTPS() string {
+	if me.Host == "dev.azure.com" {
+		return "https://" + me.Host + "/" + me.Namespace + "/_git/" + me.Name
+	}
+	return "https://" + me.Host + "/" + me.Namespace + "/" + me.Name
+}
+
+// selectRemote returns the url of the named remote from the remote name to url mapping. An empty
+// name selects the default remote, or the first remote by name if there is no default remote.
+func selectRemote(name string, urls map[string]string) (string, error) {
+	if name != "" {
+		if u, ok := urls[name]; ok && u != "" {
+			return u, nil
+		}
+		return "", errors.Errorf("could not get remote URL for remote '%s'", name)
+	}
+
+	if u, ok := urls[DefaultRemoteName]; ok && u != "" {
+		return u, nil
+	}
+
+	names := make([]string, 0, len(urls))
+	for n := range urls {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		if urls[n] != "" {
+			return urls[n], nil
+		}
+	}
+
+	return "", errors.Errorf("could not get remote URL")
+}
@@ -0,0 +1,161 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+// testRepo is a temporary on disk repository built with go-git.
+type testRepo struct {
+	t    testing.TB
+	dir  string
+	repo *gogit.Repository
+	when time.Time
+}
+
+func newTestRepo(t testing.TB) *testRepo {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	err = repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.Main))
+	require.NoError(t, err)
+
+	return &testRepo{
+		t:    t,
+		dir:  dir,
+		repo: repo,
+		when: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (me *testRepo) provider() *git.GitGoGitProvider {
+	me.t.Helper()
+
+	prov, err := git.NewGitGoGitProvider(afero.NewOsFs(), me.dir)
+	require.NoError(me.t, err)
+
+	return prov
+}
+
+// backends are the git backends every GitProvider test runs against.
+var backends = []string{git.GitBackendGoGit, git.GitBackendExec}
+
+func (me *testRepo) open(backend string) git.GitProvider {
+	me.t.Helper()
+
+	prov, err := git.NewGitProvider(afero.NewOsFs(), me.dir, backend)
+	require.NoError(me.t, err)
+
+	return prov
+}
+
+func (me *testRepo) write(files map[string]string) {
+	me.t.Helper()
+
+	wt, err := me.repo.Worktree()
+	require.NoError(me.t, err)
+
+	for name, content := range files {
+		path := filepath.Join(me.dir, name)
+		require.NoError(me.t, os.MkdirAll(filepath.Dir(path), 0755))
+		if content == "" {
+			require.NoError(me.t, os.Remove(path))
+			_, err = wt.Remove(name)
+		} else {
+			require.NoError(me.t, os.WriteFile(path, []byte(content), 0600))
+			_, err = wt.Add(name)
+		}
+		require.NoError(me.t, err)
+	}
+}
+
+// commit writes the files (an empty value deletes the file) and commits them on top of HEAD,
+// or on top of the given parents if any are passed.
+func (me *testRepo) commit(message string, files map[string]string, parents ...string) string {
+	me.t.Helper()
+
+	me.write(files)
+
+	wt, err := me.repo.Worktree()
+	require.NoError(me.t, err)
+
+	me.when = me.when.Add(time.Minute)
+
+	opts := &gogit.CommitOptions{
+		AllowEmptyCommits: true,
+		Author: &object.Signature{
+			Name:  "buildrc",
+			Email: "buildrc@example.com",
+			When:  me.when,
+		},
+	}
+
+	for _, p := range parents {
+		opts.Parents = append(opts.Parents, plumbing.NewHash(p))
+	}
+
+	hash, err := wt.Commit(message, opts)
+	require.NoError(me.t, err)
+
+	return hash.String()
+}
+
+func (me *testRepo) tag(name string, hash string, annotated bool) {
+	me.t.Helper()
+
+	var opts *gogit.CreateTagOptions
+	if annotated {
+		opts = &gogit.CreateTagOptions{
+			Message: "release " + name,
+			Tagger: &object.Signature{
+				Name:  "buildrc",
+				Email: "buildrc@example.com",
+				When:  me.when,
+			},
+		}
+	}
+
+	_, err := me.repo.CreateTag(name, plumbing.NewHash(hash), opts)
+	require.NoError(me.t, err)
+}
+
+// checkout moves HEAD to the branch, creating it at the given hash if it does not exist.
+func (me *testRepo) checkout(branch string, hash string) {
+	me.t.Helper()
+
+	wt, err := me.repo.Worktree()
+	require.NoError(me.t, err)
+
+	name := plumbing.NewBranchReferenceName(branch)
+
+	_, err = me.repo.Reference(name, false)
+	create := err != nil
+
+	opts := &gogit.CheckoutOptions{Branch: name, Create: create, Force: true}
+	if create && hash != "" {
+		opts.Hash = plumbing.NewHash(hash)
+	}
+
+	require.NoError(me.t, wt.Checkout(opts))
+}
+
+func (me *testRepo) remote(name string, url string) {
+	me.t.Helper()
+
+	_, err := me.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+	require.NoError(me.t, err)
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// SourceDateEpochEnv is the environment variable reproducible builds set to the time to use instead of
+// the current time, see https://reproducible-builds.org/specs/source-date-epoch.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+var ErrInvalidSourceDateEpoch = errors.New("git.ErrInvalidSourceDateEpoch")
+
+// Now returns the time of SOURCE_DATE_EPOCH in UTC if it is set, otherwise the current time.
+// A malformed value is an error rather than silently falling back to the current time.
+func Now() (time.Time, error) {
+	raw := strings.TrimSpace(os.Getenv(SourceDateEpochEnv))
+	if raw == "" {
+		return time.Now(), nil
+	}
+
+	secs, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, errors.Wrapf(ErrInvalidSourceDateEpoch, "%s=%q must be a non-negative integer of seconds since the unix epoch", SourceDateEpochEnv, raw)
+	}
+
+	return time.Unix(secs, 0).UTC(), nil
+}
@@ -0,0 +1,29 @@
+package git_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+func TestNowSourceDateEpoch(t *testing.T) {
+	t.Setenv(git.SourceDateEpochEnv, "1700000000")
+
+	now, err := git.Now()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), now)
+
+	t.Setenv(git.SourceDateEpochEnv, "yesterday")
+
+	_, err = git.Now()
+	assert.ErrorIs(t, err, git.ErrInvalidSourceDateEpoch)
+
+	t.Setenv(git.SourceDateEpochEnv, "")
+
+	now, err = git.Now()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), now, time.Minute)
+}
@@ -18,14 +18,22 @@ const (
 	TagStrategyCommitToNewPR      TagStragegy = "commit-to-new-pr"
 )
 
-func CalculateTagStrategy(ctx context.Context, git GitProvider, prp PullRequestProvider) (TagStragegy, *semver.Version, *PullRequest, error) {
+const DefaultMainBranch = "main"
+
+// CalculateTagStrategy works out how HEAD relates to the main branch and its pull requests.
+// If mainBranch is empty, DefaultMainBranch is used.
+func CalculateTagStrategy(ctx context.Context, git GitProvider, prp PullRequestProvider, mainBranch string) (TagStragegy, *semver.Version, *PullRequest, error) {
+
+	if mainBranch == "" {
+		mainBranch = DefaultMainBranch
+	}
 
 	latestHead, err := git.GetLatestSemverTagFromRef(ctx, "HEAD")
 	if err != nil {
 		return "", nil, nil, err
 	}
 
-	latestMain, err := git.GetLatestSemverTagFromRef(ctx, "main")
+	latestMain, err := git.GetLatestSemverTagFromRef(ctx, mainBranch)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -50,7 +58,7 @@ func CalculateTagStrategy(ctx context.Context, git GitProvider, prp PullRequestP
 		return "", nil, nil, err
 	}
 
-	if brnch != "main" {
+	if brnch != mainBranch {
 		if pr != nil {
 			if pr.Closed {
 				return "", nil, nil, errors.New("pr is closed - please create a new pr")
@@ -67,7 +75,7 @@ func CalculateTagStrategy(ctx context.Context, git GitProvider, prp PullRequestP
 		return "", nil, nil, errors.New("no pr found - please create a pr")
 	}
 
-	pr, err = getLatestMergedPullRequestThatHasAMatchingContentHash(ctx, prp, git)
+	pr, err = getLatestMergedPullRequestThatHasAMatchingContentHash(ctx, prp, git, mainBranch)
 	if err != nil {
 		if errors.Is(err, ErrNoMatchingPR) {
 			// then this is a direct commit to main
@@ -92,11 +100,12 @@ func CalculateTagStrategy(ctx context.Context, git GitProvider, prp PullRequestP
 
 }
 
-func CalculateNextPreReleaseTag(ctx context.Context, brc uint64, git GitProvider, prp PullRequestProvider) (*semver.Version, error) {
+// CalculateNextPreReleaseTag returns the next version for HEAD along with the strategy used to calculate it.
+func CalculateNextPreReleaseTag(ctx context.Context, brc uint64, git GitProvider, prp PullRequestProvider, mainBranch string) (*semver.Version, TagStragegy, error) {
 
-	strategy, last, pr, err := CalculateTagStrategy(ctx, git, prp)
+	strategy, last, pr, err := CalculateTagStrategy(ctx, git, prp, mainBranch)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	brcv := semver.New(brc, 0, 0, "", "")
@@ -109,37 +118,37 @@ func CalculateNextPreReleaseTag(ctx context.Context, brc uint64, git GitProvider
 
 	cmt, err := git.GetCurrentShortHashFromRef(ctx, "HEAD")
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	switch strategy {
 	case TagStrategyCommitToMain:
 		strt := last.IncPatch()
-		return &strt, nil
+		return &strt, strategy, nil
 	case TagStrategySquashMerge, TagStrategyMerge:
-		return semver.New(last.Major(), last.Minor(), last.Patch(), "", ""), nil
+		return semver.New(last.Major(), last.Minor(), last.Patch(), "", ""), strategy, nil
 	case TagStrategyCommitToExistingPR:
 		strt, err := last.SetMetadata(cmt)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		return &strt, nil
+		return &strt, strategy, nil
 	case TagStrategyCommitToNewPR:
 		if pr == nil {
-			return nil, errors.New("no pr found in commit to new pr strategy")
+			return nil, "", errors.New("no pr found in commit to new pr strategy")
 		}
 		strt := last.IncMinor()
 		strt, err = strt.SetPrerelease(pr.PreReleaseTag())
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		strt, err = strt.SetMetadata(cmt)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		return &strt, nil
+		return &strt, strategy, nil
 	default:
-		return nil, errors.New("unknown tag strategy")
+		return nil, "", errors.New("unknown tag strategy")
 	}
 }
 
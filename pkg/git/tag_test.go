@@ -27,7 +27,7 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 		gitp := &mockery.MockGitProvider_git{}
 		prp := &mockery.MockPullRequestProvider_git{}
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "HEAD").Return(nil, errors.New("error"))
-		_, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		_, _, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.Error(t, err)
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 
@@ -38,7 +38,7 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 		prp := &mockery.MockPullRequestProvider_git{}
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "HEAD").Return(mustParseSemver(t, "1.0.0"), nil)
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "main").Return(nil, errors.New("error"))
-		_, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		_, _, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.Error(t, err)
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 
@@ -50,7 +50,7 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "HEAD").Return(mustParseSemver(t, "1.0.0"), nil)
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "main").Return(mustParseSemver(t, "1.0.0"), nil)
 		prp.EXPECT().ListRecentPullRequests(ctx, "HEAD").Return(nil, errors.New("error"))
-		_, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		_, _, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.Error(t, err)
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 
@@ -77,9 +77,10 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 
 		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "xyz").Return(mustParseSemver(t, "2.0.0-prerelease"), nil)
 
-		res, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		res, strategy, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.NoError(t, err)
 		assert.Equal(t, "2.0.0", res.String()) // assuming versioning works this way
+		assert.Equal(t, git.TagStrategySquashMerge, strategy)
 
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 	})
@@ -100,9 +101,10 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 			},
 		}, nil)
 
-		res, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		res, strategy, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.NoError(t, err)
 		assert.Equal(t, "2.0.0-pr.99+abc", res.String()) // assuming versioning works this way
+		assert.Equal(t, git.TagStrategyCommitToExistingPR, strategy)
 
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 	})
@@ -123,9 +125,10 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 			},
 		}, nil)
 
-		res, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		res, strategy, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.NoError(t, err)
 		assert.Equal(t, "1.1.0-pr.99+abc", res.String()) // assuming versioning works this way
+		assert.Equal(t, git.TagStrategyCommitToNewPR, strategy)
 
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 	})
@@ -141,9 +144,29 @@ func TestCalculateNextPreReleaseTag(t *testing.T) {
 		prp.EXPECT().ListRecentPullRequests(ctx, "HEAD").Return([]*git.PullRequest{}, nil)
 
 		gitp.EXPECT().GetContentHashFromRef(ctx, "HEAD").Return("abc", nil)
-		res, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp)
+		res, strategy, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "")
 		require.NoError(t, err)
 		assert.Equal(t, "1.0.1", res.String()) // assuming versioning works this way
+		assert.Equal(t, git.TagStrategyCommitToMain, strategy)
+
+		mock.AssertExpectationsForObjects(t, gitp, prp)
+	})
+
+	t.Run("commit to configured main branch", func(t *testing.T) {
+		gitp := &mockery.MockGitProvider_git{}
+		prp := &mockery.MockPullRequestProvider_git{}
+		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "HEAD").Return(mustParseSemver(t, "1.0.0"), nil)
+		gitp.EXPECT().GetLatestSemverTagFromRef(ctx, "trunk").Return(mustParseSemver(t, "1.0.0"), nil)
+		gitp.EXPECT().GetCurrentBranchFromRef(ctx, "HEAD").Return("trunk", nil)
+		prp.EXPECT().ListRecentPullRequests(ctx, "trunk").Return([]*git.PullRequest{}, nil)
+		gitp.EXPECT().GetCurrentShortHashFromRef(ctx, "HEAD").Return("abc", nil)
+		prp.EXPECT().ListRecentPullRequests(ctx, "HEAD").Return([]*git.PullRequest{}, nil)
+
+		gitp.EXPECT().GetContentHashFromRef(ctx, "HEAD").Return("abc", nil)
+		res, strategy, err := git.CalculateNextPreReleaseTag(ctx, brc, gitp, prp, "trunk")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.1", res.String())
+		assert.Equal(t, git.TagStrategyCommitToMain, strategy)
 
 		mock.AssertExpectationsForObjects(t, gitp, prp)
 	})
@@ -0,0 +1,133 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+const (
+	// DefaultPerPage is the largest page the default MAX_RESPONSE_ITEMS setting allows
+	DefaultPerPage = 50
+)
+
+var ErrNotFound = errors.New("gitea.ErrNotFound")
+
+var (
+	_ git.PullRequestProvider              = (*Client)(nil)
+	_ git.ReleaseProvider                  = (*Client)(nil)
+	_ git.RemoteRepositoryMetadataProvider = (*Client)(nil)
+)
+
+type Options struct {
+	// BaseURL is the API root, https://<host>/api/v1
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	PerPage int
+	// CI is used to find the branch and pull request of a detached HEAD
+	CI ci.Detector
+}
+
+// Client is a Gitea API client for a single repository. Forgejo serves the same API.
+type Client struct {
+	api   *forgeapi.Client
+	owner string
+	repo  string
+	gitp  git.GitProvider
+	ci    ci.Detector
+}
+
+// NewHTTPClient returns an http client that authenticates with the token, if there is one.
+func NewHTTPClient(ctx context.Context, token string) *http.Client {
+	return forgeapi.NewHTTPClient(ctx, token, "token")
+}
+
+// DetectOptions returns the options for the remote and whether it is hosted on Gitea or Forgejo, which is
+// either codeberg.org, a host named gitea.*, forgejo.* or codeberg.*, or the server of the Gitea Actions
+// workflow that is running.
+func DetectOptions(remote *git.RemoteURL, getenv func(string) string) (*Options, bool) {
+	opts := &Options{
+		BaseURL: "https://" + remote.Host + "/api/v1",
+		Token:   getenv("GITEA_TOKEN"),
+		Owner:   remote.Namespace,
+		Repo:    remote.Name,
+	}
+
+	// gitea actions mimics github actions, including its variables
+	if getenv("GITEA_ACTIONS") == "true" {
+		if srv, err := url.Parse(getenv("GITHUB_SERVER_URL")); err == nil && srv.Host != "" && strings.EqualFold(srv.Hostname(), remote.Host) {
+			if api := getenv("GITHUB_API_URL"); api != "" {
+				opts.BaseURL = api
+			}
+			if opts.Token == "" {
+				opts.Token = getenv("GITHUB_TOKEN")
+			}
+			return opts, true
+		}
+	}
+
+	if remote.Host == "codeberg.org" {
+		return opts, true
+	}
+
+	for _, prefix := range []string{"gitea.", "forgejo.", "codeberg."} {
+		if strings.HasPrefix(remote.Host, prefix) {
+			return opts, true
+		}
+	}
+
+	return opts, false
+}
+
+// NewClient returns a client for the repository. The git provider is used to resolve the
+// branch of HEAD when listing its pull requests, falling back to the ci environment when detached.
+func NewClient(ctx context.Context, gitp git.GitProvider, opts *Options) (*Client, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, errors.Errorf("gitea owner and repo are required")
+	}
+
+	if opts.BaseURL == "" {
+		return nil, errors.Errorf("gitea api url is required")
+	}
+
+	if _, err := url.Parse(opts.BaseURL); err != nil {
+		return nil, errors.Wrapf(err, "invalid gitea api url '%s'", opts.BaseURL)
+	}
+
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+
+	return &Client{
+		api: &forgeapi.Client{
+			HTTP:        NewHTTPClient(ctx, opts.Token),
+			BaseURL:     strings.TrimSuffix(opts.BaseURL, "/"),
+			Name:        "gitea",
+			Header:      http.Header{"Accept": {"application/json"}},
+			ErrNotFound: ErrNotFound,
+			// gitea pages with limit instead of per_page
+			Paging:       forgeapi.PagingCount,
+			PerPage:      perPage,
+			PerPageParam: "limit",
+		},
+		owner: opts.Owner,
+		repo:  opts.Repo,
+		gitp:  gitp,
+		ci:    opts.CI,
+	}, nil
+}
+
+// repoPath returns the api path of the repository joined with the elements.
+func (me *Client) repoPath(elems ...string) string {
+	parts := []string{"repos", url.PathEscape(me.owner), url.PathEscape(me.repo)}
+	return "/" + strings.Join(append(parts, elems...), "/")
+}
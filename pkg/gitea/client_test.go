@@ -0,0 +1,459 @@
+package gitea_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/gitea"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi/forgeapitest"
+)
+
+// newClient uses the api under /api/v1 of the server, fixture paths include it as downloads are served from the root.
+func newClient(t *testing.T, srv *forgeapitest.Server, gitp git.GitProvider) *gitea.Client {
+	t.Helper()
+
+	client, err := gitea.NewClient(context.Background(), gitp, &gitea.Options{
+		BaseURL: srv.API() + "/api/v1",
+		Token:   "abc",
+		Owner:   "platform",
+		Repo:    "buildrc",
+		PerPage: 2,
+	})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestDetectOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		env    map[string]string
+		want   *gitea.Options
+		ok     bool
+	}{
+		{
+			name:   "codeberg",
+			remote: "git@codeberg.org:platform/buildrc.git",
+			env:    map[string]string{"GITEA_TOKEN": "abc"},
+			want:   &gitea.Options{BaseURL: "https://codeberg.org/api/v1", Token: "abc", Owner: "platform", Repo: "buildrc"},
+			ok:     true,
+		},
+		{
+			name:   "self-hosted by name",
+			remote: "https://forgejo.example.com/platform/buildrc.git",
+			want:   &gitea.Options{BaseURL: "https://forgejo.example.com/api/v1", Owner: "platform", Repo: "buildrc"},
+			ok:     true,
+		},
+		{
+			name:   "self-hosted in gitea actions",
+			remote: "https://code.example.com/platform/buildrc.git",
+			env: map[string]string{
+				"GITEA_ACTIONS":     "true",
+				"GITHUB_SERVER_URL": "https://code.example.com",
+				"GITHUB_API_URL":    "https://code.example.com/api/v1",
+				"GITHUB_TOKEN":      "xyz",
+			},
+			want: &gitea.Options{BaseURL: "https://code.example.com/api/v1", Token: "xyz", Owner: "platform", Repo: "buildrc"},
+			ok:   true,
+		},
+		{
+			name:   "github actions",
+			remote: "https://github.com/walteh/buildrc.git",
+			env:    map[string]string{"GITHUB_SERVER_URL": "https://github.com"},
+			want:   &gitea.Options{BaseURL: "https://github.com/api/v1", Owner: "walteh", Repo: "buildrc"},
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := git.ParseRemoteURL(tt.remote)
+			require.NoError(t, err)
+
+			got, ok := gitea.DetectOptions(remote, func(k string) string { return tt.env[k] })
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetRemoteRepositoryMetadata(t *testing.T) {
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc", Status: 200, File: "repo.json"},
+	})
+
+	meta, err := newClient(t, srv, nil).GetRemoteRepositoryMetadata(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &git.RemoteRepositoryMetadata{
+		Description: "a tool to help with building releases",
+		Homepage:    "https://forgejo.example.com/platform/buildrc",
+		License:     "Apache-2.0",
+	}, meta)
+
+	require.Len(t, srv.Requests(), 1)
+	assert.Equal(t, "token abc", srv.Requests()[0].Header.Get("Authorization"))
+}
+
+func TestListRecentPullRequests(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page1.json",
+			Query: "limit=2&page=1&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page2.json",
+			Query: "limit=2&page=2&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+	})
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("feature", nil)
+
+	client := newClient(t, srv, gitp)
+
+	tests := []struct {
+		name string
+		head string
+		want []*git.PullRequest
+	}{
+		{
+			name: "HEAD matches the branch, including forks",
+			head: "HEAD",
+			want: []*git.PullRequest{
+				{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+				{Number: 40, Head: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Open: true},
+			},
+		},
+		{
+			name: "base",
+			head: "main",
+			want: []*git.PullRequest{
+				{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+				{Number: 41, Head: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Closed: true},
+				{Number: 39, Head: "cccccccccccccccccccccccccccccccccccccccc"},
+			},
+		},
+		{
+			name: "base with a slash",
+			head: "release/1.x",
+			want: []*git.PullRequest{
+				{Number: 40, Head: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Open: true},
+			},
+		},
+		{
+			name: "no match",
+			head: "develop",
+			want: []*git.PullRequest{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, err := client.ListRecentPullRequests(ctx, tt.head)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, prs)
+		})
+	}
+}
+
+func TestListRecentPullRequestsDetachedHead(t *testing.T) {
+	pages := []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page1.json",
+			Query: "limit=2&page=1&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page2.json",
+			Query: "limit=2&page=2&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+	}
+
+	// gitea actions sets the github actions variables
+	tests := []struct {
+		name     string
+		env      map[string]string
+		fixtures []forgeapitest.Fixture
+		want     []*git.PullRequest
+	}{
+		{
+			name: "pull request build",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_REF":        "refs/pull/42/head",
+				"GITHUB_HEAD_REF":   "feature",
+			},
+			fixtures: []forgeapitest.Fixture{
+				{Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls/42", Status: 200, File: "pull_42.json"},
+			},
+			want: []*git.PullRequest{
+				{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+			},
+		},
+		{
+			name: "branch build",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REF":        "refs/heads/feature",
+				"GITHUB_REF_NAME":   "feature",
+			},
+			fixtures: pages,
+			want: []*git.PullRequest{
+				{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+				{Number: 40, Head: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Open: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := forgeapitest.NewServer(t, "", tt.fixtures)
+
+			gitp := mockery.NewMockGitProvider_git(t)
+			gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("HEAD", nil)
+
+			client, err := gitea.NewClient(context.Background(), gitp, &gitea.Options{
+				BaseURL: srv.API() + "/api/v1",
+				Owner:   "platform",
+				Repo:    "buildrc",
+				PerPage: 2,
+				CI: ci.NewEnvDetector(func(key string) string {
+					return tt.env[key]
+				}),
+			})
+			require.NoError(t, err)
+
+			prs, err := client.ListRecentPullRequests(context.Background(), "HEAD")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, prs)
+		})
+	}
+
+	t.Run("outside of ci", func(t *testing.T) {
+		gitp := mockery.NewMockGitProvider_git(t)
+		gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("HEAD", nil)
+
+		srv := forgeapitest.NewServer(t, "", nil)
+
+		_, err := newClient(t, srv, gitp).ListRecentPullRequests(context.Background(), "HEAD")
+		assert.ErrorIs(t, err, git.ErrDetachedHead)
+		assert.Empty(t, srv.Requests())
+	})
+}
+
+func TestListPullRequestsUpdatedSince(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page1.json",
+			Query: "limit=2&page=1&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+		{
+			Method: "GET", Path: "/api/v1/repos/platform/buildrc/pulls", Status: 200, File: "pulls_page2.json",
+			Query: "limit=2&page=2&sort=recentupdate&state=all", Header: map[string]string{"X-Total-Count": "4"},
+		},
+	})
+
+	client := newClient(t, srv, mockery.NewMockGitProvider_git(t))
+
+	// the api cannot filter by base, every page is listed and the other bases are left out
+	prs, err := client.ListPullRequestsUpdatedSince(ctx, "main", time.Time{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*git.PullRequest{
+		{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+		{Number: 41, Head: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Closed: true},
+		// closed without merging
+		{Number: 39, Head: "cccccccccccccccccccccccccccccccccccccccc"},
+	}, prs)
+}
+
+func TestListRecentReleases(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases", Query: "limit=2&page=1", Status: 200, File: "releases_page1.json", Header: map[string]string{"X-Total-Count": "4"}},
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases", Query: "limit=2&page=2", Status: 200, File: "releases_page2.json", Header: map[string]string{"X-Total-Count": "4"}},
+	})
+
+	client := newClient(t, srv, nil)
+
+	tests := []struct {
+		name     string
+		limit    int
+		want     []string
+		requests int
+	}{
+		{name: "all pages", limit: 0, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}, requests: 2},
+		{name: "limited", limit: 1, want: []string{"v1.2.0"}, requests: 1},
+		{name: "limit beyond first page", limit: 3, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}, requests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.Reset()
+
+			releases, err := client.ListRecentReleases(ctx, tt.limit)
+			require.NoError(t, err)
+
+			tags := []string{}
+			for _, r := range releases {
+				tags = append(tags, r.Tag)
+			}
+
+			assert.Equal(t, tt.want, tags)
+			assert.Len(t, srv.Requests(), tt.requests)
+		})
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases/tags/v1.0.0", Status: 200, File: "release.json"},
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases/tags/v9.9.9", Status: 404, File: "not_found.json"},
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases/1", Status: 200, File: "release.json"},
+	})
+
+	client := newClient(t, srv, nil)
+
+	want := &git.Release{
+		ID:         "1",
+		CommitHash: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+		Tag:        "v1.0.0",
+		Artifacts:  []string{"buildrc-linux-amd64.tar.gz"},
+		Draft:      true,
+	}
+
+	rel, err := client.GetReleaseByTag(ctx, "v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, want, rel)
+
+	rel, err = client.GetReleaseByTag(ctx, "v9.9.9")
+	require.NoError(t, err)
+	assert.Nil(t, rel)
+
+	rel, err = client.GetReleaseByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, want, rel)
+
+	rel, err = client.GetReleaseByID(ctx, "2")
+	require.NoError(t, err)
+	assert.Nil(t, rel)
+
+	ok, err := client.HasReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestTagRelease(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "POST", Path: "/api/v1/repos/platform/buildrc/releases", Status: 201, File: "release_created.json"},
+		{Method: "PATCH", Path: "/api/v1/repos/platform/buildrc/releases/4", Status: 200, File: "release_created.json"},
+	})
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return("6dcb09b5b57875f334f61aebed695e2e4193db5e", nil)
+
+	client := newClient(t, srv, gitp)
+
+	rel, err := client.TagRelease(ctx, gitp, semver.MustParse("1.3.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "4", rel.ID)
+	assert.True(t, rel.Draft)
+
+	require.NoError(t, client.TakeReleaseOutOfDraft(ctx, "4"))
+
+	require.Len(t, srv.Requests(), 2)
+	assert.JSONEq(t, `{
+		"tag_name": "v1.3.0",
+		"target_commitish": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+		"name": "v1.3.0",
+		"draft": true,
+		"prerelease": false
+	}`, srv.Requests()[0].Body)
+	assert.JSONEq(t, `{"draft": false}`, srv.Requests()[1].Body)
+}
+
+func TestReleaseArtifacts(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/api/v1/repos/platform/buildrc/releases/1", Status: 200, File: "release.json"},
+		{Method: "POST", Path: "/api/v1/repos/platform/buildrc/releases/1/assets", Status: 201, Body: `{"id": 11}`},
+		{Method: "DELETE", Path: "/api/v1/repos/platform/buildrc/releases/1/assets/10", Status: 204},
+		{Method: "GET", Path: "/platform/buildrc/releases/download/v1.0.0/buildrc-linux-amd64.tar.gz", Status: 200, Body: "hello"},
+	})
+
+	client := newClient(t, srv, nil)
+
+	t.Run("upload", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "buildrc-darwin-arm64.tar.gz", []byte("hello world"), 0644))
+
+		fle, err := fs.Open("buildrc-darwin-arm64.tar.gz")
+		require.NoError(t, err)
+		defer fle.Close()
+
+		srv.Reset()
+		require.NoError(t, client.UploadReleaseArtifact(ctx, "1", "buildrc-darwin-arm64.tar.gz", fle))
+
+		require.Len(t, srv.Requests(), 1)
+		assert.Equal(t, "name=buildrc-darwin-arm64.tar.gz", srv.Requests()[0].Query)
+
+		_, params, err := mime.ParseMediaType(srv.Requests()[0].Header.Get("Content-Type"))
+		require.NoError(t, err)
+
+		form, err := multipart.NewReader(strings.NewReader(srv.Requests()[0].Body), params["boundary"]).ReadForm(1 << 20)
+		require.NoError(t, err)
+		require.Len(t, form.File["attachment"], 1)
+
+		part, err := form.File["attachment"][0].Open()
+		require.NoError(t, err)
+		byt, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		assert.Equal(t, "buildrc-darwin-arm64.tar.gz", form.File["attachment"][0].Filename)
+		assert.Equal(t, "hello world", string(byt))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, client.DeleteReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz"))
+
+		err := client.DeleteReleaseArtifact(ctx, "1", "missing.tar.gz")
+		assert.ErrorIs(t, err, gitea.ErrNotFound)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		fle, err := client.DownloadReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz", afero.NewMemMapFs())
+		require.NoError(t, err)
+		defer fle.Close()
+
+		byt, err := io.ReadAll(fle)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(byt))
+	})
+}
@@ -0,0 +1,111 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+// recentPullRequests is how many of the most recently updated pull requests are searched, as the
+// api cannot filter by branch.
+const recentPullRequests = 100
+
+type pullRequestPayload struct {
+	Number    int       `json:"number"`
+	State     string    `json:"state"`
+	Merged    bool      `json:"merged"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Head      struct {
+		Ref   string `json:"ref"`
+		Label string `json:"label"`
+		SHA   string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (me *pullRequestPayload) pullRequest() *git.PullRequest {
+	return &git.PullRequest{
+		Number:  me.Number,
+		Head:    me.Head.SHA,
+		Open:    me.State == "open",
+		Closed:  me.Merged,
+		Updated: me.UpdatedAt,
+	}
+}
+
+// ListRecentPullRequests lists the most recently updated pull requests. For HEAD, those are the
+// pull requests opened from the current branch, or the pull request the ci is building when HEAD
+// is detached. For any other branch those targeting it.
+func (me *Client) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
+	match := func(p *pullRequestPayload) bool { return p.Base.Ref == head }
+
+	if head == "HEAD" {
+		branch, number, err := ci.CheckedOutHead(ctx, me.gitp, me.ci)
+		if err != nil {
+			return nil, err
+		}
+
+		if number > 0 {
+			var p pullRequestPayload
+			if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath("pulls", strconv.FormatUint(number, 10)), nil, "", &p); err != nil {
+				return nil, err
+			}
+			return []*git.PullRequest{p.pullRequest()}, nil
+		}
+
+		// pull requests from forks are labeled owner:branch, the ref is the branch in the fork
+		match = func(p *pullRequestPayload) bool { return p.Head.Ref == branch || p.Head.Label == branch }
+	}
+
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "recentupdate")
+
+	payloads, err := forgeapi.Paginate[pullRequestPayload](ctx, me.api, me.repoPath("pulls"), query, recentPullRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []*git.PullRequest{}
+	for i := range payloads {
+		p := &payloads[i]
+		if !match(p) {
+			continue
+		}
+		prs = append(prs, p.pullRequest())
+	}
+
+	return prs, nil
+}
+
+// ListPullRequestsUpdatedSince lists the pull requests targeting the branch, requesting pages until
+// one was last updated before since. The api cannot filter by branch, so every pull request is listed.
+func (me *Client) ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "recentupdate")
+
+	payloads, err := forgeapi.PaginateUntil(ctx, me.api, me.repoPath("pulls"), query, func(p *pullRequestPayload) bool {
+		return p.UpdatedAt.Before(since)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []*git.PullRequest{}
+	for i := range payloads {
+		if payloads[i].Base.Ref == base {
+			prs = append(prs, payloads[i].pullRequest())
+		}
+	}
+
+	return prs, nil
+}
@@ -0,0 +1,210 @@
+package gitea
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+type releaseAssetPayload struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type releasePayload struct {
+	ID              int64                 `json:"id"`
+	TagName         string                `json:"tag_name"`
+	TargetCommitish string                `json:"target_commitish"`
+	Draft           bool                  `json:"draft"`
+	Assets          []releaseAssetPayload `json:"assets"`
+}
+
+func (me *releasePayload) release() *git.Release {
+	names := make([]string, 0, len(me.Assets))
+	for _, a := range me.Assets {
+		names = append(names, a.Name)
+	}
+
+	return &git.Release{
+		ID:         strconv.FormatInt(me.ID, 10),
+		CommitHash: me.TargetCommitish,
+		Tag:        me.TagName,
+		Artifacts:  names,
+		Draft:      me.Draft,
+	}
+}
+
+func (me *releasePayload) asset(name string) *releaseAssetPayload {
+	for i := range me.Assets {
+		if me.Assets[i].Name == name {
+			return &me.Assets[i]
+		}
+	}
+	return nil
+}
+
+func (me *Client) getRelease(ctx context.Context, id string) (*releasePayload, error) {
+	var p releasePayload
+	if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath("releases", url.PathEscape(id)), nil, "", &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetReleaseByTag returns the release for the tag, or nil if there is none.
+func (me *Client) GetReleaseByTag(ctx context.Context, tag string) (*git.Release, error) {
+	var p releasePayload
+
+	if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath("releases", "tags", url.PathEscape(tag)), nil, "", &p); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return p.release(), nil
+}
+
+// GetReleaseByID returns the release with the id, or nil if there is none.
+func (me *Client) GetReleaseByID(ctx context.Context, id string) (*git.Release, error) {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return p.release(), nil
+}
+
+// ListRecentReleases returns up to limit releases, newest first. Drafts are included.
+func (me *Client) ListRecentReleases(ctx context.Context, limit int) ([]*git.Release, error) {
+	payloads, err := forgeapi.Paginate[releasePayload](ctx, me.api, me.repoPath("releases"), nil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*git.Release, 0, len(payloads))
+	for i := range payloads {
+		releases = append(releases, payloads[i].release())
+	}
+
+	return releases, nil
+}
+
+// TagRelease creates a draft release for the version at the HEAD commit. The tag is created
+// when the release is published.
+func (me *Client) TagRelease(ctx context.Context, prov git.GitProvider, vers *semver.Version) (*git.Release, error) {
+	commit, err := prov.GetCurrentCommitFromRef(ctx, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	tag := git.TagName(vers)
+
+	var p releasePayload
+
+	err = me.api.DoJSON(ctx, http.MethodPost, me.repoPath("releases"), map[string]any{
+		"tag_name":         tag,
+		"target_commitish": commit,
+		"name":             tag,
+		"draft":            true,
+		"prerelease":       vers.Prerelease() != "",
+	}, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("tag", tag).Int64("id", p.ID).Msg("created draft release")
+
+	return p.release(), nil
+}
+
+func (me *Client) TakeReleaseOutOfDraft(ctx context.Context, id string) error {
+	return me.api.DoJSON(ctx, http.MethodPatch, me.repoPath("releases", url.PathEscape(id)), map[string]any{
+		"draft": false,
+	}, nil)
+}
+
+func (me *Client) HasReleaseArtifact(ctx context.Context, id string, name string) (bool, error) {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return p.asset(name) != nil, nil
+}
+
+// UploadReleaseArtifact uploads the file as the attachment field of a multipart form, which is
+// streamed rather than buffered.
+func (me *Client) UploadReleaseArtifact(ctx context.Context, id string, name string, file afero.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := form.CreateFormFile("attachment", name)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	target := me.repoPath("releases", url.PathEscape(id), "assets") + "?" + url.Values{"name": {name}}.Encode()
+
+	_, err := me.api.Do(ctx, http.MethodPost, target, pr, form.FormDataContentType(), nil)
+	if err != nil {
+		// unblock the writer if the request failed before the body was read
+		_ = pr.CloseWithError(err)
+	}
+	return err
+}
+
+func (me *Client) DeleteReleaseArtifact(ctx context.Context, id string, name string) error {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	asset := p.asset(name)
+	if asset == nil {
+		return errors.Wrapf(ErrNotFound, "artifact %s in release %s", name, id)
+	}
+
+	_, err = me.api.Do(ctx, http.MethodDelete, me.repoPath("releases", url.PathEscape(id), "assets", strconv.FormatInt(asset.ID, 10)), nil, "", nil)
+	return err
+}
+
+// DownloadReleaseArtifact writes the artifact to a file of the same name in the filesystem
+// and returns it, positioned at the start. Gitea serves assets from their browser download url.
+func (me *Client) DownloadReleaseArtifact(ctx context.Context, id string, name string, filesystem afero.Fs) (afero.File, error) {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	asset := p.asset(name)
+	if asset == nil {
+		return nil, errors.Wrapf(ErrNotFound, "artifact %s in release %s", name, id)
+	}
+
+	return me.api.Download(ctx, asset.BrowserDownloadURL, "application/octet-stream", filesystem, name)
+}
@@ -0,0 +1,40 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+type repositoryPayload struct {
+	Description string   `json:"description"`
+	Website     string   `json:"website"`
+	HTMLURL     string   `json:"html_url"`
+	Licenses    []string `json:"licenses"`
+}
+
+// GetRemoteRepositoryMetadata returns the repository metadata. The license is only
+// reported by servers that detect licenses, Gitea 1.22 and later.
+func (me *Client) GetRemoteRepositoryMetadata(ctx context.Context) (*git.RemoteRepositoryMetadata, error) {
+	var p repositoryPayload
+
+	if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath(), nil, "", &p); err != nil {
+		return nil, err
+	}
+
+	meta := &git.RemoteRepositoryMetadata{
+		Description: p.Description,
+		Homepage:    p.Website,
+	}
+
+	if meta.Homepage == "" {
+		meta.Homepage = p.HTMLURL
+	}
+
+	if len(p.Licenses) > 0 {
+		meta.License = p.Licenses[0]
+	}
+
+	return meta, nil
+}
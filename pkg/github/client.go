@@ -0,0 +1,116 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+const (
+	DefaultHost    = "github.com"
+	DefaultBaseURL = "https://api.github.com"
+	DefaultPerPage = 100
+)
+
+var ErrNotFound = errors.New("github.ErrNotFound")
+
+var (
+	_ git.PullRequestProvider              = (*Client)(nil)
+	_ git.ReleaseProvider                  = (*Client)(nil)
+	_ git.RemoteRepositoryMetadataProvider = (*Client)(nil)
+)
+
+type Options struct {
+	// BaseURL is the REST API root, https://<host>/api/v3 for GitHub Enterprise Server
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+	PerPage int
+	// CI is used to find the branch and pull request of a detached HEAD
+	CI ci.Detector
+}
+
+// Client is a GitHub REST API client for a single repository.
+type Client struct {
+	api   *forgeapi.Client
+	owner string
+	repo  string
+	gitp  git.GitProvider
+	ci    ci.Detector
+}
+
+// NewHTTPClient returns an http client that authenticates with the token, if there is one.
+func NewHTTPClient(ctx context.Context, token string) *http.Client {
+	return forgeapi.NewHTTPClient(ctx, token, "")
+}
+
+// DetectOptions returns the options for the remote and whether it is hosted on GitHub, which is either
+// github.com or the server of the GitHub Actions workflow that is running.
+func DetectOptions(remote *git.RemoteURL, getenv func(string) string) (*Options, bool) {
+	opts := &Options{
+		Token: getenv("GITHUB_TOKEN"),
+		Owner: remote.Namespace,
+		Repo:  remote.Name,
+	}
+
+	if srv, err := url.Parse(getenv("GITHUB_SERVER_URL")); err == nil && srv.Host != "" && strings.EqualFold(srv.Hostname(), remote.Host) {
+		opts.BaseURL = getenv("GITHUB_API_URL")
+		return opts, true
+	}
+
+	return opts, remote.Host == DefaultHost
+}
+
+// NewClient returns a client for the repository. The git provider is used to resolve the
+// branch of HEAD when listing its pull requests, falling back to the ci environment when detached.
+func NewClient(ctx context.Context, gitp git.GitProvider, opts *Options) (*Client, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, errors.Errorf("github owner and repo are required")
+	}
+
+	base := opts.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+
+	if _, err := url.Parse(base); err != nil {
+		return nil, errors.Wrapf(err, "invalid github api url '%s'", base)
+	}
+
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+
+	return &Client{
+		api: &forgeapi.Client{
+			HTTP:    NewHTTPClient(ctx, opts.Token),
+			BaseURL: strings.TrimSuffix(base, "/"),
+			Name:    "github",
+			Header: http.Header{
+				"Accept":               {"application/vnd.github+json"},
+				"X-Github-Api-Version": {"2022-11-28"},
+			},
+			ErrNotFound: ErrNotFound,
+			Paging:      forgeapi.PagingLink,
+			PerPage:     perPage,
+		},
+		owner: opts.Owner,
+		repo:  opts.Repo,
+		gitp:  gitp,
+		ci:    opts.CI,
+	}, nil
+}
+
+// repoPath returns the api path of the repository joined with the elements.
+func (me *Client) repoPath(elems ...string) string {
+	parts := []string{"repos", url.PathEscape(me.owner), url.PathEscape(me.repo)}
+	return "/" + strings.Join(append(parts, elems...), "/")
+}
@@ -0,0 +1,457 @@
+package github_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/github"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi/forgeapitest"
+)
+
+func newClient(t *testing.T, srv *forgeapitest.Server, gitp git.GitProvider) *github.Client {
+	t.Helper()
+
+	client, err := github.NewClient(context.Background(), gitp, &github.Options{
+		BaseURL: srv.API(),
+		Token:   "abc",
+		Owner:   "walteh",
+		Repo:    "buildrc",
+		PerPage: 2,
+	})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := github.NewClient(context.Background(), nil, &github.Options{Owner: "walteh"})
+	assert.Error(t, err)
+
+	_, err = github.NewClient(context.Background(), nil, &github.Options{Owner: "walteh", Repo: "buildrc"})
+	assert.NoError(t, err)
+}
+
+func TestGetRemoteRepositoryMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{name: "github.com", prefix: ""},
+		{name: "enterprise", prefix: "/api/v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := forgeapitest.NewServer(t, tt.prefix, []forgeapitest.Fixture{
+				{Method: "GET", Path: "/repos/walteh/buildrc", Status: 200, File: "repo.json"},
+			})
+
+			meta, err := newClient(t, srv, nil).GetRemoteRepositoryMetadata(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, &git.RemoteRepositoryMetadata{
+				Description: "a tool to help with building releases",
+				Homepage:    "https://github.com/walteh/buildrc",
+				License:     "Apache-2.0",
+			}, meta)
+
+			require.Len(t, srv.Requests(), 1)
+			assert.Equal(t, "Bearer abc", srv.Requests()[0].Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestListRecentPullRequests(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_head.json",
+			Query: "direction=desc&head=walteh%3Afeature&per_page=2&sort=updated&state=all",
+		},
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_base_page1.json",
+			Query:  "base=main&direction=desc&per_page=2&sort=updated&state=all",
+			Header: map[string]string{"Link": `<{{server}}/repos/walteh/buildrc/pulls?base=main&page=2&per_page=2>; rel="next", <{{server}}/repos/walteh/buildrc/pulls?base=main&page=2&per_page=2>; rel="last"`},
+		},
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_base_page2.json",
+			Query: "base=main&page=2&per_page=2",
+		},
+	})
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("feature", nil)
+
+	client := newClient(t, srv, gitp)
+
+	t.Run("HEAD", func(t *testing.T) {
+		prs, err := client.ListRecentPullRequests(ctx, "HEAD")
+		require.NoError(t, err)
+
+		assert.Equal(t, []*git.PullRequest{
+			{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+		}, prs)
+	})
+
+	t.Run("base", func(t *testing.T) {
+		// the page size is 2, so the limit is reached on the first page
+		prs, err := client.ListRecentPullRequests(ctx, "main")
+		require.NoError(t, err)
+
+		assert.Equal(t, []*git.PullRequest{
+			{Number: 41, Head: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Closed: true},
+			{Number: 40, Head: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Open: true},
+		}, prs)
+	})
+}
+
+func TestListRecentPullRequestsDetachedHead(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		fixture forgeapitest.Fixture
+	}{
+		{
+			name: "pull request build",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_REF":        "refs/pull/42/merge",
+				"GITHUB_HEAD_REF":   "feature",
+			},
+			fixture: forgeapitest.Fixture{Method: "GET", Path: "/repos/walteh/buildrc/pulls/42", Status: 200, File: "pull_42.json"},
+		},
+		{
+			name: "branch build",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REF":        "refs/heads/feature",
+				"GITHUB_REF_NAME":   "feature",
+			},
+			fixture: forgeapitest.Fixture{
+				Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_head.json",
+				Query: "direction=desc&head=walteh%3Afeature&per_page=2&sort=updated&state=all",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{tt.fixture})
+
+			gitp := mockery.NewMockGitProvider_git(t)
+			gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("HEAD", nil)
+
+			client, err := github.NewClient(context.Background(), gitp, &github.Options{
+				BaseURL: srv.API(),
+				Owner:   "walteh",
+				Repo:    "buildrc",
+				PerPage: 2,
+				CI: ci.NewEnvDetector(func(key string) string {
+					return tt.env[key]
+				}),
+			})
+			require.NoError(t, err)
+
+			prs, err := client.ListRecentPullRequests(context.Background(), "HEAD")
+			require.NoError(t, err)
+
+			assert.Equal(t, []*git.PullRequest{
+				{Number: 42, Head: "6dcb09b5b57875f334f61aebed695e2e4193db5e", Open: true},
+			}, prs)
+			require.Len(t, srv.Requests(), 1)
+		})
+	}
+
+	t.Run("outside of ci", func(t *testing.T) {
+		gitp := mockery.NewMockGitProvider_git(t)
+		gitp.EXPECT().GetCurrentBranchFromRef(mock.Anything, "HEAD").Return("HEAD", nil)
+
+		srv := forgeapitest.NewServer(t, "", nil)
+
+		_, err := newClient(t, srv, gitp).ListRecentPullRequests(context.Background(), "HEAD")
+		assert.ErrorIs(t, err, git.ErrDetachedHead)
+		assert.Empty(t, srv.Requests())
+	})
+}
+
+func TestListPullRequestsUpdatedSince(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_updated_page1.json",
+			Query:  "base=main&direction=desc&per_page=2&sort=updated&state=all",
+			Header: map[string]string{"Link": `<{{server}}/repos/walteh/buildrc/pulls?base=main&page=2&per_page=2>; rel="next"`},
+		},
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/pulls", Status: 200, File: "pulls_updated_page2.json",
+			Query:  "base=main&page=2&per_page=2",
+			Header: map[string]string{"Link": `<{{server}}/repos/walteh/buildrc/pulls?base=main&page=3&per_page=2>; rel="next"`},
+		},
+	})
+
+	client := newClient(t, srv, mockery.NewMockGitProvider_git(t))
+
+	prs, err := client.ListPullRequestsUpdatedSince(ctx, "main", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, []*git.PullRequest{
+		{Number: 41, Head: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Closed: true, Updated: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Number: 40, Head: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Open: true, Updated: time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)},
+		{Number: 38, Head: "dddddddddddddddddddddddddddddddddddddddd", Closed: true, Updated: time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC)},
+		// closed without merging
+		{Number: 37, Head: "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", Updated: time.Date(2024, 2, 5, 10, 0, 0, 0, time.UTC)},
+	}, prs)
+
+	// the second page reaches a pull request updated before the range, so the third is not requested
+	assert.Len(t, srv.Requests(), 2)
+}
+
+func TestListRecentReleases(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "/api/v3", []forgeapitest.Fixture{
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/releases", Status: 200, File: "releases_page1.json",
+			Query:  "per_page=2",
+			Header: map[string]string{"Link": `<{{server}}/repos/walteh/buildrc/releases?page=2&per_page=2>; rel="next"`},
+		},
+		{
+			Method: "GET", Path: "/repos/walteh/buildrc/releases", Status: 200, File: "releases_page2.json",
+			Query: "page=2&per_page=2",
+		},
+	})
+
+	client := newClient(t, srv, nil)
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{name: "all pages", limit: 0, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		{name: "limited", limit: 1, want: []string{"v1.2.0"}},
+		{name: "limit beyond first page", limit: 3, want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases, err := client.ListRecentReleases(ctx, tt.limit)
+			require.NoError(t, err)
+
+			tags := []string{}
+			for _, r := range releases {
+				tags = append(tags, r.Tag)
+			}
+
+			assert.Equal(t, tt.want, tags)
+		})
+	}
+
+	releases, err := client.ListRecentReleases(ctx, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, &git.Release{
+		ID:         "2",
+		CommitHash: "2222222222222222222222222222222222222222",
+		Tag:        "v1.1.0",
+		Artifacts:  []string{"buildrc-linux-amd64.tar.gz"},
+	}, releases[1])
+	assert.True(t, releases[0].Draft)
+}
+
+func TestGetRelease(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/tags/v1.0.0", Status: 200, File: "release.json"},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/tags/v9.9.9", Status: 404, File: "not_found.json"},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/1", Status: 200, File: "release.json"},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/2", Status: 500, Body: `{"message":"boom"}`},
+	})
+
+	client := newClient(t, srv, nil)
+
+	want := &git.Release{
+		ID:         "1",
+		CommitHash: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+		Tag:        "v1.0.0",
+		Artifacts:  []string{"buildrc-linux-amd64.tar.gz"},
+		Draft:      true,
+	}
+
+	rel, err := client.GetReleaseByTag(ctx, "v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, want, rel)
+
+	rel, err = client.GetReleaseByTag(ctx, "v9.9.9")
+	require.NoError(t, err)
+	assert.Nil(t, rel)
+
+	rel, err = client.GetReleaseByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, want, rel)
+
+	rel, err = client.GetReleaseByID(ctx, "3")
+	require.NoError(t, err)
+	assert.Nil(t, rel)
+
+	_, err = client.GetReleaseByID(ctx, "2")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, github.ErrNotFound)
+
+	ok, err := client.HasReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = client.HasReleaseArtifact(ctx, "1", "buildrc-darwin-arm64.tar.gz")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestTagRelease(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "POST", Path: "/repos/walteh/buildrc/releases", Status: 201, File: "release_created.json"},
+		{Method: "PATCH", Path: "/repos/walteh/buildrc/releases/4", Status: 200, File: "release_created.json"},
+	})
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return("6dcb09b5b57875f334f61aebed695e2e4193db5e", nil)
+
+	client := newClient(t, srv, gitp)
+
+	rel, err := client.TagRelease(ctx, gitp, semver.MustParse("1.3.0-rc.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "4", rel.ID)
+	assert.True(t, rel.Draft)
+
+	require.NoError(t, client.TakeReleaseOutOfDraft(ctx, "4"))
+
+	require.Len(t, srv.Requests(), 2)
+	assert.JSONEq(t, `{
+		"tag_name": "v1.3.0-rc.1",
+		"target_commitish": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+		"name": "v1.3.0-rc.1",
+		"draft": true,
+		"prerelease": true
+	}`, srv.Requests()[0].Body)
+	assert.JSONEq(t, `{"draft": false, "make_latest": "legacy"}`, srv.Requests()[1].Body)
+}
+
+func TestReleaseArtifacts(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "/api/v3", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/1", Status: 200, File: "release.json"},
+		{Method: "POST", Path: "/uploads/repos/walteh/buildrc/releases/1/assets", Status: 201, Body: `{"id": 11}`},
+		{Method: "DELETE", Path: "/repos/walteh/buildrc/releases/assets/10", Status: 204},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/assets/10", Status: 200, Body: "hello"},
+	})
+
+	client := newClient(t, srv, nil)
+
+	t.Run("upload", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "buildrc-darwin-arm64.tar.gz", []byte("hello world"), 0644))
+
+		fle, err := fs.Open("buildrc-darwin-arm64.tar.gz")
+		require.NoError(t, err)
+		defer fle.Close()
+
+		// the file is uploaded from the start, wherever it was left
+		_, err = fle.Seek(5, io.SeekStart)
+		require.NoError(t, err)
+
+		srv.Reset()
+		require.NoError(t, client.UploadReleaseArtifact(ctx, "1", "buildrc-darwin-arm64.tar.gz", fle))
+
+		require.Len(t, srv.Requests(), 2)
+		assert.Equal(t, "name=buildrc-darwin-arm64.tar.gz", srv.Requests()[1].Query)
+		assert.Equal(t, int64(11), srv.Requests()[1].Length)
+		assert.Equal(t, "hello world", srv.Requests()[1].Body)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, client.DeleteReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz"))
+
+		err := client.DeleteReleaseArtifact(ctx, "1", "missing.tar.gz")
+		assert.ErrorIs(t, err, github.ErrNotFound)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+
+		fle, err := client.DownloadReleaseArtifact(ctx, "1", "buildrc-linux-amd64.tar.gz", fs)
+		require.NoError(t, err)
+		defer fle.Close()
+
+		byt, err := io.ReadAll(fle)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(byt))
+
+		_, err = client.DownloadReleaseArtifact(ctx, "1", "missing.tar.gz", fs)
+		assert.ErrorIs(t, err, github.ErrNotFound)
+	})
+}
+
+func TestDetectOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		env    map[string]string
+		want   *github.Options
+		ok     bool
+	}{
+		{
+			name:   "github.com",
+			remote: "git@github.com:walteh/buildrc.git",
+			env:    map[string]string{"GITHUB_TOKEN": "abc"},
+			want:   &github.Options{Token: "abc", Owner: "walteh", Repo: "buildrc"},
+			ok:     true,
+		},
+		{
+			name:   "enterprise in actions",
+			remote: "https://github.example.com/walteh/buildrc.git",
+			env: map[string]string{
+				"GITHUB_SERVER_URL": "https://github.example.com",
+				"GITHUB_API_URL":    "https://github.example.com/api/v3",
+			},
+			want: &github.Options{BaseURL: "https://github.example.com/api/v3", Owner: "walteh", Repo: "buildrc"},
+			ok:   true,
+		},
+		{
+			name:   "another host",
+			remote: "https://gitlab.com/walteh/buildrc.git",
+			env:    map[string]string{"GITHUB_SERVER_URL": "https://github.com"},
+			want:   &github.Options{Owner: "walteh", Repo: "buildrc"},
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := git.ParseRemoteURL(tt.remote)
+			require.NoError(t, err)
+
+			got, ok := github.DetectOptions(remote, func(k string) string { return tt.env[k] })
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
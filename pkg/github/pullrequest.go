@@ -0,0 +1,99 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+type pullRequestPayload struct {
+	Number    int       `json:"number"`
+	State     string    `json:"state"`
+	MergedAt  *string   `json:"merged_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Head      struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+func (me *pullRequestPayload) pullRequest() *git.PullRequest {
+	return &git.PullRequest{
+		Number:  me.Number,
+		Head:    me.Head.SHA,
+		Open:    me.State == "open",
+		Closed:  me.MergedAt != nil,
+		Updated: me.UpdatedAt,
+	}
+}
+
+// ListRecentPullRequests lists the most recently updated pull requests. For HEAD, those are the
+// pull requests opened from the current branch, or the pull request the ci is building when HEAD
+// is detached. For any other branch those targeting it.
+func (me *Client) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "updated")
+	query.Set("direction", "desc")
+
+	if head == "HEAD" {
+		branch, number, err := ci.CheckedOutHead(ctx, me.gitp, me.ci)
+		if err != nil {
+			return nil, err
+		}
+
+		if number > 0 {
+			var p pullRequestPayload
+			if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath("pulls", strconv.FormatUint(number, 10)), nil, "", &p); err != nil {
+				return nil, err
+			}
+			return []*git.PullRequest{p.pullRequest()}, nil
+		}
+
+		query.Set("head", me.owner+":"+branch)
+	} else {
+		query.Set("base", head)
+	}
+
+	payloads, err := forgeapi.Paginate[pullRequestPayload](ctx, me.api, me.repoPath("pulls"), query, me.api.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := make([]*git.PullRequest, 0, len(payloads))
+	for i := range payloads {
+		prs = append(prs, payloads[i].pullRequest())
+	}
+
+	return prs, nil
+}
+
+// ListPullRequestsUpdatedSince lists the pull requests targeting the branch, requesting pages until
+// one was last updated before since.
+func (me *Client) ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "updated")
+	query.Set("direction", "desc")
+	query.Set("base", base)
+
+	payloads, err := forgeapi.PaginateUntil(ctx, me.api, me.repoPath("pulls"), query, func(p *pullRequestPayload) bool {
+		return p.UpdatedAt.Before(since)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prs := make([]*git.PullRequest, 0, len(payloads))
+	for i := range payloads {
+		prs = append(prs, payloads[i].pullRequest())
+	}
+
+	return prs, nil
+}
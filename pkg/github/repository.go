@@ -0,0 +1,40 @@
+package github
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+type repositoryPayload struct {
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	HTMLURL     string `json:"html_url"`
+	License     *struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
+}
+
+func (me *Client) GetRemoteRepositoryMetadata(ctx context.Context) (*git.RemoteRepositoryMetadata, error) {
+	var p repositoryPayload
+
+	if _, err := me.api.Do(ctx, http.MethodGet, me.repoPath(), nil, "", &p); err != nil {
+		return nil, err
+	}
+
+	meta := &git.RemoteRepositoryMetadata{
+		Description: p.Description,
+		Homepage:    p.Homepage,
+	}
+
+	if meta.Homepage == "" {
+		meta.Homepage = p.HTMLURL
+	}
+
+	if p.License != nil {
+		meta.License = p.License.SPDXID
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,123 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+const (
+	DefaultHost    = "gitlab.com"
+	DefaultPerPage = 100
+)
+
+var ErrNotFound = errors.New("gitlab.ErrNotFound")
+
+var (
+	_ git.PullRequestProvider              = (*Client)(nil)
+	_ git.ReleaseProvider                  = (*Client)(nil)
+	_ git.RemoteRepositoryMetadataProvider = (*Client)(nil)
+)
+
+type Options struct {
+	// BaseURL is the v4 API root, https://<host>/api/v4
+	BaseURL string
+	Token   string
+	// JobToken is the CI_JOB_TOKEN of a GitLab CI job, used when there is no Token
+	JobToken string
+	// Project is the full path of the project, including any subgroups
+	Project string
+	PerPage int
+	// CI is used to find the branch and merge request of a detached HEAD
+	CI ci.Detector
+}
+
+// Client is a GitLab v4 API client for a single project.
+type Client struct {
+	api     *forgeapi.Client
+	project string
+	gitp    git.GitProvider
+	ci      ci.Detector
+}
+
+// DetectOptions returns the options for the remote and whether it is hosted on GitLab, which is either
+// gitlab.com, a host named gitlab.*, or the server of the GitLab CI job that is running.
+func DetectOptions(remote *git.RemoteURL, getenv func(string) string) (*Options, bool) {
+	opts := &Options{
+		BaseURL: "https://" + remote.Host + "/api/v4",
+		Token:   getenv("GITLAB_TOKEN"),
+		Project: remote.Namespace + "/" + remote.Name,
+	}
+
+	if host := getenv("CI_SERVER_HOST"); host != "" && strings.EqualFold(host, remote.Host) {
+		if api := getenv("CI_API_V4_URL"); api != "" {
+			opts.BaseURL = api
+		}
+		opts.JobToken = getenv("CI_JOB_TOKEN")
+		return opts, true
+	}
+
+	return opts, remote.Host == DefaultHost || strings.HasPrefix(remote.Host, "gitlab.")
+}
+
+// NewClient returns a client for the project. The git provider is used to resolve the
+// branch of HEAD when listing its merge requests, falling back to the ci environment when detached.
+func NewClient(_ context.Context, gitp git.GitProvider, opts *Options) (*Client, error) {
+	if opts.Project == "" {
+		return nil, errors.Errorf("gitlab project is required")
+	}
+
+	base := opts.BaseURL
+	if base == "" {
+		base = "https://" + DefaultHost + "/api/v4"
+	}
+
+	if _, err := url.Parse(base); err != nil {
+		return nil, errors.Wrapf(err, "invalid gitlab api url '%s'", base)
+	}
+
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+
+	header := http.Header{}
+	if opts.Token != "" {
+		header.Set("PRIVATE-TOKEN", opts.Token)
+	} else if opts.JobToken != "" {
+		header.Set("JOB-TOKEN", opts.JobToken)
+	}
+
+	return &Client{
+		api: &forgeapi.Client{
+			HTTP:        &http.Client{},
+			BaseURL:     strings.TrimSuffix(base, "/"),
+			Name:        "gitlab",
+			Header:      header,
+			ErrNotFound: ErrNotFound,
+			Paging:      forgeapi.PagingNextPage,
+			PerPage:     perPage,
+		},
+		project: strings.Trim(opts.Project, "/"),
+		gitp:    gitp,
+		ci:      opts.CI,
+	}, nil
+}
+
+// projectPath returns the api path of the project joined with the elements. The project
+// is addressed by its url encoded full path.
+func (me *Client) projectPath(elems ...string) string {
+	parts := []string{"projects", url.PathEscape(me.project)}
+	return "/" + strings.Join(append(parts, elems...), "/")
+}
+
+// packageName is the name of the generic package release artifacts are uploaded to.
+func (me *Client) packageName() string {
+	return me.project[strings.LastIndex(me.project, "/")+1:]
+}
@@ -0,0 +1,97 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/walteh/buildrc/pkg/ci"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+type mergeRequestPayload struct {
+	IID       int       `json:"iid"`
+	State     string    `json:"state"`
+	SHA       string    `json:"sha"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// pullRequest maps the payload, only merged merge requests are reported as closed, as they are for GitHub.
+func (me *mergeRequestPayload) pullRequest() *git.PullRequest {
+	return &git.PullRequest{
+		Number:  me.IID,
+		Head:    me.SHA,
+		Open:    me.State == "opened",
+		Closed:  me.State == "merged",
+		Updated: me.UpdatedAt,
+	}
+}
+
+// ListRecentPullRequests lists the most recently updated merge requests. For HEAD, those are the
+// merge requests opened from the current branch, or the merge request the ci is building when HEAD
+// is detached. For any other branch those targeting it.
+func (me *Client) ListRecentPullRequests(ctx context.Context, head string) ([]*git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("order_by", "updated_at")
+	query.Set("sort", "desc")
+
+	if head == "HEAD" {
+		branch, number, err := ci.CheckedOutHead(ctx, me.gitp, me.ci)
+		if err != nil {
+			return nil, err
+		}
+
+		if number > 0 {
+			var p mergeRequestPayload
+			if _, err := me.api.Do(ctx, http.MethodGet, me.projectPath("merge_requests", strconv.FormatUint(number, 10)), nil, "", &p); err != nil {
+				return nil, err
+			}
+			return []*git.PullRequest{p.pullRequest()}, nil
+		}
+
+		query.Set("source_branch", branch)
+	} else {
+		query.Set("target_branch", head)
+	}
+
+	payloads, err := forgeapi.Paginate[mergeRequestPayload](ctx, me.api, me.projectPath("merge_requests"), query, me.api.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := make([]*git.PullRequest, 0, len(payloads))
+	for i := range payloads {
+		prs = append(prs, payloads[i].pullRequest())
+	}
+
+	return prs, nil
+}
+
+// ListPullRequestsUpdatedSince lists the merge requests targeting the branch, which GitLab filters
+// by their update time.
+func (me *Client) ListPullRequestsUpdatedSince(ctx context.Context, base string, since time.Time) ([]*git.PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("order_by", "updated_at")
+	query.Set("sort", "desc")
+	query.Set("target_branch", base)
+	if !since.IsZero() {
+		query.Set("updated_after", since.UTC().Format(time.RFC3339))
+	}
+
+	payloads, err := forgeapi.Paginate[mergeRequestPayload](ctx, me.api, me.projectPath("merge_requests"), query, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := make([]*git.PullRequest, 0, len(payloads))
+	for i := range payloads {
+		prs = append(prs, payloads[i].pullRequest())
+	}
+
+	return prs, nil
+}
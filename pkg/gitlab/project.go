@@ -0,0 +1,37 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+type projectPayload struct {
+	Description string `json:"description"`
+	WebURL      string `json:"web_url"`
+	License     *struct {
+		Key string `json:"key"`
+	} `json:"license"`
+}
+
+// GetRemoteRepositoryMetadata returns the project metadata. GitLab projects have no homepage,
+// so the web url is used, and the license is GitLab's key, the lowercased SPDX id for common licenses.
+func (me *Client) GetRemoteRepositoryMetadata(ctx context.Context) (*git.RemoteRepositoryMetadata, error) {
+	var p projectPayload
+
+	if _, err := me.api.Do(ctx, http.MethodGet, me.projectPath()+"?license=true", nil, "", &p); err != nil {
+		return nil, err
+	}
+
+	meta := &git.RemoteRepositoryMetadata{
+		Description: p.Description,
+		Homepage:    p.WebURL,
+	}
+
+	if p.License != nil {
+		meta.License = p.License.Key
+	}
+
+	return meta, nil
+}
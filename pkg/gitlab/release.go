@@ -0,0 +1,206 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+// draftReleasedAt is the release date of drafts. GitLab has no draft releases, so drafts are
+// created as upcoming releases and published by moving their release date to now.
+const draftReleasedAt = "9999-12-31T00:00:00Z"
+
+type releaseLinkPayload struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type releasePayload struct {
+	TagName         string `json:"tag_name"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Commit          struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Assets struct {
+		Links []releaseLinkPayload `json:"links"`
+	} `json:"assets"`
+}
+
+// release maps the payload, using the tag as the id as GitLab releases are addressed by their tag.
+func (me *releasePayload) release() *git.Release {
+	names := make([]string, 0, len(me.Assets.Links))
+	for _, l := range me.Assets.Links {
+		names = append(names, l.Name)
+	}
+
+	return &git.Release{
+		ID:         me.TagName,
+		CommitHash: me.Commit.ID,
+		Tag:        me.TagName,
+		Artifacts:  names,
+		Draft:      me.UpcomingRelease,
+	}
+}
+
+func (me *releasePayload) link(name string) *releaseLinkPayload {
+	for i := range me.Assets.Links {
+		if me.Assets.Links[i].Name == name {
+			return &me.Assets.Links[i]
+		}
+	}
+	return nil
+}
+
+func (me *Client) getRelease(ctx context.Context, tag string) (*releasePayload, error) {
+	var p releasePayload
+	if _, err := me.api.Do(ctx, http.MethodGet, me.projectPath("releases", url.PathEscape(tag)), nil, "", &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// packagePath is the generic package file the artifact of the release is stored in.
+func (me *Client) packagePath(tag string, name string) string {
+	return me.projectPath("packages", "generic", url.PathEscape(me.packageName()), url.PathEscape(tag), url.PathEscape(name))
+}
+
+// GetReleaseByTag returns the release for the tag, or nil if there is none.
+func (me *Client) GetReleaseByTag(ctx context.Context, tag string) (*git.Release, error) {
+	p, err := me.getRelease(ctx, tag)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return p.release(), nil
+}
+
+// GetReleaseByID is GetReleaseByTag, the id of a GitLab release is its tag.
+func (me *Client) GetReleaseByID(ctx context.Context, id string) (*git.Release, error) {
+	return me.GetReleaseByTag(ctx, id)
+}
+
+// ListRecentReleases returns up to limit releases, newest first. Upcoming releases are included as drafts.
+func (me *Client) ListRecentReleases(ctx context.Context, limit int) ([]*git.Release, error) {
+	query := url.Values{}
+	query.Set("order_by", "released_at")
+	query.Set("sort", "desc")
+
+	payloads, err := forgeapi.Paginate[releasePayload](ctx, me.api, me.projectPath("releases"), query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*git.Release, 0, len(payloads))
+	for i := range payloads {
+		releases = append(releases, payloads[i].release())
+	}
+
+	return releases, nil
+}
+
+// TagRelease creates a draft release for the version at the HEAD commit. Unlike GitHub, GitLab
+// creates the tag immediately.
+func (me *Client) TagRelease(ctx context.Context, prov git.GitProvider, vers *semver.Version) (*git.Release, error) {
+	commit, err := prov.GetCurrentCommitFromRef(ctx, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	tag := git.TagName(vers)
+
+	var p releasePayload
+
+	err = me.api.DoJSON(ctx, http.MethodPost, me.projectPath("releases"), map[string]any{
+		"tag_name":    tag,
+		"ref":         commit,
+		"name":        tag,
+		"released_at": draftReleasedAt,
+	}, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Debug().Str("tag", tag).Msg("created upcoming release")
+
+	return p.release(), nil
+}
+
+func (me *Client) TakeReleaseOutOfDraft(ctx context.Context, id string) error {
+	return me.api.DoJSON(ctx, http.MethodPut, me.projectPath("releases", url.PathEscape(id)), map[string]any{
+		"released_at": time.Now().UTC().Format(time.RFC3339),
+	}, nil)
+}
+
+func (me *Client) HasReleaseArtifact(ctx context.Context, id string, name string) (bool, error) {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return p.link(name) != nil, nil
+}
+
+// UploadReleaseArtifact stores the file in the generic package of the project, versioned by the
+// release tag, and links it to the release.
+func (me *Client) UploadReleaseArtifact(ctx context.Context, id string, name string, file afero.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	pkg := me.packagePath(id, name)
+
+	if _, err := me.api.Do(ctx, http.MethodPut, pkg, file, "application/octet-stream", nil); err != nil {
+		return err
+	}
+
+	return me.api.DoJSON(ctx, http.MethodPost, me.projectPath("releases", url.PathEscape(id), "assets", "links"), map[string]any{
+		"name":      name,
+		"url":       me.api.BaseURL + pkg,
+		"link_type": "package",
+	}, nil)
+}
+
+// DeleteReleaseArtifact removes the link from the release. The package file is kept.
+func (me *Client) DeleteReleaseArtifact(ctx context.Context, id string, name string) error {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	link := p.link(name)
+	if link == nil {
+		return errors.Wrapf(ErrNotFound, "artifact %s in release %s", name, id)
+	}
+
+	_, err = me.api.Do(ctx, http.MethodDelete, me.projectPath("releases", url.PathEscape(id), "assets", "links", strconv.FormatInt(link.ID, 10)), nil, "", nil)
+	return err
+}
+
+// DownloadReleaseArtifact writes the artifact to a file of the same name in the filesystem
+// and returns it, positioned at the start.
+func (me *Client) DownloadReleaseArtifact(ctx context.Context, id string, name string, filesystem afero.Fs) (afero.File, error) {
+	p, err := me.getRelease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.link(name) == nil {
+		return nil, errors.Wrapf(ErrNotFound, "artifact %s in release %s", name, id)
+	}
+
+	return me.api.Download(ctx, me.packagePath(id, name), "", filesystem, name)
+}
@@ -0,0 +1,51 @@
+package install
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/buildrc"
+	"github.com/walteh/buildrc/pkg/gitea"
+)
+
+type DownloadGiteaReleaseOptions struct {
+	// BaseURL is the API root, https://<host>/api/v1
+	BaseURL  string
+	Org      string
+	Name     string
+	Version  string
+	Token    string
+	Platform *buildrc.Platform
+}
+
+// DownloadGiteaReleaseWithOptions is DownloadGithubReleaseWithOptions for Gitea and Forgejo servers.
+func DownloadGiteaReleaseWithOptions(ctx context.Context, fls afero.Fs, opts *DownloadGiteaReleaseOptions) (afero.File, error) {
+
+	if opts.BaseURL == "" {
+		return nil, errors.Errorf("gitea api url is required")
+	}
+
+	version := "latest"
+	if opts.Version != "latest" {
+		version = "tags/" + url.PathEscape(opts.Version)
+	}
+
+	client := gitea.NewHTTPClient(ctx, opts.Token)
+
+	target := strings.TrimSuffix(opts.BaseURL, "/") + "/repos/" + url.PathEscape(opts.Org) + "/" + url.PathEscape(opts.Name) + "/releases/" + version
+
+	release, err := fetchRelease(ctx, client, target, "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "release for %s/%s at %s", opts.Org, opts.Name, opts.Version)
+	}
+
+	// gitea assets have no api url, they are downloaded from the browser url with the same credentials
+	for i := range release.Assets {
+		release.Assets[i].URL = release.Assets[i].BrowserDownloadURL
+	}
+
+	return downloadReleaseExecutable(ctx, client, fls, release, opts.Platform)
+}
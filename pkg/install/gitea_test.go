@@ -0,0 +1,101 @@
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/buildrc"
+)
+
+func targz(t *testing.T, name string, content string) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+func TestDownloadGiteaRelease(t *testing.T) {
+	ctx := context.Background()
+
+	archive := targz(t, "mytool", "#!/bin/sh\necho hello\n")
+
+	auths := []string{}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/v1/repos/platform/mytool/releases/latest", "/api/v1/repos/platform/mytool/releases/tags/v1.0.0":
+			_, _ = io.WriteString(w, `{
+				"id": 1,
+				"tag_name": "v1.0.0",
+				"assets": [
+					{"id": 2, "name": "mytool-windows-amd64.tar.gz", "browser_download_url": "`+srv.URL+`/platform/mytool/releases/download/v1.0.0/mytool-windows-amd64.tar.gz"},
+					{"id": 3, "name": "mytool-linux-amd64.tar.gz", "browser_download_url": "`+srv.URL+`/platform/mytool/releases/download/v1.0.0/mytool-linux-amd64.tar.gz"}
+				]
+			}`)
+		case "/platform/mytool/releases/download/v1.0.0/mytool-linux-amd64.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		version string
+		wantErr bool
+	}{
+		{name: "latest", version: "latest"},
+		{name: "tag", version: "v1.0.0"},
+		{name: "missing tag", version: "v2.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auths = []string{}
+
+			fle, err := DownloadGiteaReleaseWithOptions(ctx, afero.NewMemMapFs(), &DownloadGiteaReleaseOptions{
+				BaseURL:  srv.URL + "/api/v1",
+				Org:      "platform",
+				Name:     "mytool",
+				Version:  tt.version,
+				Token:    "abc",
+				Platform: &buildrc.Platform{OS: "linux", Arch: "amd64"},
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer fle.Close()
+
+			byt, err := io.ReadAll(fle)
+			require.NoError(t, err)
+
+			assert.Equal(t, "#!/bin/sh\necho hello\n", string(byt))
+			assert.Equal(t, []string{"token abc", "token abc"}, auths)
+		})
+	}
+}
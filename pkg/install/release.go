@@ -14,7 +14,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/walteh/buildrc/pkg/buildrc"
 	"github.com/walteh/buildrc/pkg/file"
-	"golang.org/x/oauth2"
+	"github.com/walteh/buildrc/pkg/github"
 )
 
 type payloadAsset struct {
@@ -52,27 +52,30 @@ func DownloadGithubRelease(ctx context.Context, fls afero.Fs, org string, name s
 
 func DownloadGithubReleaseWithOptions(ctx context.Context, fls afero.Fs, opts *DownloadGithubReleaseOptions) (afero.File, error) {
 
-	var err error
-
 	if opts.Version != "latest" {
 		opts.Version = "tags/" + opts.Version
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/repos/"+opts.Org+"/"+opts.Name+"/releases/"+opts.Version, nil)
+	client := github.NewHTTPClient(ctx, opts.Token)
+
+	release, err := fetchRelease(ctx, client, "https://api.github.com/repos/"+opts.Org+"/"+opts.Name+"/releases/"+opts.Version, "application/vnd.github.v3+json")
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "release for %s/%s at %s", opts.Org, opts.Name, opts.Version)
 	}
 
-	req.Header.Add("Accept", "application/vnd.github.v3+json")
+	return downloadReleaseExecutable(ctx, client, fls, release, opts.Platform)
+}
 
-	var client *http.Client
+// fetchRelease gets and decodes the release json at the url.
+func fetchRelease(ctx context.Context, client *http.Client, target string, accept string) (*payload, error) {
 
-	if opts.Token != "" {
-		client = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: opts.Token}))
-	} else {
-		client = &http.Client{}
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
 	}
 
+	req.Header.Add("Accept", accept)
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -87,7 +90,7 @@ func DownloadGithubReleaseWithOptions(ctx context.Context, fls afero.Fs, opts *D
 
 	if resp.StatusCode == 404 {
 		zerolog.Ctx(ctx).Debug().Err(err).RawJSON("response_body", body).Msg("not found")
-		return nil, errors.Errorf("release for %s/%s at %s not found", opts.Org, opts.Name, opts.Version)
+		return nil, errors.Errorf("not found")
 	}
 	if resp.StatusCode != 200 {
 		zerolog.Ctx(ctx).Debug().Err(err).RawJSON("response_body", body).Msg("bad status")
@@ -105,7 +108,14 @@ func DownloadGithubReleaseWithOptions(ctx context.Context, fls afero.Fs, opts *D
 
 	zerolog.Ctx(ctx).Debug().Interface("respdata", release).Msg("got respdata")
 
-	targetPlats := opts.Platform.Aliases()
+	return &release, nil
+}
+
+// downloadReleaseExecutable downloads the asset of the release for the platform, unpacks it
+// and returns the executable inside.
+func downloadReleaseExecutable(ctx context.Context, client *http.Client, fls afero.Fs, release *payload, platform *buildrc.Platform) (afero.File, error) {
+
+	targetPlats := platform.Aliases()
 
 	var dl payloadAsset
 
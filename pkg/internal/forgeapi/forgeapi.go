@@ -0,0 +1,276 @@
+package forgeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"golang.org/x/oauth2"
+)
+
+// Paging is how a list endpoint points at its next page.
+type Paging int
+
+const (
+	// PagingLink follows the url of the rel="next" Link header, as GitHub does
+	PagingLink Paging = iota
+	// PagingNextPage requests the page of the X-Next-Page header, as GitLab does
+	PagingNextPage
+	// PagingCount requests pages until one comes back short or the X-Total-Count header is reached, as Gitea does
+	PagingCount
+)
+
+// Client sends requests to the REST api of a forge, the forge packages only build the requests and payloads.
+type Client struct {
+	HTTP *http.Client
+	// BaseURL is the api root, without a trailing slash
+	BaseURL string
+	// Name is the forge in logs
+	Name string
+	// Header is set on every request, like the accept header or a token the http client does not send
+	Header http.Header
+	// ErrNotFound is wrapped by the error of a request answered with 404
+	ErrNotFound error
+
+	Paging  Paging
+	PerPage int
+	// PerPageParam is the query parameter of the page size, per_page if empty
+	PerPageParam string
+}
+
+// NewHTTPClient returns an http client that authenticates with the token of the type, bearer if empty,
+// if there is one.
+func NewHTTPClient(ctx context.Context, token string, tokenType string) *http.Client {
+	if token == "" {
+		return &http.Client{}
+	}
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token, TokenType: tokenType}))
+}
+
+// NewRequest returns a request with the headers of the client. The target may be a path relative to the
+// base url or an absolute url, as used for uploads and downloads.
+func (me *Client) NewRequest(ctx context.Context, method string, target string, body io.Reader) (*http.Request, error) {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = me.BaseURL + target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range me.Header {
+		req.Header[k] = v
+	}
+
+	// uploads are rejected without a content length, which is unknown for files
+	if f, ok := body.(interface{ Stat() (os.FileInfo, error) }); ok {
+		st, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = st.Size()
+	}
+
+	return req, nil
+}
+
+// Do sends a request to the api and decodes the json response into out, if out is not nil.
+func (me *Client) Do(ctx context.Context, method string, target string, body io.Reader, contentType string, out any) (*http.Response, error) {
+	req, err := me.NewRequest(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := me.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	byt, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Trace().Str("method", method).Str("url", req.URL.String()).Int("status", resp.StatusCode).Msgf("%s api request", me.Name)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp, errors.Wrapf(me.ErrNotFound, "%s %s", method, target)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		zerolog.Ctx(ctx).Debug().Str("response_body", string(byt)).Msg("bad status")
+		return resp, errors.Errorf("bad status for %s %s: %s", method, target, resp.Status)
+	}
+
+	if out != nil && len(byt) > 0 {
+		if err := json.Unmarshal(byt, out); err != nil {
+			return resp, errors.Wrapf(err, "decoding response of %s %s", method, target)
+		}
+	}
+
+	return resp, nil
+}
+
+// DoJSON sends the value as a json body.
+func (me *Client) DoJSON(ctx context.Context, method string, target string, in any, out any) error {
+	byt, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	_, err = me.Do(ctx, method, target, bytes.NewReader(byt), "application/json", out)
+	return err
+}
+
+// Download writes the body of a GET of the target to a file of the name in the filesystem and returns it,
+// positioned at the start. An empty accept keeps the accept header of the client.
+func (me *Client) Download(ctx context.Context, target string, accept string, filesystem afero.Fs, name string) (afero.File, error) {
+	req, err := me.NewRequest(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := me.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("bad status for GET to download artifact %s: %s", name, resp.Status)
+	}
+
+	fle, err := filesystem.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(fle, resp.Body); err != nil {
+		return nil, err
+	}
+
+	if _, err := fle.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return fle, nil
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// checkSameOrigin checks the url has the scheme and host of the base url, so the token of the http client
+// is not sent to wherever a response points.
+func (me *Client) checkSameOrigin(target string) error {
+	base, err := url.Parse(me.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return errors.Wrapf(err, "invalid next page url '%s'", target)
+	}
+
+	if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return errors.Errorf("next page url '%s' is not on the %s api at %s", target, me.Name, me.BaseURL)
+	}
+
+	return nil
+}
+
+// Paginate lists every page of a list endpoint, stopping after limit items if limit is positive.
+func Paginate[T any](ctx context.Context, me *Client, target string, query url.Values, limit int) ([]T, error) {
+	return paginate[T](ctx, me, target, query, limit, nil)
+}
+
+// PaginateUntil lists the pages of a list endpoint until done is true for an item, which is left out
+// with the items after it.
+func PaginateUntil[T any](ctx context.Context, me *Client, target string, query url.Values, done func(*T) bool) ([]T, error) {
+	return paginate(ctx, me, target, query, 0, done)
+}
+
+func paginate[T any](ctx context.Context, me *Client, target string, query url.Values, limit int, done func(*T) bool) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	param := me.PerPageParam
+	if param == "" {
+		param = "per_page"
+	}
+	query.Set(param, strconv.Itoa(me.PerPage))
+
+	if me.Paging != PagingLink {
+		query.Set("page", "1")
+	}
+
+	next := target + "?" + query.Encode()
+	all := []T{}
+
+	for page := 1; next != ""; page++ {
+		var items []T
+
+		resp, err := me.Do(ctx, http.MethodGet, next, nil, "", &items)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range items {
+			if done != nil && done(&items[i]) {
+				return all, nil
+			}
+			all = append(all, items[i])
+			if limit > 0 && len(all) >= limit {
+				return all, nil
+			}
+		}
+
+		next = ""
+
+		switch me.Paging {
+		case PagingLink:
+			if m := linkNext.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+				if err := me.checkSameOrigin(m[1]); err != nil {
+					return nil, err
+				}
+				next = m[1]
+			}
+		case PagingNextPage:
+			if p := resp.Header.Get("X-Next-Page"); p != "" {
+				query.Set("page", p)
+				next = target + "?" + query.Encode()
+			}
+		case PagingCount:
+			if len(items) < me.PerPage {
+				break
+			}
+			if total, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil && len(all) >= total {
+				break
+			}
+			query.Set("page", strconv.Itoa(page+1))
+			next = target + "?" + query.Encode()
+		}
+	}
+
+	return all, nil
+}
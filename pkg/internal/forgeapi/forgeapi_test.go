@@ -0,0 +1,117 @@
+package forgeapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi"
+)
+
+var errNotFound = errors.New("test.ErrNotFound")
+
+// newServer serves the numbers 1 to 5 from /items, two per page, pointing at the next page the way
+// the paging does.
+func newServer(t *testing.T, paging forgeapi.Paging) *forgeapi.Client {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/items" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		size, err := strconv.Atoi(r.URL.Query().Get("size"))
+		require.NoError(t, err)
+
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, err = strconv.Atoi(p)
+			require.NoError(t, err)
+		}
+
+		items := []int{}
+		for i := (page-1)*size + 1; i <= page*size && i <= 5; i++ {
+			items = append(items, i)
+		}
+
+		more := page*size < 5
+		switch paging {
+		case forgeapi.PagingLink:
+			if more {
+				w.Header().Set("Link", `<`+srv.URL+`/items?size=2&page=`+strconv.Itoa(page+1)+`>; rel="next"`)
+			}
+		case forgeapi.PagingNextPage:
+			if more {
+				w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+			}
+		case forgeapi.PagingCount:
+			w.Header().Set("X-Total-Count", "5")
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(items))
+	}))
+	t.Cleanup(srv.Close)
+
+	return &forgeapi.Client{
+		HTTP:         srv.Client(),
+		BaseURL:      srv.URL,
+		Name:         "test",
+		ErrNotFound:  errNotFound,
+		Paging:       paging,
+		PerPage:      2,
+		PerPageParam: "size",
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	for _, paging := range []forgeapi.Paging{forgeapi.PagingLink, forgeapi.PagingNextPage, forgeapi.PagingCount} {
+		t.Run(strconv.Itoa(int(paging)), func(t *testing.T) {
+			api := newServer(t, paging)
+
+			all, err := forgeapi.Paginate[int](context.Background(), api, "/items", nil, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []int{1, 2, 3, 4, 5}, all)
+
+			limited, err := forgeapi.Paginate[int](context.Background(), api, "/items", nil, 3)
+			require.NoError(t, err)
+			assert.Equal(t, []int{1, 2, 3}, limited)
+
+			until, err := forgeapi.PaginateUntil(context.Background(), api, "/items", nil, func(i *int) bool { return *i == 4 })
+			require.NoError(t, err)
+			assert.Equal(t, []int{1, 2, 3}, until)
+		})
+	}
+}
+
+func TestPaginateOtherOrigin(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request with the token sent to %s", r.URL)
+	}))
+	t.Cleanup(other.Close)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<`+other.URL+`/items?page=2>; rel="next"`)
+		require.NoError(t, json.NewEncoder(w).Encode([]int{1}))
+	}))
+	t.Cleanup(srv.Close)
+
+	api := &forgeapi.Client{HTTP: srv.Client(), BaseURL: srv.URL, Name: "test", Paging: forgeapi.PagingLink, PerPage: 1}
+
+	_, err := forgeapi.Paginate[int](context.Background(), api, "/items", nil, 0)
+	assert.ErrorContains(t, err, "is not on the test api")
+}
+
+func TestDoNotFound(t *testing.T) {
+	api := newServer(t, forgeapi.PagingLink)
+
+	_, err := api.Do(context.Background(), http.MethodGet, "/missing", nil, "", nil)
+	assert.ErrorIs(t, err, errNotFound)
+}
@@ -0,0 +1,121 @@
+// Package forgeapitest replays recorded forge api responses for the tests of the forge clients.
+package forgeapitest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Fixture is a recorded response, matched by method, escaped path below the api root and the raw query
+// if one is set.
+type Fixture struct {
+	Method string
+	Path   string
+	Query  string
+	Status int
+	// File is read from testdata, Body is used if there is none
+	File string
+	Body string
+	// Header is set on the response, like the paging headers
+	Header map[string]string
+}
+
+// Request is a request the server received.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Length int64
+	Body   string
+}
+
+type Server struct {
+	*httptest.Server
+	prefix   string
+	mu       sync.Mutex
+	requests []Request
+}
+
+// NewServer serves the fixtures below the prefix, replacing {{server}} in bodies and headers with the api url.
+func NewServer(t *testing.T, prefix string, fixtures []Fixture) *Server {
+	t.Helper()
+
+	srv := &Server{prefix: prefix}
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		byt, _ := io.ReadAll(r.Body)
+
+		srv.mu.Lock()
+		srv.requests = append(srv.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.RawQuery,
+			Header: r.Header.Clone(),
+			Length: r.ContentLength,
+			Body:   string(byt),
+		})
+		srv.mu.Unlock()
+
+		for _, f := range fixtures {
+			if f.Method != r.Method || prefix+f.Path != r.URL.EscapedPath() {
+				continue
+			}
+			if f.Query != "" && f.Query != r.URL.RawQuery {
+				continue
+			}
+
+			body := f.Body
+			if f.File != "" {
+				raw, err := os.ReadFile(filepath.Join("testdata", f.File))
+				require.NoError(t, err)
+				body = string(raw)
+			}
+
+			for k, v := range f.Header {
+				w.Header().Set(k, srv.expand(v))
+			}
+
+			w.WriteHeader(f.Status)
+			_, _ = w.Write([]byte(srv.expand(body)))
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// API is the url of the api root.
+func (me *Server) API() string {
+	return me.URL + me.prefix
+}
+
+func (me *Server) expand(s string) string {
+	return strings.ReplaceAll(s, "{{server}}", me.API())
+}
+
+// Requests returns the requests received since the server started or was reset.
+func (me *Server) Requests() []Request {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return append([]Request{}, me.requests...)
+}
+
+// Reset forgets the requests received so far.
+func (me *Server) Reset() {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.requests = nil
+}
@@ -0,0 +1,276 @@
+package release
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-faster/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/walteh/buildrc/pkg/file"
+	"github.com/walteh/buildrc/pkg/git"
+)
+
+const (
+	DefaultRetries    = 3
+	DefaultRetryDelay = 2 * time.Second
+)
+
+var (
+	ErrNoArtifacts         = errors.New("release.ErrNoArtifacts")
+	ErrDuplicateArtifact   = errors.New("release.ErrDuplicateArtifact")
+	ErrArtifactNotUploaded = errors.New("release.ErrArtifactNotUploaded")
+)
+
+type Options struct {
+	Version *semver.Version
+	// Artifacts are doublestar globs, matched against regular files only
+	Artifacts  []string
+	Retries    int
+	RetryDelay time.Duration
+}
+
+type Result struct {
+	Release *git.Release `json:"-"`
+	Tag     string       `json:"tag"`
+	// Skipped is set when HEAD was already released, Tag is then the existing release
+	Skipped   bool     `json:"skipped"`
+	Artifacts []string `json:"artifacts"`
+}
+
+// Publish releases HEAD as the version. The release is created as a draft, every artifact is archived,
+// checksummed and uploaded, and the draft is only promoted once all uploads are confirmed.
+// The draft left behind by a failed run is reused. If HEAD has already been released nothing is done.
+func Publish(ctx context.Context, fls afero.Fs, gitp git.GitProvider, relp git.ReleaseProvider, opts *Options) (*Result, error) {
+
+	exists, tag, err := git.ReleaseAlreadyExists(ctx, relp, gitp)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		zerolog.Ctx(ctx).Info().Str("tag", tag).Msg("commit already released, skipping")
+		return &Result{Tag: tag, Skipped: true, Artifacts: []string{}}, nil
+	}
+
+	// match before archiving, so the archives themselves are never matched
+	matches, err := expandArtifacts(fls, opts.Artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := draftRelease(ctx, gitp, relp, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := []string{}
+	for _, m := range matches {
+		archive, checksum, err := packageArtifact(ctx, fls, m)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, archive, checksum)
+	}
+
+	if err := checkUniqueNames(uploads); err != nil {
+		return nil, err
+	}
+
+	retries := opts.Retries
+	if retries <= 0 {
+		retries = DefaultRetries
+	}
+
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+
+	names := []string{}
+	for _, up := range uploads {
+		name := filepath.Base(up)
+		err := retry(ctx, retries, delay, func() error {
+			return upload(ctx, fls, relp, rel.ID, name, up)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "uploading %s", name)
+		}
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		ok, err := relp.HasReleaseArtifact(ctx, rel.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Wrapf(ErrArtifactNotUploaded, "%s in release %s", name, rel.Tag)
+		}
+	}
+
+	if err := relp.TakeReleaseOutOfDraft(ctx, rel.ID); err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("tag", rel.Tag).Int("artifacts", len(names)).Msg("published release")
+
+	return &Result{Release: rel, Tag: rel.Tag, Artifacts: names}, nil
+}
+
+// draftRelease returns the draft release of the version, creating it unless a failed run left one behind.
+// Drafts are looked up in the release list, GitHub does not return them by tag.
+func draftRelease(ctx context.Context, gitp git.GitProvider, relp git.ReleaseProvider, vers *semver.Version) (*git.Release, error) {
+	releases, err := relp.ListRecentReleases(ctx, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := git.TagName(vers)
+
+	for _, rel := range releases {
+		if rel.Tag == tag && rel.Draft {
+			zerolog.Ctx(ctx).Info().Str("tag", rel.Tag).Str("id", rel.ID).Msg("reusing draft release")
+			return rel, nil
+		}
+	}
+
+	rel, err := relp.TagRelease(ctx, gitp, vers)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("tag", rel.Tag).Str("id", rel.ID).Msg("created draft release")
+
+	return rel, nil
+}
+
+// expandArtifacts returns the regular files matching the globs, sorted and without duplicates.
+func expandArtifacts(fls afero.Fs, globs []string) ([]string, error) {
+	iofs := afero.NewIOFS(fls)
+
+	seen := map[string]bool{}
+	files := []string{}
+
+	for _, g := range globs {
+		matches, err := doublestar.Glob(iofs, filepath.ToSlash(filepath.Clean(g)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad artifact glob '%s'", g)
+		}
+
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+
+			st, err := fls.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+
+			if !st.Mode().IsRegular() {
+				continue
+			}
+
+			seen[m] = true
+			files = append(files, m)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, errors.Wrapf(ErrNoArtifacts, "no files match %v", globs)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// packageArtifact archives the file, unless it already is a tar.gz, and writes its checksum file.
+func packageArtifact(ctx context.Context, fls afero.Fs, pth string) (string, string, error) {
+	archive := pth
+
+	if !strings.HasSuffix(pth, ".tar.gz") && !strings.HasSuffix(pth, ".tgz") {
+		fle, err := file.Targz(ctx, fls, pth)
+		if err != nil {
+			return "", "", err
+		}
+		archive = fle.Name()
+		if err := fle.Close(); err != nil {
+			return "", "", err
+		}
+	}
+
+	sum, err := file.Sha256(ctx, fls, archive)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := sum.Close(); err != nil {
+		return "", "", err
+	}
+
+	return archive, sum.Name(), nil
+}
+
+func checkUniqueNames(paths []string) error {
+	seen := map[string]string{}
+	for _, p := range paths {
+		name := filepath.Base(p)
+		if prev, ok := seen[name]; ok {
+			return errors.Wrapf(ErrDuplicateArtifact, "%s and %s are both uploaded as %s", prev, p, name)
+		}
+		seen[name] = p
+	}
+	return nil
+}
+
+// upload uploads the file, first removing what a failed attempt may have left behind.
+func upload(ctx context.Context, fls afero.Fs, relp git.ReleaseProvider, id string, name string, pth string) error {
+	ok, err := relp.HasReleaseArtifact(ctx, id, name)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if err := relp.DeleteReleaseArtifact(ctx, id, name); err != nil {
+			return err
+		}
+	}
+
+	fle, err := fls.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer fle.Close()
+
+	return relp.UploadReleaseArtifact(ctx, id, name, fle)
+}
+
+// retry calls fn until it succeeds or has been called attempts times, waiting delay between calls.
+func retry(ctx context.Context, attempts int, delay time.Duration, fn func() error) error {
+	var err error
+
+	for i := 1; ; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if i >= attempts {
+			return err
+		}
+
+		zerolog.Ctx(ctx).Warn().Err(err).Int("attempt", i).Msg("retrying")
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), err.Error())
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package release_test
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-faster/errors"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/walteh/buildrc/gen/mockery"
+	"github.com/walteh/buildrc/pkg/git"
+	"github.com/walteh/buildrc/pkg/github"
+	"github.com/walteh/buildrc/pkg/internal/forgeapi/forgeapitest"
+	"github.com/walteh/buildrc/pkg/release"
+)
+
+const head = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+
+// uploads records what has been uploaded to the mocked release, failing the first upload
+// of the names in flaky.
+type uploads struct {
+	mu      sync.Mutex
+	files   map[string]string
+	flaky   map[string]bool
+	lost    map[string]bool
+	deleted []string
+}
+
+func newUploads() *uploads {
+	return &uploads{files: map[string]string{}, flaky: map[string]bool{}, lost: map[string]bool{}}
+}
+
+func (me *uploads) expect(relp *mockery.MockReleaseProvider_git) {
+	relp.EXPECT().UploadReleaseArtifact(mock.Anything, "1", mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, _ string, name string, fle afero.File) error {
+		me.mu.Lock()
+		defer me.mu.Unlock()
+
+		byt, err := io.ReadAll(fle)
+		if err != nil {
+			return err
+		}
+
+		if me.flaky[name] {
+			// the failed attempt leaves a partial upload behind
+			me.flaky[name] = false
+			me.files[name] = string(byt[:len(byt)/2])
+			return errors.New("connection reset")
+		}
+
+		if !me.lost[name] {
+			me.files[name] = string(byt)
+		}
+
+		return nil
+	}).Maybe()
+
+	relp.EXPECT().HasReleaseArtifact(mock.Anything, "1", mock.Anything).RunAndReturn(func(_ context.Context, _ string, name string) (bool, error) {
+		me.mu.Lock()
+		defer me.mu.Unlock()
+		_, ok := me.files[name]
+		return ok, nil
+	}).Maybe()
+
+	relp.EXPECT().DeleteReleaseArtifact(mock.Anything, "1", mock.Anything).RunAndReturn(func(_ context.Context, _ string, name string) error {
+		me.mu.Lock()
+		defer me.mu.Unlock()
+		delete(me.files, name)
+		me.deleted = append(me.deleted, name)
+		return nil
+	}).Maybe()
+}
+
+func (me *uploads) names() []string {
+	names := []string{}
+	for k := range me.files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newDist(t *testing.T) afero.Fs {
+	t.Helper()
+
+	fls := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fls, "dist/buildrc-linux-amd64", []byte("linux binary"), 0755))
+	require.NoError(t, afero.WriteFile(fls, "dist/sub/buildrc-darwin-arm64", []byte("darwin binary"), 0755))
+	require.NoError(t, afero.WriteFile(fls, "dist/source.tar.gz", []byte("already an archive"), 0644))
+	require.NoError(t, afero.WriteFile(fls, "README.md", []byte("not released"), 0644))
+
+	return fls
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+
+	vers := semver.MustParse("1.2.0")
+
+	tests := []struct {
+		name      string
+		artifacts []string
+		flaky     []string
+		lost      []string
+		released  bool
+		wantErr   error
+		wantNames []string
+		deleted   []string
+	}{
+		{
+			name:      "all artifacts",
+			artifacts: []string{"dist/**"},
+			wantNames: []string{
+				"buildrc-darwin-arm64.tar.gz", "buildrc-darwin-arm64.tar.gz.sha256",
+				"buildrc-linux-amd64.tar.gz", "buildrc-linux-amd64.tar.gz.sha256",
+				"source.tar.gz", "source.tar.gz.sha256",
+			},
+			deleted: []string{},
+		},
+		{
+			name:      "overlapping globs",
+			artifacts: []string{"dist/buildrc-*", "dist/**/buildrc-linux-*"},
+			wantNames: []string{"buildrc-linux-amd64.tar.gz", "buildrc-linux-amd64.tar.gz.sha256"},
+			deleted:   []string{},
+		},
+		{
+			name:      "failed upload is cleaned up and retried",
+			artifacts: []string{"dist/buildrc-*"},
+			flaky:     []string{"buildrc-linux-amd64.tar.gz"},
+			wantNames: []string{"buildrc-linux-amd64.tar.gz", "buildrc-linux-amd64.tar.gz.sha256"},
+			deleted:   []string{"buildrc-linux-amd64.tar.gz"},
+		},
+		{
+			name:      "missing upload keeps the draft",
+			artifacts: []string{"dist/buildrc-*"},
+			lost:      []string{"buildrc-linux-amd64.tar.gz.sha256"},
+			wantErr:   release.ErrArtifactNotUploaded,
+		},
+		{
+			name:      "no matches",
+			artifacts: []string{"bin/**"},
+			wantErr:   release.ErrNoArtifacts,
+		},
+		{
+			name:      "already released",
+			artifacts: []string{"dist/**"},
+			released:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fls := newDist(t)
+
+			gitp := mockery.NewMockGitProvider_git(t)
+			gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return(head, nil)
+
+			relp := mockery.NewMockReleaseProvider_git(t)
+
+			existing := []*git.Release{}
+			if tt.released {
+				existing = append(existing, &git.Release{ID: "0", Tag: "v1.1.0", CommitHash: head})
+			}
+			relp.EXPECT().ListRecentReleases(mock.Anything, 100).Return(existing, nil)
+
+			up := newUploads()
+			for _, f := range tt.flaky {
+				up.flaky[f] = true
+			}
+			for _, f := range tt.lost {
+				up.lost[f] = true
+			}
+			up.expect(relp)
+
+			if !tt.released && !errors.Is(tt.wantErr, release.ErrNoArtifacts) {
+				relp.EXPECT().TagRelease(mock.Anything, gitp, vers).Return(&git.Release{ID: "1", Tag: "v1.2.0", Draft: true}, nil)
+			}
+
+			if tt.wantErr == nil && !tt.released {
+				relp.EXPECT().TakeReleaseOutOfDraft(mock.Anything, "1").Return(nil)
+			}
+
+			res, err := release.Publish(ctx, fls, gitp, relp, &release.Options{
+				Version:    vers,
+				Artifacts:  tt.artifacts,
+				RetryDelay: time.Millisecond,
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.released {
+				assert.True(t, res.Skipped)
+				assert.Equal(t, "v1.1.0", res.Tag)
+				assert.Empty(t, up.names())
+				return
+			}
+
+			assert.Equal(t, "v1.2.0", res.Tag)
+			assert.ElementsMatch(t, tt.wantNames, res.Artifacts)
+			assert.Equal(t, tt.wantNames, up.names())
+			assert.ElementsMatch(t, tt.deleted, up.deleted)
+
+			for name, content := range up.files {
+				assert.NotEmpty(t, content, name)
+			}
+		})
+	}
+}
+
+// TestPublishResumesGitHubDraft replays a GitHub api with the draft of a failed run, which the tag endpoint
+// does not return as GitHub only returns published releases by tag.
+func TestPublishResumesGitHubDraft(t *testing.T) {
+	ctx := context.Background()
+
+	srv := forgeapitest.NewServer(t, "", []forgeapitest.Fixture{
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases", Status: 200, File: "github_releases.json"},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/tags/v1.2.0", Status: 404, File: "github_not_found.json"},
+		{Method: "GET", Path: "/repos/walteh/buildrc/releases/3", Status: 200, File: "github_draft.json"},
+		{Method: "DELETE", Path: "/repos/walteh/buildrc/releases/assets/30", Status: 204},
+		{Method: "DELETE", Path: "/repos/walteh/buildrc/releases/assets/31", Status: 204},
+		{Method: "POST", Path: "/repos/walteh/buildrc/releases/3/assets", Status: 201, Body: `{"id": 32}`},
+		{Method: "PATCH", Path: "/repos/walteh/buildrc/releases/3", Status: 200, File: "github_draft.json"},
+	})
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return(head, nil)
+
+	relp, err := github.NewClient(ctx, gitp, &github.Options{BaseURL: srv.API(), Token: "abc", Owner: "walteh", Repo: "buildrc"})
+	require.NoError(t, err)
+
+	rel, err := relp.GetReleaseByTag(ctx, "v1.2.0")
+	require.NoError(t, err)
+	assert.Nil(t, rel, "the tag endpoint does not return drafts")
+
+	srv.Reset()
+
+	res, err := release.Publish(ctx, newDist(t), gitp, relp, &release.Options{
+		Version:    semver.MustParse("1.2.0"),
+		Artifacts:  []string{"dist/source.tar.gz"},
+		RetryDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.2.0", res.Tag)
+	assert.Equal(t, []string{"source.tar.gz", "source.tar.gz.sha256"}, res.Artifacts)
+
+	calls := []string{}
+	for _, r := range srv.Requests() {
+		if r.Method != "GET" {
+			calls = append(calls, r.Method+" "+r.Path)
+		}
+	}
+
+	// no second draft is created, the uploads of the failed run are replaced and the draft is published
+	assert.Equal(t, []string{
+		"DELETE /repos/walteh/buildrc/releases/assets/30",
+		"POST /repos/walteh/buildrc/releases/3/assets",
+		"DELETE /repos/walteh/buildrc/releases/assets/31",
+		"POST /repos/walteh/buildrc/releases/3/assets",
+		"PATCH /repos/walteh/buildrc/releases/3",
+	}, calls)
+}
+
+func TestPublishChecksums(t *testing.T) {
+	ctx := context.Background()
+
+	fls := newDist(t)
+
+	gitp := mockery.NewMockGitProvider_git(t)
+	gitp.EXPECT().GetCurrentCommitFromRef(mock.Anything, "HEAD").Return(head, nil)
+
+	relp := mockery.NewMockReleaseProvider_git(t)
+	relp.EXPECT().ListRecentReleases(mock.Anything, 100).Return([]*git.Release{}, nil)
+	relp.EXPECT().TagRelease(mock.Anything, gitp, mock.Anything).Return(&git.Release{ID: "1", Tag: "v1.2.0", Draft: true}, nil)
+	relp.EXPECT().TakeReleaseOutOfDraft(mock.Anything, "1").Return(nil)
+
+	up := newUploads()
+	up.expect(relp)
+
+	_, err := release.Publish(ctx, fls, gitp, relp, &release.Options{
+		Version:   semver.MustParse("1.2.0"),
+		Artifacts: []string{"dist/source.tar.gz"},
+	})
+	require.NoError(t, err)
+
+	// existing archives are uploaded as they are
+	assert.Equal(t, "already an archive", up.files["source.tar.gz"])
+	assert.Equal(t, "6bd89483b6f9d279fd49d354b8394d383757e61a4edd485b6528b19c34d72e3d  source.tar.gz", up.files["source.tar.gz.sha256"])
+}